@@ -0,0 +1,396 @@
+package sap
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/pkg/errors"
+	"log"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// adminUserNameRegexp matches the email address an admin's user name must be, since XSUAA rejects shadow users
+// without one
+var adminUserNameRegexp = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+// accountAdmins manages the admins of a sub account or of a directory: the users of the account's identity
+// provider assigned to its administrator role collection.
+type accountAdmins struct {
+	// The name of the account kind, used in messages
+	kind string
+	// The attribute holding the id of the account, which is the id of the resource as well
+	idKey string
+	// The role collection granting administration of the account
+	roleCollection string
+	// Returns the subdomain of the account, which the host of its Authorization Service starts with, along with
+	// whether the account exists
+	subdomain func(ctx context.Context, client accountsClient, id string) (string, bool, error)
+}
+
+func (a *accountAdmins) schema(idDescription string) map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"endpoint_id":           endpointIdSchema("authorization_service"),
+		"authorization_service": endpointSchema("authorization_service"),
+
+		a.idKey: {
+			Type:         schema.TypeString,
+			Required:     true,
+			ForceNew:     true,
+			ValidateFunc: validation.StringIsNotWhiteSpace,
+			Description:  idDescription,
+		},
+		"admins": {
+			Type:     schema.TypeSet,
+			Required: true,
+			MinItems: 1,
+			Elem: &schema.Schema{
+				Type:         schema.TypeString,
+				ValidateFunc: validation.StringMatch(adminUserNameRegexp, "must be an email address"),
+			},
+			Set:         schema.HashString,
+			Description: "User names of the admins, their email addresses, within the identity provider given by 'origin'.",
+		},
+		"origin": {
+			Type:     schema.TypeString,
+			Optional: true,
+			ForceNew: true,
+			Default:  "ldap",
+			Description: "Origin key of the identity provider of the admins; 'ldap' for SAP ID Service. Users missing " +
+				"in XSUAA are created for SAP ID Service, or for an identity provider some user already logged on " +
+				"with, so that a misspelt origin fails.",
+		},
+		"authoritative": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  false,
+			Description: "Whether 'admins' is the complete list of admins, removing any other admin of the same " +
+				"identity provider; otherwise admins added outside of Terraform are kept. Either way, destroying " +
+				"the resource never leaves the account without any admin.",
+		},
+	}
+}
+
+func (a *accountAdmins) create(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if diags := a.apply(ctx, d, meta, "created"); diags.HasError() {
+		return diags
+	}
+	d.SetId(d.Get(a.idKey).(string))
+	return a.read(ctx, d, meta)
+}
+
+func (a *accountAdmins) read(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, found, err := a.client(ctx, d, meta)
+	if err != nil {
+		return diag.FromErr(errors.Errorf("BTP %s admins can't be read; %v", a.kind, err))
+	}
+	if !found {
+		log.Printf("[WARN] BTP %s %s not found, removing its admins from state", a.kind, d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	_, current, err := a.currentAdmins(ctx, client, d.Get("origin").(string))
+	if err != nil {
+		if isAuthorizationNotFound(err) {
+			log.Printf("[WARN] BTP %s %s admins not found, removing from state; %v", a.kind, d.Id(), err)
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(errors.Errorf("BTP %s admins can't be read; %v", a.kind, err))
+	}
+
+	admins := make([]string, 0, len(current))
+	if d.Get("authoritative").(bool) {
+		for userName := range current {
+			admins = append(admins, userName)
+		}
+	} else {
+		// Only the admins managed here, so that one removed outside of Terraform shows up as a change
+		for _, userName := range expandStringSet(d.Get("admins").(*schema.Set)) {
+			if _, ok := current[userName]; ok {
+				admins = append(admins, userName)
+			}
+		}
+	}
+	sort.Strings(admins)
+
+	d.Set(a.idKey, d.Id())
+	d.Set("admins", admins)
+	return nil
+}
+
+func (a *accountAdmins) update(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if d.HasChanges("admins", "authoritative") {
+		if diags := a.apply(ctx, d, meta, "updated"); diags.HasError() {
+			return diags
+		}
+	}
+	return a.read(ctx, d, meta)
+}
+
+// delete removes the admins held in state; in authoritative mode these are all the admins of the identity provider,
+// hence they're kept, with a warning, when they're the only members of the role collection left.
+func (a *accountAdmins) delete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, found, err := a.client(ctx, d, meta)
+	if err != nil {
+		return diag.FromErr(errors.Errorf("BTP %s admins can't be deleted; %v", a.kind, err))
+	}
+	if !found {
+		return nil
+	}
+
+	group, current, err := a.currentAdmins(ctx, client, d.Get("origin").(string))
+	if err != nil {
+		if isAuthorizationNotFound(err) {
+			return nil
+		}
+		return diag.FromErr(errors.Errorf("BTP %s admins can't be deleted; %v", a.kind, err))
+	}
+	removed := make([]string, 0, len(current))
+	for _, userName := range expandStringSet(d.Get("admins").(*schema.Set)) {
+		if _, ok := current[userName]; ok {
+			removed = append(removed, userName)
+		}
+	}
+	sort.Strings(removed)
+	if len(removed) > 0 && len(removed) == len(group.Members) {
+		// In authoritative mode these are every admin there is, including ones Terraform never added
+		log.Printf("[WARN] Keeping the admins of BTP %s %s, as removing them would leave it without any admin",
+			a.kind, d.Id())
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  fmt.Sprintf("BTP %s %s admins are kept", a.kind, d.Id()),
+			Detail: fmt.Sprintf("Removing %s would leave the %s role collection without any member; remove "+
+				"them in XSUAA once other admins are assigned.", strings.Join(removed, ", "), a.roleCollection),
+		}}
+	}
+
+	for _, userName := range removed {
+		userId := current[userName]
+		if err := client.removeGroupMember(ctx, &removeScimGroupMemberInput{
+			GroupId:  group.Id,
+			MemberId: userId,
+		}); err != nil && !isAuthorizationNotFound(err) {
+			return diag.FromErr(errors.Errorf("BTP %s admin %s can't be removed; %v", a.kind, userName, err))
+		}
+	}
+	return nil
+}
+
+// apply adds the configured admins which aren't yet, and removes the ones no longer configured; in authoritative
+// mode also the admins which were never configured. Removals follow only once every add succeeded, so that a
+// failure never leaves the account with fewer admins than configured.
+func (a *accountAdmins) apply(ctx context.Context, d *schema.ResourceData, meta interface{}, op string) diag.Diagnostics {
+	client, found, err := a.client(ctx, d, meta)
+	if err != nil {
+		return diag.FromErr(errors.Errorf("BTP %s admins can't be %s; %v", a.kind, op, err))
+	}
+	if !found {
+		return diag.Errorf("BTP %s admins can't be %s; BTP %s %s not found", a.kind, op, a.kind, d.Get(a.idKey))
+	}
+
+	origin := d.Get("origin").(string)
+	group, current, err := a.currentAdmins(ctx, client, origin)
+	if err != nil {
+		return diag.FromErr(errors.Errorf("BTP %s admins can't be %s; %v", a.kind, op, err))
+	}
+
+	oldAdmins, newAdmins := d.GetChange("admins")
+	wanted := newAdmins.(*schema.Set)
+
+	removed := expandStringSet(oldAdmins.(*schema.Set).Difference(wanted))
+	if d.Get("authoritative").(bool) {
+		removed = make([]string, 0, len(current))
+		for userName := range current {
+			if !wanted.Contains(userName) {
+				removed = append(removed, userName)
+			}
+		}
+	}
+	sort.Strings(removed)
+
+	for _, userName := range expandStringSet(wanted) {
+		if _, ok := current[userName]; ok {
+			continue
+		}
+		user, err := findOrCreateScimUser(ctx, client, userName, origin)
+		if err != nil {
+			return diag.FromErr(errors.Errorf("BTP %s admin %s can't be added; %v", a.kind, userName, err))
+		}
+		log.Printf("[INFO] Adding %s to the admins of BTP %s %s", userName, a.kind, d.Get(a.idKey))
+		if err := client.addGroupMember(ctx, &addScimGroupMemberInput{
+			GroupId: group.Id,
+			Origin:  origin,
+			Type:    "USER",
+			Value:   user.Id,
+		}); err != nil {
+			return diag.FromErr(errors.Errorf("BTP %s admin %s can't be added; %v", a.kind, userName, err))
+		}
+	}
+
+	for _, userName := range removed {
+		userId, ok := current[userName]
+		if !ok {
+			continue
+		}
+		log.Printf("[INFO] Removing %s from the admins of BTP %s %s", userName, a.kind, d.Get(a.idKey))
+		if err := client.removeGroupMember(ctx, &removeScimGroupMemberInput{
+			GroupId:  group.Id,
+			MemberId: userId,
+		}); err != nil && !isAuthorizationNotFound(err) {
+			return diag.FromErr(errors.Errorf("BTP %s admin %s can't be removed; %v", a.kind, userName, err))
+		}
+	}
+	return nil
+}
+
+// importState resolves the provider 'service_endpoint' referring to the Authorization Service of the account, whose
+// id is the one imported, and takes over all its admins of SAP ID Service as the managed ones.
+func (a *accountAdmins) importState(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	sapClient := meta.(*SAPClient)
+	id := d.Id()
+
+	subdomain, found, err := a.subdomain(ctx, sapClient.btpAccountsV1Client, id)
+	if err != nil {
+		return nil, errors.Errorf("BTP %s %s can't be read; %v", a.kind, id, err)
+	}
+	if !found {
+		return nil, errors.Errorf("BTP %s %s not found", a.kind, id)
+	}
+
+	endpointIds := make([]string, 0, 1)
+	for endpointId, cfg := range sapClient.endpoints {
+		if strings.EqualFold(endpointSubdomain(cfg.Host), subdomain) {
+			endpointIds = append(endpointIds, endpointId)
+		}
+	}
+	sort.Strings(endpointIds)
+	if len(endpointIds) != 1 {
+		return nil, errors.Errorf("BTP %s %s admins can't be imported; expecting exactly one provider "+
+			"'service_endpoint' with a host starting with subdomain '%s', found %v", a.kind, id, subdomain, endpointIds)
+	}
+
+	d.Set("endpoint_id", endpointIds[0])
+	d.Set(a.idKey, id)
+	d.Set("origin", "ldap")
+	d.Set("authoritative", false)
+
+	client, err := sapClient.authorizationClient(d, "authorization_service")
+	if err != nil {
+		return nil, errors.Errorf("BTP %s admins; Authorization Service OAuth2; %v", a.kind, err)
+	}
+	_, current, err := a.currentAdmins(ctx, client, "ldap")
+	if err != nil {
+		return nil, errors.Errorf("BTP %s admins can't be imported; %v", a.kind, err)
+	}
+	admins := make([]string, 0, len(current))
+	for userName := range current {
+		admins = append(admins, userName)
+	}
+	sort.Strings(admins)
+	d.Set("admins", admins)
+	return []*schema.ResourceData{d}, nil
+}
+
+// client returns the client of the Authorization Service the resource refers to, along with whether the account
+// exists. The endpoint must belong to the account: XSUAA knows nothing about the account's id, hence admins would
+// otherwise be changed on whichever account the endpoint happens to refer to.
+func (a *accountAdmins) client(ctx context.Context, d *schema.ResourceData, meta interface{}) (authorizationClient, bool, error) {
+	sapClient := meta.(*SAPClient)
+	id := d.Get(a.idKey).(string)
+
+	subdomain, found, err := a.subdomain(ctx, sapClient.btpAccountsV1Client, id)
+	if err != nil || !found {
+		return nil, found, err
+	}
+
+	cfg, err := sapClient.endpointConfig(d, "authorization_service")
+	if err != nil {
+		return nil, true, err
+	}
+	if hostSubdomain := endpointSubdomain(cfg.Host); !strings.EqualFold(hostSubdomain, subdomain) {
+		return nil, true, errors.Errorf("the Authorization Service endpoint '%s' doesn't belong to BTP %s %s, "+
+			"whose subdomain is '%s'", cfg.Host, a.kind, id, subdomain)
+	}
+
+	client, err := sapClient.authorizationClient(d, "authorization_service")
+	if err != nil {
+		return nil, true, errors.Errorf("Authorization Service OAuth2; %v", err)
+	}
+	return client, true, nil
+}
+
+// endpointSubdomain returns the first label of the endpoint's host, which for an Authorization Service is the
+// subdomain of its account, as in https://<subdomain>.authentication.eu10.hana.ondemand.com
+func endpointSubdomain(host string) string {
+	hostname := host
+	if u, err := url.Parse(host); err == nil && u.Hostname() != "" {
+		hostname = u.Hostname()
+	}
+	return strings.SplitN(hostname, ".", 2)[0]
+}
+
+// currentAdmins returns the administrator role collection along with its members from the given identity
+// provider, keyed by their user name, holding their user id.
+func (a *accountAdmins) currentAdmins(ctx context.Context, client authorizationClient,
+	origin string) (*scimGroup, map[string]string, error) {
+
+	group, err := client.getGroup(ctx, a.roleCollection)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := make([]string, 0, len(group.Members))
+	for _, member := range group.Members {
+		if member.Type == "USER" && member.Origin == origin {
+			ids = append(ids, fmt.Sprintf("id eq %q", member.Value))
+		}
+	}
+
+	admins := make(map[string]string, len(ids))
+	if len(ids) == 0 {
+		return group, admins, nil
+	}
+	users, err := client.getUsers(ctx, strings.Join(ids, " or "))
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, user := range users {
+		admins[user.UserName] = user.Id
+	}
+	return group, admins, nil
+}
+
+// findOrCreateScimUser returns the user of the identity provider, creating the shadow user XSUAA assigns role
+// collections to when it doesn't exist yet.
+func findOrCreateScimUser(ctx context.Context, client authorizationClient, userName, origin string) (*scimUser, error) {
+	users, err := client.getUsers(ctx, fmt.Sprintf("userName eq %q and origin eq %q", userName, origin))
+	if err != nil {
+		return nil, err
+	}
+	if len(users) > 0 {
+		return &users[0], nil
+	}
+
+	if origin != "ldap" {
+		// XSUAA takes shadow users of any origin, so one no user has logged on with yet is most likely misspelt
+		known, err := client.getUsers(ctx, fmt.Sprintf("origin eq %q", origin))
+		if err != nil {
+			return nil, err
+		}
+		if len(known) == 0 {
+			return nil, errors.Errorf("no user of identity provider '%s' is known to XSUAA; check 'origin'", origin)
+		}
+	}
+
+	return client.createUser(ctx, &scimUser{
+		UserName: userName,
+		Origin:   origin,
+		Emails:   []scimEmail{{Value: userName, Primary: true}},
+	})
+}
@@ -0,0 +1,64 @@
+package sap
+
+import (
+	"context"
+	"github.com/nnicora/sap-sdk-go/sap/http/request"
+	"github.com/nnicora/sap-sdk-go/service/btpaccounts"
+)
+
+// The sdk creates directories only within the global account, while the Accounts API takes the parent of the new
+// directory as the 'parentId' query parameter; hence directories are created through the request of accountsV1.
+// Likewise the sdk updates custom properties of directories without the 'delete' flag the API removes them with.
+
+type createDirectoryInput struct {
+	// The parent directory; the global account when not set
+	ParentId *string `dest:"querystring" dest-name:"parentId" json:"-"`
+
+	CustomProperties  []btpaccounts.KeyValue `json:"customProperties,omitempty"`
+	Description       string                 `json:"description,omitempty"`
+	DirectoryAdmins   []string               `json:"directoryAdmins,omitempty"`
+	DirectoryFeatures []string               `json:"directoryFeatures,omitempty"`
+	DisplayName       string                 `json:"displayName,omitempty"`
+	Subdomain         string                 `json:"subdomain,omitempty"`
+}
+
+type updateDirectoryInput struct {
+	DirectoryGuid string `dest:"uri" dest-name:"directoryGUID" json:"-"`
+
+	CustomProperties []btpaccounts.UpdateSubAccountProperties `json:"customProperties,omitempty"`
+	Description      string                                   `json:"description,omitempty"`
+	DisplayName      string                                   `json:"displayName,omitempty"`
+}
+
+// accountsV1 is the sdk client along with the requests the provider sends itself.
+type accountsV1 struct {
+	*btpaccounts.AccountsV1
+}
+
+func (c *accountsV1) createDirectory(ctx context.Context,
+	input *createDirectoryInput) (*btpaccounts.CreateDirectoryOutput, error) {
+
+	op := &request.Operation{
+		Name: "Create Account Directory",
+		Http: request.HTTP{
+			Method: request.POST,
+			Path:   "/directories",
+		},
+	}
+	output := &btpaccounts.CreateDirectoryOutput{}
+	return output, c.NewRequest(ctx, op, input, output).Send()
+}
+
+func (c *accountsV1) updateDirectory(ctx context.Context,
+	input *updateDirectoryInput) (*btpaccounts.UpdateDirectoryOutput, error) {
+
+	op := &request.Operation{
+		Name: "Update Account Directory",
+		Http: request.HTTP{
+			Method: request.PATCH,
+			Path:   "/directories/{directoryGUID}",
+		},
+	}
+	output := &btpaccounts.UpdateDirectoryOutput{}
+	return output, c.NewRequest(ctx, op, input, output).Send()
+}
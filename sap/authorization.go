@@ -0,0 +1,249 @@
+package sap
+
+import (
+	"context"
+	"fmt"
+	"github.com/nnicora/sap-sdk-go/sap/http/request"
+	"github.com/nnicora/sap-sdk-go/sap/http/request/processors/jsonbuiltin"
+	"github.com/nnicora/sap-sdk-go/sap/metainfo"
+	"github.com/nnicora/sap-sdk-go/sap/service"
+	"github.com/nnicora/sap-sdk-go/service/types"
+	"github.com/pkg/errors"
+	"net/http"
+	"strings"
+)
+
+// The Accounts API takes the admins of sub accounts and directories on creation only. They are the members of the
+// administrator role collections of the account's Authorization and Trust Management service (XSUAA), which the
+// sdk has no client for; hence they are managed through the SCIM requests of authorizationV1.
+
+// authorizationEndpointsId is the id of the XSUAA endpoint within an endpoint session
+const authorizationEndpointsId = "authorization"
+
+// authorizationError classifies a failed XSUAA call by its HTTP status and by the error it answered with.
+type authorizationError struct {
+	StatusCode  int32
+	Message     string
+	Description string
+	Cause       error
+}
+
+func (e *authorizationError) Error() string {
+	parts := make([]string, 0, 3)
+	if e.StatusCode > 0 {
+		parts = append(parts, fmt.Sprintf("Status Code %d", e.StatusCode))
+	}
+	if e.Message != "" {
+		parts = append(parts, e.Message)
+	}
+	if e.Description != "" {
+		parts = append(parts, e.Description)
+	}
+	if (e.Message == "" && e.Description == "") && e.Cause != nil {
+		parts = append(parts, e.Cause.Error())
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (e *authorizationError) Unwrap() error {
+	return e.Cause
+}
+
+// isAuthorizationNotFound tells whether err is an XSUAA answer that the resource doesn't exist
+func isAuthorizationNotFound(err error) bool {
+	var authErr *authorizationError
+	return errors.As(err, &authErr) && authErr.StatusCode == http.StatusNotFound
+}
+
+type scimGroupMember struct {
+	Origin string `json:"origin,omitempty"`
+	Type   string `json:"type,omitempty"`
+	// The id of the user
+	Value string `json:"value,omitempty"`
+}
+
+type scimGroup struct {
+	Id          string            `json:"id,omitempty"`
+	DisplayName string            `json:"displayName,omitempty"`
+	Members     []scimGroupMember `json:"members,omitempty"`
+}
+
+type scimEmail struct {
+	Value   string `json:"value,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type scimUser struct {
+	Id       string      `json:"id,omitempty"`
+	UserName string      `json:"userName,omitempty"`
+	Origin   string      `json:"origin,omitempty"`
+	Emails   []scimEmail `json:"emails,omitempty"`
+}
+
+type listScimInput struct {
+	Filter string `dest:"querystring" dest-name:"filter" json:"-"`
+}
+
+// The outputs spell out their fields, as the sdk reads the response into embedded structs only when exported
+
+type scimOutput struct {
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"error_description,omitempty"`
+
+	types.StatusAndBodyFromResponse
+}
+
+type listScimGroupsOutput struct {
+	Resources []scimGroup `json:"resources,omitempty"`
+
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"error_description,omitempty"`
+
+	types.StatusAndBodyFromResponse
+}
+
+type listScimUsersOutput struct {
+	Resources []scimUser `json:"resources,omitempty"`
+
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"error_description,omitempty"`
+
+	types.StatusAndBodyFromResponse
+}
+
+type createScimUserOutput struct {
+	Id       string `json:"id,omitempty"`
+	UserName string `json:"userName,omitempty"`
+	Origin   string `json:"origin,omitempty"`
+
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"error_description,omitempty"`
+
+	types.StatusAndBodyFromResponse
+}
+
+type addScimGroupMemberInput struct {
+	GroupId string `dest:"uri" dest-name:"groupId" json:"-"`
+
+	Origin string `json:"origin,omitempty"`
+	Type   string `json:"type,omitempty"`
+	Value  string `json:"value,omitempty"`
+}
+
+type removeScimGroupMemberInput struct {
+	GroupId  string `dest:"uri" dest-name:"groupId" json:"-"`
+	MemberId string `dest:"uri" dest-name:"memberId" json:"-"`
+}
+
+// authorizationV1 sends the SCIM requests of an XSUAA endpoint.
+type authorizationV1 struct {
+	*service.Requester
+}
+
+func newAuthorizationV1(p service.RequesterConfig) *authorizationV1 {
+	c, err := p.ServiceConfig(authorizationEndpointsId)
+	if err != nil {
+		c.Processors.Using(request.Validate).PushFrontHandler(func(t interface{}) {
+			r := t.(*request.Request)
+			r.Error = err
+		})
+	}
+
+	c.Processors.Using(request.Build).
+		PushBack(&jsonbuiltin.BuildProcessor).
+		PushBack(&jsonbuiltin.MarshalToRequestJSONBodyProcessor)
+	c.Processors.Using(request.Unmarshal).
+		PushBack(&jsonbuiltin.UnmarshalResponseJSONBodyProcessor)
+	c.Processors.Using(request.UnmarshalError).
+		PushBack(&jsonbuiltin.UnmarshalErrorResponseJSONBodyProcessor)
+
+	return &authorizationV1{
+		Requester: service.NewRequester(
+			c.RuntimeConfig,
+			metainfo.ServiceInfo{
+				ServiceName: "Authorization and Trust Management Service",
+				ServiceID:   authorizationEndpointsId,
+				Endpoint:    c.Endpoint,
+			},
+			c.Processors,
+		),
+	}
+}
+
+// getGroup returns the group, a role collection, having the given name
+func (c *authorizationV1) getGroup(ctx context.Context, displayName string) (*scimGroup, error) {
+	input := &listScimInput{
+		Filter: fmt.Sprintf("displayName eq %q", displayName),
+	}
+	output := &listScimGroupsOutput{}
+	if err := sendAuthorization(ctx, c, request.GET, "/Groups", input, output); err != nil {
+		return nil, newAuthorizationError(err, output.Error, output.ErrorDescription, output.StatusAndBodyFromResponse)
+	}
+	if len(output.Resources) < 1 {
+		return nil, &authorizationError{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("role collection '%s' not found", displayName),
+		}
+	}
+	return &output.Resources[0], nil
+}
+
+// getUsers returns the users matching the SCIM filter
+func (c *authorizationV1) getUsers(ctx context.Context, filter string) ([]scimUser, error) {
+	output := &listScimUsersOutput{}
+	if err := sendAuthorization(ctx, c, request.GET, "/Users", &listScimInput{Filter: filter}, output); err != nil {
+		return nil, newAuthorizationError(err, output.Error, output.ErrorDescription, output.StatusAndBodyFromResponse)
+	}
+	return output.Resources, nil
+}
+
+// createUser creates the shadow user XSUAA requires before assigning role collections to a user
+func (c *authorizationV1) createUser(ctx context.Context, user *scimUser) (*scimUser, error) {
+	output := &createScimUserOutput{}
+	if err := sendAuthorization(ctx, c, request.POST, "/Users", user, output); err != nil {
+		return nil, newAuthorizationError(err, output.Error, output.ErrorDescription, output.StatusAndBodyFromResponse)
+	}
+	return &scimUser{Id: output.Id, UserName: output.UserName, Origin: output.Origin}, nil
+}
+
+func (c *authorizationV1) addGroupMember(ctx context.Context, input *addScimGroupMemberInput) error {
+	output := &scimOutput{}
+	if err := sendAuthorization(ctx, c, request.POST, "/Groups/{groupId}/members", input, output); err != nil {
+		return newAuthorizationError(err, output.Error, output.ErrorDescription, output.StatusAndBodyFromResponse)
+	}
+	return nil
+}
+
+func (c *authorizationV1) removeGroupMember(ctx context.Context, input *removeScimGroupMemberInput) error {
+	output := &scimOutput{}
+	if err := sendAuthorization(ctx, c, request.DELETE, "/Groups/{groupId}/members/{memberId}", input,
+		output); err != nil {
+		return newAuthorizationError(err, output.Error, output.ErrorDescription, output.StatusAndBodyFromResponse)
+	}
+	return nil
+}
+
+func newAuthorizationError(err error, message, description string,
+	response types.StatusAndBodyFromResponse) *authorizationError {
+
+	return &authorizationError{
+		StatusCode:  response.StatusCode,
+		Message:     message,
+		Description: description,
+		Cause:       err,
+	}
+}
+
+func sendAuthorization(ctx context.Context, client *authorizationV1, method request.HTTPMethod,
+	path string, input, output interface{}) error {
+
+	op := &request.Operation{
+		Name: "Authorization",
+		Http: request.HTTP{
+			Method:      method,
+			Path:        path,
+			UsePathAsIs: true,
+		},
+	}
+	return client.NewRequest(ctx, op, input, output).Send()
+}
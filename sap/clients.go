@@ -0,0 +1,226 @@
+package sap
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/nnicora/sap-sdk-go/sap"
+	"github.com/nnicora/sap-sdk-go/sap/oauth2"
+	"github.com/nnicora/sap-sdk-go/sap/session"
+	"github.com/nnicora/sap-sdk-go/service/btpaccounts"
+	"github.com/nnicora/sap-sdk-go/service/btpentitlements"
+	"github.com/nnicora/sap-sdk-go/service/btpmanagment"
+	"github.com/nnicora/sap-sdk-go/service/btpprovisioning"
+	"github.com/nnicora/sap-sdk-go/service/btpsaasmanager"
+	"sync"
+)
+
+// The interfaces below hold only the operations of the BTP APIs the provider calls, so resources can be tested
+// against fakes; the sdk clients satisfy them.
+
+type accountsClient interface {
+	GetGlobalAccount(ctx context.Context, input *btpaccounts.GetGlobalAccountInput) (*btpaccounts.GlobalAccountOutput, error)
+
+	GetSubAccounts(ctx context.Context, input *btpaccounts.GetSubAccountsInput) (*btpaccounts.GetSubAccountsOutput, error)
+	CreateSubAccount(ctx context.Context, input *btpaccounts.CreateSubAccountInput) (*btpaccounts.CreateSubAccountOutput, error)
+	GetSubAccount(ctx context.Context, input *btpaccounts.GetSubAccountInput) (*btpaccounts.GetSubAccountOutput, error)
+	UpdateSubAccount(ctx context.Context, input *btpaccounts.UpdateSubAccountInput) (*btpaccounts.UpdateSubAccountOutput, error)
+	DeleteSubAccount(ctx context.Context, input *btpaccounts.DeleteSubAccountInput) (*btpaccounts.DeleteSubAccountOutput, error)
+	MoveSubAccount(ctx context.Context, input *btpaccounts.MoveSubAccountInput) (*btpaccounts.MoveSubAccountOutput, error)
+	GetSubAccountCustomProperties(ctx context.Context, input *btpaccounts.GetCustomPropertiesInput) (*btpaccounts.GetCustomPropertiesOutput, error)
+
+	CreateSubAccountServiceManagementBinding(ctx context.Context, input *btpaccounts.CreateServiceManagementBindingInput) (*btpaccounts.CreateServiceManagementBindingOutput, error)
+	GetSubAccountServiceManagementBinding(ctx context.Context, input *btpaccounts.GetServiceManagementBindingInput) (*btpaccounts.GetServiceManagementBindingOutput, error)
+	DeleteSubAccountServiceManagementBinding(ctx context.Context, input *btpaccounts.DeleteServiceManagementBindingInput) (*btpaccounts.DeleteServiceManagementBindingOutput, error)
+
+	GetDirectory(ctx context.Context, input *btpaccounts.GetDirectoryInput) (*btpaccounts.GetDirectoryOutput, error)
+	DeleteDirectory(ctx context.Context, input *btpaccounts.DeleteDirectoryInput) (*btpaccounts.DeleteDirectoryOutput, error)
+	UpdateDirectoryFeatures(ctx context.Context, input *btpaccounts.UpdateDirectoryFeaturesInput) (*btpaccounts.UpdateDirectoryFeaturesOutput, error)
+	GetDirectorCustomProperties(ctx context.Context, input *btpaccounts.GetDirectoryCustomPropertiesInput) (*btpaccounts.GetDirectoryCustomPropertiesOutput, error)
+
+	// Sent by the provider itself, see accountsV1
+	createDirectory(ctx context.Context, input *createDirectoryInput) (*btpaccounts.CreateDirectoryOutput, error)
+	updateDirectory(ctx context.Context, input *updateDirectoryInput) (*btpaccounts.UpdateDirectoryOutput, error)
+}
+
+type entitlementsClient interface {
+	GetAssignments(ctx context.Context, input *btpentitlements.GetAssignmentsInput) (*btpentitlements.GetAssignmentsOutput, error)
+	GetGlobalAccountAssignments(ctx context.Context, input *btpentitlements.GlobalAccountAssignmentsInput) (*btpentitlements.GlobalAccountAssignmentsOutput, error)
+	UpdateSubAccountServicePlan(ctx context.Context, input *btpentitlements.UpdateSubAccountServicePlanInput) (*btpentitlements.UpdateSubAccountServicePlanOutput, error)
+	UpdateDirectoryEntitlements(ctx context.Context, input *btpentitlements.UpdateDirectoryEntitlementsInput) (*btpentitlements.UpdateDirectoryEntitlementsOutput, error)
+	GetJobStatus(ctx context.Context, input *btpentitlements.GetJobStatusInput) (*btpentitlements.GetJobStatusOutput, error)
+}
+
+type provisioningClient interface {
+	GetAvailableEnvironments(ctx context.Context) (*btpprovisioning.GetAvailableEnvironmentsOutput, error)
+	GetEnvironmentInstances(ctx context.Context) (*btpprovisioning.GetEnvironmentInstancesOutput, error)
+	CreateEnvironmentInstance(ctx context.Context, input *btpprovisioning.CreateEnvironmentInstanceInput) (*btpprovisioning.CreateEnvironmentInstancesOutput, error)
+	GetEnvironmentInstance(ctx context.Context, input *btpprovisioning.GetEnvironmentInstanceInput) (*btpprovisioning.GetEnvironmentInstanceOutput, error)
+	UpdateEnvironmentInstance(ctx context.Context, input *btpprovisioning.UpdateEnvironmentInstanceInput) (*btpprovisioning.UpdateEnvironmentInstanceOutput, error)
+	DeleteEnvironmentInstance(ctx context.Context, input *btpprovisioning.DeleteEnvironmentInstanceInput) (*btpprovisioning.DeleteEnvironmentInstanceOutput, error)
+}
+
+type saasManagerClient interface {
+	GetApplicationRegistration(ctx context.Context, input *btpsaasmanager.GetApplicationRegistrationInput) (*btpsaasmanager.GetApplicationRegistrationOutput, error)
+	GetApplicationSubscriptions(ctx context.Context, input *btpsaasmanager.GetApplicationSubscriptionsInput) (*btpsaasmanager.GetApplicationSubscriptionsOutput, error)
+	SubscribeTenantToApplication(ctx context.Context, input *btpsaasmanager.SubscribeTenantToApplicationInput) (*btpsaasmanager.SubscribeTenantToApplicationOutput, error)
+	UpdateSubscriptionDependencies(ctx context.Context, input *btpsaasmanager.UpdateSubscriptionDependenciesInput) (*btpsaasmanager.UpdateSubscriptionDependenciesOutput, error)
+	UnSubscribeTenantFromApplication(ctx context.Context, input *btpsaasmanager.UnSubscribeTenantFromApplicationInput) (*btpsaasmanager.UnSubscribeTenantFromApplicationOutput, error)
+	GetJobStatus(ctx context.Context, input *btpsaasmanager.GetJobStatusInput) (*btpsaasmanager.GetJobStatusOutput, error)
+}
+
+// authorizationClient is the SCIM API of an XSUAA, see authorizationV1
+type authorizationClient interface {
+	getGroup(ctx context.Context, displayName string) (*scimGroup, error)
+	getUsers(ctx context.Context, filter string) ([]scimUser, error)
+	createUser(ctx context.Context, user *scimUser) (*scimUser, error)
+	addGroupMember(ctx context.Context, input *addScimGroupMemberInput) error
+	removeGroupMember(ctx context.Context, input *removeScimGroupMemberInput) error
+}
+
+type serviceManagementClient interface {
+	GetServiceOfferings(ctx context.Context, input *btpmanagment.GetServiceOfferingsInput) (*btpmanagment.GetServiceOfferingsOutput, error)
+	GetServiceOffering(ctx context.Context, input *btpmanagment.GetServiceOfferingInput) (*btpmanagment.GetServiceOfferingOutput, error)
+	GetServicePlan(ctx context.Context, input *btpmanagment.GetServicePlanInput) (*btpmanagment.GetServicePlanOutput, error)
+	GetServicePlans(ctx context.Context, input *btpmanagment.GetServicePlansInput) (*btpmanagment.GetServicePlansOutput, error)
+
+	GetServiceInstances(ctx context.Context, input *btpmanagment.GetServiceInstancesInput) (*btpmanagment.GetServiceInstancesOutput, error)
+	GetServiceInstance(ctx context.Context, input *btpmanagment.GetServiceInstanceInput) (*btpmanagment.GetServiceInstanceOutput, error)
+	GetServiceBindings(ctx context.Context, input *btpmanagment.GetServiceBindingsInput) (*btpmanagment.GetServiceBindingsOutput, error)
+	GetServiceBinding(ctx context.Context, input *btpmanagment.GetServiceBindingInput) (*btpmanagment.GetServiceBindingOutput, error)
+	GetOperationStatus(ctx context.Context, input *btpmanagment.GetOperationStatusInput) (*btpmanagment.GetOperationStatusOutput, error)
+
+	CreatePlatform(ctx context.Context, input *btpmanagment.CreatePlatformInput) (*btpmanagment.CreatePlatformOutput, error)
+	GetPlatform(ctx context.Context, input *btpmanagment.GetPlatformInput) (*btpmanagment.GetPlatformOutput, error)
+	UpdatePlatform(ctx context.Context, input *btpmanagment.UpdatePlatformInput) (*btpmanagment.UpdatePlatformOutput, error)
+	DeletePlatform(ctx context.Context, input *btpmanagment.DeletePlatformInput) (*btpmanagment.DeletePlatformOutput, error)
+
+	// Sent by the provider itself, see serviceManagementV1
+	createServiceInstance(ctx context.Context, input *btpmanagment.CreateServiceInstanceInput) (*createServiceInstanceOutput, error)
+	updateServiceInstance(ctx context.Context, input *updateServiceInstanceInput) (*updateServiceInstanceOutput, error)
+	deleteServiceInstance(ctx context.Context, input *btpmanagment.DeleteServiceInstanceInput) (*deleteServiceInstanceOutput, error)
+	createServiceBinding(ctx context.Context, input *btpmanagment.CreateServiceBindingInput) (*createServiceBindingOutput, error)
+	deleteServiceBinding(ctx context.Context, input *btpmanagment.DeleteServiceBindingInput) (*deleteServiceBindingOutput, error)
+	getServicePlans(ctx context.Context, input *btpmanagment.GetServicePlansInput) (*getServicePlansOutput, error)
+}
+
+// clientFactory builds the clients SAPClient hands over to resources and data sources. Accounts and
+// Entitlements go through the provider level endpoints, while the other APIs through the endpoint a resource
+// refers to.
+type clientFactory interface {
+	accounts() accountsClient
+	entitlements() entitlementsClient
+	serviceManagement(cfg *sap.EndpointConfig) (serviceManagementClient, error)
+	provisioning(cfg *sap.EndpointConfig) (provisioningClient, error)
+	saasManager(cfg *sap.EndpointConfig) (saasManagerClient, error)
+	authorization(cfg *sap.EndpointConfig) (authorizationClient, error)
+}
+
+// sessionClientFactory builds the sdk clients on top of sessions: the provider's one, and isolated sessions for
+// the endpoints resources refer to.
+type sessionClientFactory struct {
+	session *session.RuntimeSession
+
+	// OAuth2 configuration used by endpoint blocks which don't define their own
+	defaultOAuth2 *oauth2.Config
+
+	// Isolated sessions for endpoints declared on resources, keyed by endpoint id, host and client id
+	endpointSessionsLock sync.Mutex
+	endpointSessions     map[string]*session.RuntimeSession
+}
+
+func (f *sessionClientFactory) accounts() accountsClient {
+	return &accountsV1{btpaccounts.New(f.session)}
+}
+
+func (f *sessionClientFactory) entitlements() entitlementsClient {
+	return btpentitlements.New(f.session)
+}
+
+func (f *sessionClientFactory) serviceManagement(cfg *sap.EndpointConfig) (serviceManagementClient, error) {
+	sess, err := f.endpointSession(btpmanagment.EndpointsID, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &serviceManagementV1{btpmanagment.New(sess)}, nil
+}
+
+func (f *sessionClientFactory) provisioning(cfg *sap.EndpointConfig) (provisioningClient, error) {
+	sess, err := f.endpointSession(btpprovisioning.EndpointsID, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return btpprovisioning.New(sess), nil
+}
+
+func (f *sessionClientFactory) saasManager(cfg *sap.EndpointConfig) (saasManagerClient, error) {
+	sess, err := f.endpointSession(btpsaasmanager.EndpointsID, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return btpsaasmanager.New(sess), nil
+}
+
+func (f *sessionClientFactory) authorization(cfg *sap.EndpointConfig) (authorizationClient, error) {
+	sess, err := f.endpointSession(authorizationEndpointsId, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newAuthorizationV1(sess), nil
+}
+
+// endpointSession returns a session holding only the given endpoint. Sessions are cached, so resources pointing
+// to the same host with the same OAuth2 configuration share the token, while different ones never see each other.
+// The configuration of the caller is left untouched.
+func (f *sessionClientFactory) endpointSession(endpointId string, cfg *sap.EndpointConfig) (*session.RuntimeSession, error) {
+	endpointCfg := *cfg
+	if endpointCfg.OAuth2 == nil {
+		endpointCfg.OAuth2 = f.defaultOAuth2
+	}
+	if endpointCfg.OAuth2 == nil {
+		return nil, fmt.Errorf("no OAuth2 configuration for endpoint '%s'", cfg.Host)
+	}
+	oauth2Cfg := *endpointCfg.OAuth2
+	endpointCfg.OAuth2 = &oauth2Cfg
+
+	key, err := endpointSessionKey(endpointId, &endpointCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	f.endpointSessionsLock.Lock()
+	defer f.endpointSessionsLock.Unlock()
+
+	if sess, ok := f.endpointSessions[key]; ok {
+		return sess, nil
+	}
+
+	sess, err := session.BuildFromConfig(&sap.Config{
+		Endpoints: map[string]*sap.EndpointConfig{
+			endpointId: &endpointCfg,
+		},
+		DefaultOAuth2: endpointCfg.OAuth2,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if f.endpointSessions == nil {
+		f.endpointSessions = make(map[string]*session.RuntimeSession)
+	}
+	f.endpointSessions[key] = sess
+	return sess, nil
+}
+
+// endpointSessionKey identifies the session of an endpoint by its host and every OAuth2 setting, so rotated
+// credentials never reuse a stale session; the settings are hashed to keep the secrets out of the key.
+func endpointSessionKey(endpointId string, cfg *sap.EndpointConfig) (string, error) {
+	oauth2Bytes, err := json.Marshal(cfg.OAuth2)
+	if err != nil {
+		return "", fmt.Errorf("OAuth2 configuration for endpoint '%s' can't be encoded; %v", cfg.Host, err)
+	}
+	hash := sha256.Sum256(oauth2Bytes)
+	return endpointId + "|" + cfg.Host + "|" + hex.EncodeToString(hash[:]), nil
+}
@@ -0,0 +1,665 @@
+package sap
+
+import (
+	"context"
+	"fmt"
+	"github.com/nnicora/sap-sdk-go/sap"
+	"github.com/nnicora/sap-sdk-go/sap/oauth2"
+	"github.com/nnicora/sap-sdk-go/service/btpaccounts"
+	"github.com/nnicora/sap-sdk-go/service/btpentitlements"
+	"github.com/nnicora/sap-sdk-go/service/btpmanagment"
+	"github.com/nnicora/sap-sdk-go/service/btpprovisioning"
+	"github.com/nnicora/sap-sdk-go/service/btpsaasmanager"
+	"github.com/nnicora/sap-sdk-go/service/types"
+	"net/http"
+	"sort"
+	"testing"
+)
+
+// Unit tests drive the resource functions straight through schema.TestResourceDataRaw, against the fakes below.
+// The fakes embed the client interfaces, so an operation a test doesn't expect panics instead of passing silently.
+
+const fakeEndpointId = "fake"
+
+type fakeClientFactory struct {
+	accountsClient      accountsClient
+	entitlementsClient  entitlementsClient
+	smClient            serviceManagementClient
+	provisioningClient  provisioningClient
+	saasManagerClient   saasManagerClient
+	authorizationClient authorizationClient
+}
+
+func (f *fakeClientFactory) accounts() accountsClient {
+	return f.accountsClient
+}
+
+func (f *fakeClientFactory) entitlements() entitlementsClient {
+	return f.entitlementsClient
+}
+
+func (f *fakeClientFactory) serviceManagement(cfg *sap.EndpointConfig) (serviceManagementClient, error) {
+	return f.smClient, nil
+}
+
+func (f *fakeClientFactory) provisioning(cfg *sap.EndpointConfig) (provisioningClient, error) {
+	return f.provisioningClient, nil
+}
+
+func (f *fakeClientFactory) saasManager(cfg *sap.EndpointConfig) (saasManagerClient, error) {
+	return f.saasManagerClient, nil
+}
+
+func (f *fakeClientFactory) authorization(cfg *sap.EndpointConfig) (authorizationClient, error) {
+	return f.authorizationClient, nil
+}
+
+// newFakeSAPClient returns the provider meta built on the given fakes, along with a provider level endpoint
+// resources refer to through 'endpoint_id = fakeEndpointId'.
+func newFakeSAPClient(factory *fakeClientFactory) *SAPClient {
+	return newSAPClient(factory, map[string]*sap.EndpointConfig{
+		fakeEndpointId: {Host: "https://fake.local"},
+	})
+}
+
+// fakeResponse returns the status and body the sdk fills in on failed calls
+func fakeResponse(statusCode int) types.StatusAndBodyFromResponse {
+	return types.StatusAndBodyFromResponse{
+		StatusCode: int32(statusCode),
+		Status:     http.StatusText(statusCode),
+	}
+}
+
+// fakeAccountsError returns the error body the Accounts and Provisioning APIs answer with
+func fakeAccountsError(statusCode int, message string) *types.Error {
+	return &types.Error{
+		Code:    sap.Int32(int32(statusCode)),
+		Message: sap.String(message),
+	}
+}
+
+type fakeAccounts struct {
+	accountsClient
+
+	subAccounts map[string]btpaccounts.SubAccount
+	smBindings  map[string]btpaccounts.ServiceManagementBinding
+	directories map[string]btpaccounts.Directory
+
+	// The sub accounts a binding was created for
+	createdSmBindings []string
+	// The input the features of a directory were last updated with
+	updatedDirectoryFeatures *btpaccounts.UpdateDirectoryFeaturesInput
+
+	// When set, every call fails with this status and message
+	failStatus  int
+	failMessage string
+}
+
+func (f *fakeAccounts) GetSubAccount(ctx context.Context,
+	input *btpaccounts.GetSubAccountInput) (*btpaccounts.GetSubAccountOutput, error) {
+
+	if f.failStatus != 0 {
+		return &btpaccounts.GetSubAccountOutput{
+			Error:                     fakeAccountsError(f.failStatus, f.failMessage),
+			StatusAndBodyFromResponse: fakeResponse(f.failStatus),
+		}, fmt.Errorf("%s", http.StatusText(f.failStatus))
+	}
+	subAccount, ok := f.subAccounts[input.SubAccountGuid]
+	if !ok {
+		return &btpaccounts.GetSubAccountOutput{
+			Error:                     fakeAccountsError(http.StatusNotFound, "Sub account not found"),
+			StatusAndBodyFromResponse: fakeResponse(http.StatusNotFound),
+		}, fmt.Errorf("%s", http.StatusText(http.StatusNotFound))
+	}
+	return &btpaccounts.GetSubAccountOutput{SubAccount: subAccount}, nil
+}
+
+func (f *fakeAccounts) DeleteSubAccount(ctx context.Context,
+	input *btpaccounts.DeleteSubAccountInput) (*btpaccounts.DeleteSubAccountOutput, error) {
+
+	if f.failStatus != 0 {
+		return &btpaccounts.DeleteSubAccountOutput{
+			Error:                     fakeAccountsError(f.failStatus, f.failMessage),
+			StatusAndBodyFromResponse: fakeResponse(f.failStatus),
+		}, fmt.Errorf("%s", http.StatusText(f.failStatus))
+	}
+	subAccount := f.subAccounts[input.SubAccountGuid]
+	delete(f.subAccounts, input.SubAccountGuid)
+	return &btpaccounts.DeleteSubAccountOutput{SubAccount: subAccount}, nil
+}
+
+func (f *fakeAccounts) MoveSubAccount(ctx context.Context,
+	input *btpaccounts.MoveSubAccountInput) (*btpaccounts.MoveSubAccountOutput, error) {
+
+	if f.failStatus != 0 {
+		return &btpaccounts.MoveSubAccountOutput{
+			Error:                     fakeAccountsError(f.failStatus, f.failMessage),
+			StatusAndBodyFromResponse: fakeResponse(f.failStatus),
+		}, fmt.Errorf("%s", http.StatusText(f.failStatus))
+	}
+	subAccount := f.subAccounts[input.SubAccountGuid]
+	subAccount.ParentGuid = input.TargetAccountGuid
+	f.subAccounts[input.SubAccountGuid] = subAccount
+	return &btpaccounts.MoveSubAccountOutput{SubAccount: subAccount}, nil
+}
+
+func (f *fakeAccounts) CreateSubAccountServiceManagementBinding(ctx context.Context,
+	input *btpaccounts.CreateServiceManagementBindingInput) (*btpaccounts.CreateServiceManagementBindingOutput, error) {
+
+	if _, ok := f.smBindings[input.SubAccountGuid]; ok {
+		return &btpaccounts.CreateServiceManagementBindingOutput{
+			Error:                     fakeAccountsError(http.StatusConflict, "Binding already exists"),
+			StatusAndBodyFromResponse: fakeResponse(http.StatusConflict),
+		}, fmt.Errorf("%s", http.StatusText(http.StatusConflict))
+	}
+	binding := btpaccounts.ServiceManagementBinding{
+		ClientId:     "client-" + input.SubAccountGuid,
+		ClientSecret: "secret-" + input.SubAccountGuid,
+	}
+	f.smBindings[input.SubAccountGuid] = binding
+	f.createdSmBindings = append(f.createdSmBindings, input.SubAccountGuid)
+	return &btpaccounts.CreateServiceManagementBindingOutput{ServiceManagementBinding: binding}, nil
+}
+
+func (f *fakeAccounts) GetSubAccountServiceManagementBinding(ctx context.Context,
+	input *btpaccounts.GetServiceManagementBindingInput) (*btpaccounts.GetServiceManagementBindingOutput, error) {
+
+	if f.failStatus != 0 {
+		return &btpaccounts.GetServiceManagementBindingOutput{
+			Error:                     fakeAccountsError(f.failStatus, f.failMessage),
+			StatusAndBodyFromResponse: fakeResponse(f.failStatus),
+		}, fmt.Errorf("%s", http.StatusText(f.failStatus))
+	}
+	binding, ok := f.smBindings[input.SubAccountGuid]
+	if !ok {
+		return &btpaccounts.GetServiceManagementBindingOutput{
+			Error:                     fakeAccountsError(http.StatusNotFound, "Binding not found"),
+			StatusAndBodyFromResponse: fakeResponse(http.StatusNotFound),
+		}, fmt.Errorf("%s", http.StatusText(http.StatusNotFound))
+	}
+	return &btpaccounts.GetServiceManagementBindingOutput{ServiceManagementBinding: binding}, nil
+}
+
+func (f *fakeAccounts) GetDirectory(ctx context.Context,
+	input *btpaccounts.GetDirectoryInput) (*btpaccounts.GetDirectoryOutput, error) {
+
+	directory, ok := f.directories[input.DirectoryGuid]
+	if !ok {
+		return &btpaccounts.GetDirectoryOutput{
+			Error:                     fakeAccountsError(http.StatusNotFound, "Directory not found"),
+			StatusAndBodyFromResponse: fakeResponse(http.StatusNotFound),
+		}, fmt.Errorf("%s", http.StatusText(http.StatusNotFound))
+	}
+	return &btpaccounts.GetDirectoryOutput{Directory: directory}, nil
+}
+
+func (f *fakeAccounts) UpdateDirectoryFeatures(ctx context.Context,
+	input *btpaccounts.UpdateDirectoryFeaturesInput) (*btpaccounts.UpdateDirectoryFeaturesOutput, error) {
+
+	f.updatedDirectoryFeatures = input
+	directory, ok := f.directories[input.DirectoryGuid]
+	if !ok {
+		return &btpaccounts.UpdateDirectoryFeaturesOutput{
+			Error:                     fakeAccountsError(http.StatusNotFound, "Directory not found"),
+			StatusAndBodyFromResponse: fakeResponse(http.StatusNotFound),
+		}, fmt.Errorf("%s", http.StatusText(http.StatusNotFound))
+	}
+	directory.DirectoryFeatures = input.DirectoryFeatures
+	f.directories[input.DirectoryGuid] = directory
+	return &btpaccounts.UpdateDirectoryFeaturesOutput{
+		Directory:                 directory,
+		StatusAndBodyFromResponse: fakeResponse(http.StatusOK),
+	}, nil
+}
+
+// fakeEntitlements holds the services entitled to the global account, and the services assigned to each sub
+// account and directory, keyed by their id
+type fakeEntitlements struct {
+	entitlementsClient
+
+	entitledServices []btpentitlements.EntitledService
+	assignedServices map[string][]btpentitlements.AssignedService
+}
+
+func (f *fakeEntitlements) GetAssignments(ctx context.Context,
+	input *btpentitlements.GetAssignmentsInput) (*btpentitlements.GetAssignmentsOutput, error) {
+
+	id := input.SubAccountGuid
+	if input.DirectoryGuid != "" {
+		id = input.DirectoryGuid
+	}
+	services, ok := f.assignedServices[id]
+	if !ok {
+		return &btpentitlements.GetAssignmentsOutput{
+			Error:                     fakeAccountsError(http.StatusNotFound, "Entity not found"),
+			StatusAndBodyFromResponse: fakeResponse(http.StatusNotFound),
+		}, fmt.Errorf("%s", http.StatusText(http.StatusNotFound))
+	}
+	return &btpentitlements.GetAssignmentsOutput{AssignedServices: services}, nil
+}
+
+func (f *fakeEntitlements) GetGlobalAccountAssignments(ctx context.Context,
+	input *btpentitlements.GlobalAccountAssignmentsInput) (*btpentitlements.GlobalAccountAssignmentsOutput, error) {
+
+	services := make([]btpentitlements.AssignedService, 0)
+	for _, id := range sortedFakeIds(f.assignedServices) {
+		if input.SubAccountGuid == "" || input.SubAccountGuid == id {
+			services = append(services, f.assignedServices[id]...)
+		}
+	}
+	return &btpentitlements.GlobalAccountAssignmentsOutput{
+		EntitledServices: f.entitledServices,
+		AssignedServices: services,
+	}, nil
+}
+
+func sortedFakeIds(assignedServices map[string][]btpentitlements.AssignedService) []string {
+	ids := make([]string, 0, len(assignedServices))
+	for id := range assignedServices {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+type fakeProvisioning struct {
+	provisioningClient
+
+	environments map[string]btpprovisioning.EnvironmentInstance
+	// Successive answers for an environment instance, the last one repeated, taking precedence over environments
+	polls map[string][]btpprovisioning.EnvironmentInstance
+
+	failStatus  int
+	failMessage string
+}
+
+func (f *fakeProvisioning) GetEnvironmentInstance(ctx context.Context,
+	input *btpprovisioning.GetEnvironmentInstanceInput) (*btpprovisioning.GetEnvironmentInstanceOutput, error) {
+
+	if f.failStatus != 0 {
+		return &btpprovisioning.GetEnvironmentInstanceOutput{
+			Error:                     fakeAccountsError(f.failStatus, f.failMessage),
+			StatusAndBodyFromResponse: fakeResponse(f.failStatus),
+		}, fmt.Errorf("%s", http.StatusText(f.failStatus))
+	}
+	if polls := f.polls[input.EnvironmentInstanceId]; len(polls) > 0 {
+		if len(polls) > 1 {
+			f.polls[input.EnvironmentInstanceId] = polls[1:]
+		}
+		return &btpprovisioning.GetEnvironmentInstanceOutput{EnvironmentInstance: polls[0]}, nil
+	}
+	environment, ok := f.environments[input.EnvironmentInstanceId]
+	if !ok {
+		return &btpprovisioning.GetEnvironmentInstanceOutput{
+			StatusAndBodyFromResponse: fakeResponse(http.StatusNotFound),
+		}, fmt.Errorf("%s", http.StatusText(http.StatusNotFound))
+	}
+	return &btpprovisioning.GetEnvironmentInstanceOutput{EnvironmentInstance: environment}, nil
+}
+
+// fakeSaasManager holds the subscriptions of the consumer tenants, keyed by tenant id
+type fakeSaasManager struct {
+	saasManagerClient
+
+	subscriptions map[string]btpsaasmanager.ApplicationSubscription
+}
+
+func (f *fakeSaasManager) GetApplicationSubscriptions(ctx context.Context,
+	input *btpsaasmanager.GetApplicationSubscriptionsInput) (*btpsaasmanager.GetApplicationSubscriptionsOutput, error) {
+
+	subscription, ok := f.subscriptions[input.TenantId]
+	if !ok {
+		return &btpsaasmanager.GetApplicationSubscriptionsOutput{
+			Error:                     "Not Found",
+			ErrorDescription:          "Tenant not found",
+			StatusAndBodyFromResponse: fakeResponse(http.StatusNotFound),
+		}, fmt.Errorf("%s", http.StatusText(http.StatusNotFound))
+	}
+	return &btpsaasmanager.GetApplicationSubscriptionsOutput{
+		Values:                    []btpsaasmanager.ApplicationSubscription{subscription},
+		StatusAndBodyFromResponse: fakeResponse(http.StatusOK),
+	}, nil
+}
+
+// fakeServiceManagement holds instances, plans and offerings; operations run synchronously unless
+// asyncOperationState is set, in which case they answer with the location of an operation in that state.
+type fakeServiceManagement struct {
+	serviceManagementClient
+
+	offerings map[string]btpmanagment.OfferingItem
+	plans     map[string]btpmanagment.PlanItem
+	instances map[string]btpmanagment.InstanceItem
+	bindings  map[string]btpmanagment.BindingItem
+
+	asyncOperationState string
+
+	failStatus  int
+	failMessage string
+
+	// The inputs the instance operations were sent with
+	createdInstance *btpmanagment.CreateServiceInstanceInput
+	updatedInstance *updateServiceInstanceInput
+}
+
+func (f *fakeServiceManagement) error() (btpmanagment.Error, types.StatusAndBodyFromResponse, error) {
+	return btpmanagment.Error{ErrorMessage: f.failMessage}, fakeResponse(f.failStatus),
+		fmt.Errorf("%s", http.StatusText(f.failStatus))
+}
+
+func (f *fakeServiceManagement) notFound() (btpmanagment.Error, types.StatusAndBodyFromResponse, error) {
+	return btpmanagment.Error{ErrorMessage: "NotFound"}, fakeResponse(http.StatusNotFound),
+		fmt.Errorf("%s", http.StatusText(http.StatusNotFound))
+}
+
+func (f *fakeServiceManagement) location(resourceType, id string) string {
+	if f.asyncOperationState == "" {
+		return ""
+	}
+	return fmt.Sprintf("/v1/%s/%s/operations/operation-%s", resourceType, id, id)
+}
+
+func (f *fakeServiceManagement) GetServiceOffering(ctx context.Context,
+	input *btpmanagment.GetServiceOfferingInput) (*btpmanagment.GetServiceOfferingOutput, error) {
+
+	output := &btpmanagment.GetServiceOfferingOutput{}
+	offering, ok := f.offerings[input.ServiceOfferingID]
+	if !ok {
+		var err error
+		output.Error, output.StatusAndBodyFromResponse, err = f.notFound()
+		return output, err
+	}
+	output.OfferingItem = offering
+	return output, nil
+}
+
+func (f *fakeServiceManagement) GetServicePlan(ctx context.Context,
+	input *btpmanagment.GetServicePlanInput) (*btpmanagment.GetServicePlanOutput, error) {
+
+	output := &btpmanagment.GetServicePlanOutput{}
+	plan, ok := f.plans[input.ServicePlanID]
+	if !ok {
+		var err error
+		output.Error, output.StatusAndBodyFromResponse, err = f.notFound()
+		return output, err
+	}
+	output.PlanItem = plan
+	return output, nil
+}
+
+func (f *fakeServiceManagement) GetServicePlans(ctx context.Context,
+	input *btpmanagment.GetServicePlansInput) (*btpmanagment.GetServicePlansOutput, error) {
+
+	output := &btpmanagment.GetServicePlansOutput{}
+	for _, plan := range f.plans {
+		query := fmt.Sprintf("name eq '%s' and service_offering_id eq '%s'", plan.Name, plan.ServiceOfferingId)
+		if input.FieldQuery == query {
+			output.Items = append(output.Items, plan)
+		}
+	}
+	output.NumItems = int64(len(output.Items))
+	return output, nil
+}
+
+func (f *fakeServiceManagement) GetServiceBinding(ctx context.Context,
+	input *btpmanagment.GetServiceBindingInput) (*btpmanagment.GetServiceBindingOutput, error) {
+
+	output := &btpmanagment.GetServiceBindingOutput{}
+	binding, ok := f.bindings[input.ServiceBindingID]
+	if !ok {
+		var err error
+		output.Error, output.StatusAndBodyFromResponse, err = f.notFound()
+		return output, err
+	}
+	output.BindingItem = binding
+	return output, nil
+}
+
+func (f *fakeServiceManagement) GetServiceInstance(ctx context.Context,
+	input *btpmanagment.GetServiceInstanceInput) (*btpmanagment.GetServiceInstanceOutput, error) {
+
+	output := &btpmanagment.GetServiceInstanceOutput{}
+	var err error
+	if f.failStatus != 0 {
+		output.Error, output.StatusAndBodyFromResponse, err = f.error()
+		return output, err
+	}
+	instance, ok := f.instances[input.ServiceInstanceID]
+	if !ok {
+		output.Error, output.StatusAndBodyFromResponse, err = f.notFound()
+		return output, err
+	}
+	output.InstanceItem = instance
+	return output, nil
+}
+
+func (f *fakeServiceManagement) GetOperationStatus(ctx context.Context,
+	input *btpmanagment.GetOperationStatusInput) (*btpmanagment.GetOperationStatusOutput, error) {
+
+	output := &btpmanagment.GetOperationStatusOutput{}
+	output.Id = input.OperationID
+	output.ResourceId = input.ResourceID
+	output.State = f.asyncOperationState
+	if output.State == "failed" {
+		output.Errors = []btpmanagment.Error{{ErrorMessage: "BrokerError", ErrorDescription: "provisioning failed"}}
+	}
+	return output, nil
+}
+
+func (f *fakeServiceManagement) createServiceInstance(ctx context.Context,
+	input *btpmanagment.CreateServiceInstanceInput) (*createServiceInstanceOutput, error) {
+
+	f.createdInstance = input
+	output := &createServiceInstanceOutput{}
+	if f.failStatus != 0 {
+		var err error
+		output.Error, output.StatusAndBodyFromResponse, err = f.error()
+		return output, newServiceManagementError(err, output.Error, output.StatusAndBodyFromResponse)
+	}
+
+	id := fmt.Sprintf("instance-%d", len(f.instances)+1)
+	f.instances[id] = btpmanagment.InstanceItem{
+		Id:            id,
+		Ready:         true,
+		Usable:        true,
+		Name:          input.Name,
+		ServicePlanId: input.ServicePlanId,
+	}
+	output.Location = f.location("service_instances", id)
+	if output.Location == "" {
+		output.InstanceItem = f.instances[id]
+	}
+	return output, nil
+}
+
+func (f *fakeServiceManagement) updateServiceInstance(ctx context.Context,
+	input *updateServiceInstanceInput) (*updateServiceInstanceOutput, error) {
+
+	f.updatedInstance = input
+	output := &updateServiceInstanceOutput{}
+	instance, ok := f.instances[input.ServiceInstanceID]
+	if !ok {
+		var err error
+		output.Error, output.StatusAndBodyFromResponse, err = f.notFound()
+		return output, newServiceManagementError(err, output.Error, output.StatusAndBodyFromResponse)
+	}
+	if input.Name != "" {
+		instance.Name = input.Name
+	}
+	if input.ServicePlanId != "" {
+		instance.ServicePlanId = input.ServicePlanId
+	}
+	f.instances[input.ServiceInstanceID] = instance
+
+	output.Location = f.location("service_instances", instance.Id)
+	return output, nil
+}
+
+func (f *fakeServiceManagement) deleteServiceInstance(ctx context.Context,
+	input *btpmanagment.DeleteServiceInstanceInput) (*deleteServiceInstanceOutput, error) {
+
+	output := &deleteServiceInstanceOutput{}
+	var err error
+	if f.failStatus != 0 {
+		output.Error, output.StatusAndBodyFromResponse, err = f.error()
+		return output, newServiceManagementError(err, output.Error, output.StatusAndBodyFromResponse)
+	}
+	if _, ok := f.instances[input.ServiceInstanceID]; !ok {
+		output.Error, output.StatusAndBodyFromResponse, err = f.notFound()
+		return output, newServiceManagementError(err, output.Error, output.StatusAndBodyFromResponse)
+	}
+	delete(f.instances, input.ServiceInstanceID)
+
+	output.Location = f.location("service_instances", input.ServiceInstanceID)
+	return output, nil
+}
+
+// fakeAuthorization holds the role collections of an XSUAA, keyed by their name, and its users, keyed by their id
+type fakeAuthorization struct {
+	authorizationClient
+
+	groups map[string]*scimGroup
+	users  map[string]scimUser
+
+	// The user names of the shadow users created
+	createdUsers []string
+
+	// When set, every call fails with this status and message
+	failStatus  int
+	failMessage string
+	// When set, adding this user id to a role collection fails
+	failAddMember string
+}
+
+func (f *fakeAuthorization) fail() error {
+	return &authorizationError{StatusCode: int32(f.failStatus), Message: f.failMessage}
+}
+
+func (f *fakeAuthorization) getGroup(ctx context.Context, displayName string) (*scimGroup, error) {
+	if f.failStatus != 0 {
+		return nil, f.fail()
+	}
+	group, ok := f.groups[displayName]
+	if !ok {
+		return nil, &authorizationError{StatusCode: http.StatusNotFound, Message: "role collection not found"}
+	}
+	return group, nil
+}
+
+func (f *fakeAuthorization) getUsers(ctx context.Context, filter string) ([]scimUser, error) {
+	if f.failStatus != 0 {
+		return nil, f.fail()
+	}
+	matches := mockScimFilter(filter)
+	users := make([]scimUser, 0)
+	for _, user := range f.users {
+		if matches(mockObject{"id": user.Id, "userName": user.UserName, "origin": user.Origin}) {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (f *fakeAuthorization) createUser(ctx context.Context, user *scimUser) (*scimUser, error) {
+	created := *user
+	created.Id = "user-" + user.UserName
+	f.users[created.Id] = created
+	f.createdUsers = append(f.createdUsers, user.UserName)
+	return &created, nil
+}
+
+func (f *fakeAuthorization) addGroupMember(ctx context.Context, input *addScimGroupMemberInput) error {
+	if input.Value == f.failAddMember {
+		return &authorizationError{StatusCode: http.StatusBadRequest, Message: "member can't be added"}
+	}
+	for _, group := range f.groups {
+		if group.Id == input.GroupId {
+			group.Members = append(group.Members, scimGroupMember{
+				Origin: input.Origin,
+				Type:   input.Type,
+				Value:  input.Value,
+			})
+		}
+	}
+	return nil
+}
+
+func (f *fakeAuthorization) removeGroupMember(ctx context.Context, input *removeScimGroupMemberInput) error {
+	for _, group := range f.groups {
+		if group.Id != input.GroupId {
+			continue
+		}
+		members := make([]scimGroupMember, 0, len(group.Members))
+		for _, member := range group.Members {
+			if member.Value != input.MemberId {
+				members = append(members, member)
+			}
+		}
+		group.Members = members
+	}
+	return nil
+}
+
+// memberNames returns the sorted user names of the members of the named role collection
+func (f *fakeAuthorization) memberNames(displayName string) []string {
+	names := make([]string, 0)
+	for _, member := range f.groups[displayName].Members {
+		names = append(names, f.users[member.Value].UserName)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestSessionClientFactory_endpointSession(t *testing.T) {
+	factory := &sessionClientFactory{}
+	if _, err := factory.endpointSession(btpmanagment.EndpointsID, &sap.EndpointConfig{
+		Host: "https://service-manager.local",
+	}); err == nil {
+		t.Fatalf("expected an error for an endpoint without OAuth2 configuration")
+	}
+}
+
+func TestSessionClientFactory_endpointSession_cache(t *testing.T) {
+	defaultOAuth2 := &oauth2.Config{
+		GrantType:    "client_credentials",
+		ClientID:     "client",
+		ClientSecret: "secret",
+		TokenURL:     "http://localhost/oauth/token",
+	}
+	factory := &sessionClientFactory{defaultOAuth2: defaultOAuth2}
+
+	cfg := &sap.EndpointConfig{Host: "http://localhost"}
+	first, err := factory.endpointSession(btpmanagment.EndpointsID, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.OAuth2 != nil {
+		t.Errorf("the endpoint configuration of the caller was changed")
+	}
+
+	same, err := factory.endpointSession(btpmanagment.EndpointsID, &sap.EndpointConfig{Host: "http://localhost"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if same != first {
+		t.Errorf("the same endpoint and OAuth2 configuration got a new session")
+	}
+
+	for name, change := range map[string]func(*oauth2.Config){
+		"client_secret": func(c *oauth2.Config) { c.ClientSecret = "rotated" },
+		"token_url":     func(c *oauth2.Config) { c.TokenURL = "http://localhost/other/token" },
+		"password":      func(c *oauth2.Config) { c.Password = "rotated" },
+	} {
+		rotated := *defaultOAuth2
+		change(&rotated)
+		sess, err := factory.endpointSession(btpmanagment.EndpointsID, &sap.EndpointConfig{
+			Host:   "http://localhost",
+			OAuth2: &rotated,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if sess == first {
+			t.Errorf("a changed %s reused the session of the previous credentials", name)
+		}
+	}
+}
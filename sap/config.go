@@ -1,15 +1,86 @@
 package sap
 
 import (
-	"github.com/nnicora/sap-sdk-go/sap/session"
-	"github.com/nnicora/sap-sdk-go/service/btpaccounts"
-	"github.com/nnicora/sap-sdk-go/service/btpentitlements"
+	"fmt"
+	"github.com/nnicora/sap-sdk-go/sap"
 )
 
 type SAPClient struct {
-	session                 *session.RuntimeSession
-	btpAccountsV1Client     *btpaccounts.AccountsV1
-	btpEntitlementsV1Client *btpentitlements.EntitlementsV1
-	//btpProvisioningV1Client     *btpprovisioning.ProvisioningV1
-	//btpSaasManagerV1Client *btpsaasmanager.SaaSProvisioningV1
+	// Builds the clients of the BTP APIs; tests hand over one building fakes
+	factory clientFactory
+
+	btpAccountsV1Client     accountsClient
+	btpEntitlementsV1Client entitlementsClient
+
+	// Provider level 'service_endpoint' blocks, keyed by their id
+	endpoints map[string]*sap.EndpointConfig
+}
+
+func newSAPClient(factory clientFactory, endpoints map[string]*sap.EndpointConfig) *SAPClient {
+	return &SAPClient{
+		factory:                 factory,
+		btpAccountsV1Client:     factory.accounts(),
+		btpEntitlementsV1Client: factory.entitlements(),
+		endpoints:               endpoints,
+	}
+}
+
+// resourceGetter is satisfied by both schema.ResourceData and schema.ResourceDiff
+type resourceGetter interface {
+	Get(key string) interface{}
+	GetOk(key string) (interface{}, bool)
+}
+
+// endpointConfig returns the endpoint a resource refers to, either through 'endpoint_id' or inline through the
+// block named by blockName.
+func (c *SAPClient) endpointConfig(d resourceGetter, blockName string) (*sap.EndpointConfig, error) {
+	if endpointId, ok := d.GetOk("endpoint_id"); ok {
+		cfg, ok := c.endpoints[endpointId.(string)]
+		if !ok {
+			return nil, fmt.Errorf("no provider 'service_endpoint' with id '%s'", endpointId)
+		}
+		// Copied, as the default OAuth2 may be filled in later
+		return &sap.EndpointConfig{
+			Host:   cfg.Host,
+			OAuth2: cfg.OAuth2,
+		}, nil
+	}
+
+	services, _ := d.Get(blockName).([]interface{})
+	if len(services) < 1 || services[0] == nil {
+		return nil, fmt.Errorf("either 'endpoint_id' or '%s' is required", blockName)
+	}
+	return extractEndpointConfig(services), nil
+}
+
+func (c *SAPClient) serviceManagementV1Client(d resourceGetter, blockName string) (serviceManagementClient, error) {
+	cfg, err := c.endpointConfig(d, blockName)
+	if err != nil {
+		return nil, err
+	}
+	return c.factory.serviceManagement(cfg)
+}
+
+func (c *SAPClient) provisioningV1Client(d resourceGetter, blockName string) (provisioningClient, error) {
+	cfg, err := c.endpointConfig(d, blockName)
+	if err != nil {
+		return nil, err
+	}
+	return c.factory.provisioning(cfg)
+}
+
+func (c *SAPClient) saasManagerV1Client(d resourceGetter, blockName string) (saasManagerClient, error) {
+	cfg, err := c.endpointConfig(d, blockName)
+	if err != nil {
+		return nil, err
+	}
+	return c.factory.saasManager(cfg)
+}
+
+func (c *SAPClient) authorizationClient(d resourceGetter, blockName string) (authorizationClient, error) {
+	cfg, err := c.endpointConfig(d, blockName)
+	if err != nil {
+		return nil, err
+	}
+	return c.factory.authorization(cfg)
 }
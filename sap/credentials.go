@@ -0,0 +1,95 @@
+package sap
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nnicora/sap-sdk-go/sap/oauth2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BTP service key, as downloaded from the cockpit or created via CLI; only the 'uaa' section is relevant for OAuth2
+type serviceKey struct {
+	UAA *struct {
+		ClientId     string `json:"clientid"`
+		ClientSecret string `json:"clientsecret"`
+		Url          string `json:"url"`
+	} `json:"uaa"`
+}
+
+// providerOAuth2Config resolves the provider OAuth2 configuration. Values given in the 'oauth2' block win over
+// environment variables, which win over the service key from the credentials file.
+func providerOAuth2Config(oauth2Map map[string]interface{}, credentialsFile string) (*oauth2.Config, error) {
+	cfg := oauth2ConfigFrom(oauth2Map)
+
+	// Without an 'oauth2' block the schema defaults never apply, so environment is checked here as well
+	setIfEmpty(&cfg.GrantType, os.Getenv("SAP_BTP_GRANT_TYPE"))
+	setIfEmpty(&cfg.GrantType, "client_credentials")
+	setIfEmpty(&cfg.ClientID, os.Getenv("SAP_BTP_CLIENT_ID"))
+	setIfEmpty(&cfg.ClientSecret, os.Getenv("SAP_BTP_CLIENT_SECRET"))
+	setIfEmpty(&cfg.TokenURL, os.Getenv("SAP_BTP_TOKEN_URL"))
+	setIfEmpty(&cfg.Username, os.Getenv("SAP_BTP_USERNAME"))
+	setIfEmpty(&cfg.Password, os.Getenv("SAP_BTP_PASSWORD"))
+
+	if credentialsFile != "" {
+		key, err := readServiceKey(credentialsFile)
+		if err != nil {
+			return nil, err
+		}
+		setIfEmpty(&cfg.ClientID, key.UAA.ClientId)
+		setIfEmpty(&cfg.ClientSecret, key.UAA.ClientSecret)
+		if key.UAA.Url != "" {
+			setIfEmpty(&cfg.TokenURL, strings.TrimSuffix(key.UAA.Url, "/")+"/oauth/token")
+		}
+	}
+
+	var missing []string
+	if cfg.ClientID == "" {
+		missing = append(missing, "client_id (SAP_BTP_CLIENT_ID)")
+	}
+	if cfg.ClientSecret == "" {
+		missing = append(missing, "client_secret (SAP_BTP_CLIENT_SECRET)")
+	}
+	if cfg.TokenURL == "" {
+		missing = append(missing, "token_url (SAP_BTP_TOKEN_URL)")
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("SAP OAuth2 configuration is incomplete; missing %s; set them in the 'oauth2' "+
+			"block, as environment variables or through 'credentials_file'", strings.Join(missing, ", "))
+	}
+
+	return cfg, nil
+}
+
+func readServiceKey(path string) (*serviceKey, error) {
+	expandedPath := path
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("credentials file '%s' can't be resolved; %v", path, err)
+		}
+		expandedPath = filepath.Join(home, path[2:])
+	}
+
+	content, err := ioutil.ReadFile(expandedPath)
+	if err != nil {
+		return nil, fmt.Errorf("credentials file '%s' can't be read; %v", path, err)
+	}
+
+	key := &serviceKey{}
+	if err := json.Unmarshal(content, key); err != nil {
+		return nil, fmt.Errorf("credentials file '%s' isn't a valid service key; %v", path, err)
+	}
+	if key.UAA == nil {
+		return nil, fmt.Errorf("credentials file '%s' has no 'uaa' section", path)
+	}
+	return key, nil
+}
+
+func setIfEmpty(target *string, value string) {
+	if *target == "" {
+		*target = value
+	}
+}
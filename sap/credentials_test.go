@@ -0,0 +1,215 @@
+package sap
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testCredentialsEnv = []string{
+	"SAP_BTP_GRANT_TYPE",
+	"SAP_BTP_CLIENT_ID",
+	"SAP_BTP_CLIENT_SECRET",
+	"SAP_BTP_TOKEN_URL",
+	"SAP_BTP_USERNAME",
+	"SAP_BTP_PASSWORD",
+}
+
+// testSetCredentialsEnv sets the given credential environment variables and clears the others, restoring all of
+// them once the test ends.
+func testSetCredentialsEnv(t *testing.T, env map[string]string) {
+	for _, name := range testCredentialsEnv {
+		previous, ok := os.LookupEnv(name)
+		t.Cleanup(func() {
+			if ok {
+				os.Setenv(name, previous)
+			} else {
+				os.Unsetenv(name)
+			}
+		})
+		if value, set := env[name]; set {
+			os.Setenv(name, value)
+		} else {
+			os.Unsetenv(name)
+		}
+	}
+}
+
+func testServiceKeyFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "service-key.json")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const testServiceKey = `{
+  "uaa": {
+    "clientid": "key-client",
+    "clientsecret": "key-secret",
+    "url": "https://key.authentication.local/"
+  }
+}`
+
+func TestProviderOAuth2Config(t *testing.T) {
+	cases := []struct {
+		name       string
+		oauth2Map  map[string]interface{}
+		env        map[string]string
+		serviceKey string
+
+		clientId     string
+		clientSecret string
+		tokenUrl     string
+		grantType    string
+	}{
+		{
+			name: "inline",
+			oauth2Map: map[string]interface{}{
+				"client_id":     "inline-client",
+				"client_secret": "inline-secret",
+				"token_url":     "https://inline.local/oauth/token",
+			},
+			clientId:     "inline-client",
+			clientSecret: "inline-secret",
+			tokenUrl:     "https://inline.local/oauth/token",
+			grantType:    "client_credentials",
+		},
+		{
+			name: "inline wins over environment and service key",
+			oauth2Map: map[string]interface{}{
+				"grant_type":    "password",
+				"client_id":     "inline-client",
+				"client_secret": "inline-secret",
+				"token_url":     "https://inline.local/oauth/token",
+			},
+			env: map[string]string{
+				"SAP_BTP_GRANT_TYPE":    "client_credentials",
+				"SAP_BTP_CLIENT_ID":     "env-client",
+				"SAP_BTP_CLIENT_SECRET": "env-secret",
+				"SAP_BTP_TOKEN_URL":     "https://env.local/oauth/token",
+			},
+			serviceKey:   testServiceKey,
+			clientId:     "inline-client",
+			clientSecret: "inline-secret",
+			tokenUrl:     "https://inline.local/oauth/token",
+			grantType:    "password",
+		},
+		{
+			name: "environment fills what inline misses",
+			oauth2Map: map[string]interface{}{
+				"client_id": "inline-client",
+			},
+			env: map[string]string{
+				"SAP_BTP_CLIENT_ID":     "env-client",
+				"SAP_BTP_CLIENT_SECRET": "env-secret",
+				"SAP_BTP_TOKEN_URL":     "https://env.local/oauth/token",
+			},
+			clientId:     "inline-client",
+			clientSecret: "env-secret",
+			tokenUrl:     "https://env.local/oauth/token",
+			grantType:    "client_credentials",
+		},
+		{
+			name: "environment wins over service key",
+			env: map[string]string{
+				"SAP_BTP_CLIENT_SECRET": "env-secret",
+			},
+			serviceKey:   testServiceKey,
+			clientId:     "key-client",
+			clientSecret: "env-secret",
+			tokenUrl:     "https://key.authentication.local/oauth/token",
+			grantType:    "client_credentials",
+		},
+		{
+			name:         "service key only",
+			serviceKey:   testServiceKey,
+			clientId:     "key-client",
+			clientSecret: "key-secret",
+			tokenUrl:     "https://key.authentication.local/oauth/token",
+			grantType:    "client_credentials",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			testSetCredentialsEnv(t, c.env)
+			credentialsFile := ""
+			if c.serviceKey != "" {
+				credentialsFile = testServiceKeyFile(t, c.serviceKey)
+			}
+
+			cfg, err := providerOAuth2Config(c.oauth2Map, credentialsFile)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.ClientID != c.clientId {
+				t.Errorf("client id is %q, expected %q", cfg.ClientID, c.clientId)
+			}
+			if cfg.ClientSecret != c.clientSecret {
+				t.Errorf("client secret is %q, expected %q", cfg.ClientSecret, c.clientSecret)
+			}
+			if cfg.TokenURL != c.tokenUrl {
+				t.Errorf("token url is %q, expected %q", cfg.TokenURL, c.tokenUrl)
+			}
+			if cfg.GrantType != c.grantType {
+				t.Errorf("grant type is %q, expected %q", cfg.GrantType, c.grantType)
+			}
+		})
+	}
+}
+
+func TestProviderOAuth2Config_incomplete(t *testing.T) {
+	testSetCredentialsEnv(t, map[string]string{"SAP_BTP_CLIENT_ID": "env-client"})
+
+	_, err := providerOAuth2Config(nil, "")
+	if err == nil {
+		t.Fatalf("expected an error for an incomplete configuration")
+	}
+	for _, missing := range []string{"client_secret (SAP_BTP_CLIENT_SECRET)", "token_url (SAP_BTP_TOKEN_URL)"} {
+		if !strings.Contains(err.Error(), missing) {
+			t.Errorf("error %q doesn't name the missing %s", err, missing)
+		}
+	}
+	if strings.Contains(err.Error(), "client_id") {
+		t.Errorf("error %q names client_id, which is set", err)
+	}
+}
+
+func TestReadServiceKey(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{name: "valid", content: testServiceKey},
+		{name: "not json", content: "clientid=key-client", err: "isn't a valid service key"},
+		{name: "no uaa", content: `{"url": "https://key.local"}`, err: "has no 'uaa' section"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			key, err := readServiceKey(testServiceKeyFile(t, c.content))
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("expected an error containing %q, got %v", c.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if key.UAA.ClientId != "key-client" || key.UAA.ClientSecret != "key-secret" ||
+				key.UAA.Url != "https://key.authentication.local/" {
+				t.Errorf("service key is read as %+v", *key.UAA)
+			}
+		})
+	}
+
+	if _, err := readServiceKey(filepath.Join(t.TempDir(), "missing.json")); err == nil ||
+		!strings.Contains(err.Error(), "can't be read") {
+		t.Errorf("expected an error for a missing file, got %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package sap
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nnicora/sap-sdk-go/service/btpaccounts"
+	"sort"
+)
+
+// customPropertiesSchema returns the schema to use for the custom properties of sub accounts and directories.
+func customPropertiesSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeMap,
+		Optional:     true,
+		Elem:         &schema.Schema{Type: schema.TypeString},
+		ValidateFunc: validateCustomProperties,
+	}
+}
+
+// The Accounts API rejects properties without a value, and a property can't be told apart from its removal otherwise.
+func validateCustomProperties(v interface{}, k string) (ws []string, errs []error) {
+	for key, value := range v.(map[string]interface{}) {
+		if key == "" {
+			errs = append(errs, fmt.Errorf("%s can't hold an empty key", k))
+		}
+		if s, _ := value.(string); s == "" {
+			errs = append(errs, fmt.Errorf("%s.%s can't be empty", k, key))
+		}
+	}
+	return
+}
+
+func expandCustomProperties(data interface{}) []btpaccounts.KeyValue {
+	properties, _ := data.(map[string]interface{})
+
+	result := make([]btpaccounts.KeyValue, 0, len(properties))
+	for _, key := range sortedCustomPropertyKeys(properties) {
+		result = append(result, btpaccounts.KeyValue{
+			Key:   key,
+			Value: properties[key].(string),
+		})
+	}
+	return result
+}
+
+func flattenCustomProperties(properties []btpaccounts.CustomProperties) map[string]interface{} {
+	result := make(map[string]interface{}, len(properties))
+	for _, property := range properties {
+		result[property.Key] = property.Value
+	}
+	return result
+}
+
+// customPropertiesUpdates returns the changes which turn the old properties into the new ones; the Accounts API
+// keeps any property an update doesn't mention, hence removed properties are sent flagged for deletion.
+func customPropertiesUpdates(oldProperties, newProperties interface{}) []btpaccounts.UpdateSubAccountProperties {
+	oldMap, _ := oldProperties.(map[string]interface{})
+	newMap, _ := newProperties.(map[string]interface{})
+
+	updates := make([]btpaccounts.UpdateSubAccountProperties, 0)
+	for _, key := range sortedCustomPropertyKeys(oldMap) {
+		if _, ok := newMap[key]; !ok {
+			updates = append(updates, btpaccounts.UpdateSubAccountProperties{
+				KeyValue: btpaccounts.KeyValue{Key: key, Value: oldMap[key].(string)},
+				Delete:   true,
+			})
+		}
+	}
+	for _, key := range sortedCustomPropertyKeys(newMap) {
+		if oldValue, ok := oldMap[key]; !ok || oldValue != newMap[key] {
+			updates = append(updates, btpaccounts.UpdateSubAccountProperties{
+				KeyValue: btpaccounts.KeyValue{Key: key, Value: newMap[key].(string)},
+			})
+		}
+	}
+	return updates
+}
+
+func sortedCustomPropertyKeys(properties map[string]interface{}) []string {
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// customPropertiesStateUpgraderV0 upgrades the state of a resource which held its custom properties as a list of
+// key/value blocks, described by v0Properties, into the map of customPropertiesSchema.
+func customPropertiesStateUpgraderV0(current *schema.Resource, v0Properties *schema.Schema) schema.StateUpgrader {
+	v0 := &schema.Resource{Schema: make(map[string]*schema.Schema, len(current.Schema))}
+	for name, s := range current.Schema {
+		v0.Schema[name] = s
+	}
+	v0.Schema["custom_properties"] = v0Properties
+
+	return schema.StateUpgrader{
+		Version: 0,
+		Type:    v0.CoreConfigSchema().ImpliedType(),
+		Upgrade: customPropertiesStateUpgradeV0,
+	}
+}
+
+func customPropertiesStateUpgradeV0(ctx context.Context, rawState map[string]interface{},
+	meta interface{}) (map[string]interface{}, error) {
+
+	properties := make(map[string]interface{})
+	blocks, _ := rawState["custom_properties"].([]interface{})
+	for _, block := range blocks {
+		m, ok := block.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if deleted, _ := m["delete"].(bool); deleted {
+			continue
+		}
+		if key, _ := m["key"].(string); key != "" {
+			properties[key] = m["value"]
+		}
+	}
+	rawState["custom_properties"] = properties
+	return rawState, nil
+}
@@ -0,0 +1,69 @@
+package sap
+
+import (
+	"context"
+	"github.com/nnicora/sap-sdk-go/service/btpaccounts"
+	"reflect"
+	"testing"
+)
+
+func TestCustomPropertiesUpdates(t *testing.T) {
+	oldProperties := map[string]interface{}{
+		"cost_center": "1000",
+		"owner":       "team-a",
+		"stage":       "dev",
+	}
+	newProperties := map[string]interface{}{
+		"owner":  "team-b",
+		"region": "eu",
+		"stage":  "dev",
+	}
+
+	expected := []btpaccounts.UpdateSubAccountProperties{
+		{KeyValue: btpaccounts.KeyValue{Key: "cost_center", Value: "1000"}, Delete: true},
+		{KeyValue: btpaccounts.KeyValue{Key: "owner", Value: "team-b"}},
+		{KeyValue: btpaccounts.KeyValue{Key: "region", Value: "eu"}},
+	}
+	if got := customPropertiesUpdates(oldProperties, newProperties); !reflect.DeepEqual(got, expected) {
+		t.Errorf("updates are %v, expected %v", got, expected)
+	}
+}
+
+func TestCustomPropertiesUpdates_unchanged(t *testing.T) {
+	properties := map[string]interface{}{"owner": "team-a"}
+
+	if got := customPropertiesUpdates(properties, properties); len(got) != 0 {
+		t.Errorf("updates are %v, expected none", got)
+	}
+}
+
+func TestValidateCustomProperties(t *testing.T) {
+	if _, errs := validateCustomProperties(map[string]interface{}{"owner": "team-a"}, "custom_properties"); len(errs) != 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+	if _, errs := validateCustomProperties(map[string]interface{}{"owner": ""}, "custom_properties"); len(errs) != 1 {
+		t.Errorf("%d errors, expected 1", len(errs))
+	}
+}
+
+func TestCustomPropertiesStateUpgradeV0(t *testing.T) {
+	rawState := map[string]interface{}{
+		"id": "sub-account-1",
+		"custom_properties": []interface{}{
+			map[string]interface{}{"key": "owner", "value": "team-a", "delete": false},
+			map[string]interface{}{"key": "stage", "value": "dev", "delete": true},
+		},
+	}
+
+	got, err := customPropertiesStateUpgradeV0(context.Background(), rawState, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]interface{}{"owner": "team-a"}
+	if !reflect.DeepEqual(got["custom_properties"], expected) {
+		t.Errorf("custom_properties is %v, expected %v", got["custom_properties"], expected)
+	}
+	if got["id"] != "sub-account-1" {
+		t.Errorf("id is %v, expected %q", got["id"], "sub-account-1")
+	}
+}
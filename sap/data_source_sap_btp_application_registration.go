@@ -12,82 +12,8 @@ func dataSourceSapBtpApplicationRegistration() *schema.Resource {
 	return &schema.Resource{
 		ReadContext: dataSourceSapBtpApplicationRegistrationRead,
 		Schema: map[string]*schema.Schema{
-			"saas_manager_service": {
-				Type:     schema.TypeList,
-				Required: true,
-				MaxItems: 1,
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-						"host": {
-							Type:     schema.TypeString,
-							Required: true,
-						},
-
-						"oauth2": {
-							Type:     schema.TypeList,
-							Required: true,
-							MaxItems: 1,
-							Elem: &schema.Resource{
-								Schema: map[string]*schema.Schema{
-									"grant_type": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "client_credentials",
-										Description: "SAP OAuth2 Grant Type.",
-									},
-									"client_id": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Client Id.",
-									},
-									"client_secret": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Client Secret.",
-									},
-									"token_url": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Token Url.",
-									},
-									"authorization_url": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Authorization Url.",
-									},
-									"redirect_url": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Redirect Url.",
-									},
-
-									"username": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Username. Used in case if 'grant_type=password'.",
-									},
-									"password": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Password. Used in case if 'grant_type=password'.",
-									},
-
-									"timeout_seconds": {
-										Type:        schema.TypeInt,
-										Optional:    true,
-										Default:     60,
-										Description: "SAP OAuth2 HTTP Client timeout.",
-									},
-								},
-							},
-						},
-					},
-				},
-			},
+			"endpoint_id":          endpointIdSchema("saas_manager_service"),
+			"saas_manager_service": endpointSchema("saas_manager_service"),
 
 			"service_instance_id": {
 				Type:     schema.TypeString,
@@ -157,17 +83,10 @@ func dataSourceSapBtpApplicationRegistration() *schema.Resource {
 
 func dataSourceSapBtpApplicationRegistrationRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	//btpSaasManagerV1Client := meta.(*SAPClient).btpSaasManagerV1Client
-	session := meta.(*SAPClient).session
-	serviceList := d.Get("saas_manager_service").([]interface{})
-	if len(serviceList) < 1 {
-		return diag.Errorf("SaaS manager service is required")
-	}
-
-	err := session.AddEndpointWithReplace(btpsaasmanager.EndpointsID, extractEndpointConfig(serviceList))
+	btpSaasManagerV1Client, err := meta.(*SAPClient).saasManagerV1Client(d, "saas_manager_service")
 	if err != nil {
 		return diag.FromErr(errors.Errorf("BTP SaaS manager service OAuth2;  %v", err))
 	}
-	btpSaasManagerV1Client := btpsaasmanager.New(session)
 
 	input := &btpsaasmanager.GetApplicationRegistrationInput{}
 	if output, err := btpSaasManagerV1Client.GetApplicationRegistration(ctx, input); err != nil {
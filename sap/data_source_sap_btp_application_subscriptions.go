@@ -12,82 +12,8 @@ func dataSourceSapBtpApplicationSubscriptions() *schema.Resource {
 	return &schema.Resource{
 		ReadContext: dataSourceSapBtpApplicationSubscriptionsRead,
 		Schema: map[string]*schema.Schema{
-			"saas_manager_service": {
-				Type:     schema.TypeList,
-				Required: true,
-				MaxItems: 1,
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-						"host": {
-							Type:     schema.TypeString,
-							Required: true,
-						},
-
-						"oauth2": {
-							Type:     schema.TypeList,
-							Required: true,
-							MaxItems: 1,
-							Elem: &schema.Resource{
-								Schema: map[string]*schema.Schema{
-									"grant_type": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "client_credentials",
-										Description: "SAP OAuth2 Grant Type.",
-									},
-									"client_id": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Client Id.",
-									},
-									"client_secret": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Client Secret.",
-									},
-									"token_url": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Token Url.",
-									},
-									"authorization_url": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Authorization Url.",
-									},
-									"redirect_url": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Redirect Url.",
-									},
-
-									"username": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Username. Used in case if 'grant_type=password'.",
-									},
-									"password": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Password. Used in case if 'grant_type=password'.",
-									},
-
-									"timeout_seconds": {
-										Type:        schema.TypeInt,
-										Optional:    true,
-										Default:     60,
-										Description: "SAP OAuth2 HTTP Client timeout.",
-									},
-								},
-							},
-						},
-					},
-				},
-			},
+			"endpoint_id":          endpointIdSchema("saas_manager_service"),
+			"saas_manager_service": endpointSchema("saas_manager_service"),
 
 			"global_account_id": {
 				Type:     schema.TypeString,
@@ -222,17 +148,10 @@ func dataSourceSapBtpApplicationSubscriptions() *schema.Resource {
 
 func dataSourceSapBtpApplicationSubscriptionsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	//btpSaasManagerV1Client := meta.(*SAPClient).btpSaasManagerV1Client
-	session := meta.(*SAPClient).session
-	serviceList := d.Get("saas_manager_service").([]interface{})
-	if len(serviceList) < 1 {
-		return diag.Errorf("SaaS manager service is required")
-	}
-
-	err := session.AddEndpointWithReplace(btpsaasmanager.EndpointsID, extractEndpointConfig(serviceList))
+	btpSaasManagerV1Client, err := meta.(*SAPClient).saasManagerV1Client(d, "saas_manager_service")
 	if err != nil {
 		return diag.FromErr(errors.Errorf("BTP SaaS manager service OAuth2;  %v", err))
 	}
-	btpSaasManagerV1Client := btpsaasmanager.New(session)
 
 	input := &btpsaasmanager.GetApplicationSubscriptionsInput{}
 	if val, ok := d.GetOk("global_account_id"); ok {
@@ -0,0 +1,22 @@
+package sap
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nnicora/sap-sdk-go/service/btpentitlements"
+)
+
+func dataSourceSapBtpDirectoryEntitlements() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceSapBtpDirectoryEntitlementsRead,
+		Schema:      entityEntitlementsSchema("directory_id"),
+	}
+}
+
+func dataSourceSapBtpDirectoryEntitlementsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	directoryId := d.Get("directory_id").(string)
+	return readEntityEntitlements(ctx, d, meta, "Directory", directoryId, &btpentitlements.GetAssignmentsInput{
+		DirectoryGuid: directoryId,
+	})
+}
@@ -0,0 +1,55 @@
+package sap
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"reflect"
+	"testing"
+)
+
+func TestSapBtpDirectoryEntitlementsDataSourceRead(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: newFakeDirectoryEntitlements()})
+
+	d := schema.TestResourceDataRaw(t, dataSourceSapBtpDirectoryEntitlements().Schema, map[string]interface{}{
+		"directory_id": "directory-1",
+	})
+	if diags := dataSourceSapBtpDirectoryEntitlementsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	if d.Id() != "directory-1" {
+		t.Errorf("id is %q, expected %q", d.Id(), "directory-1")
+	}
+	// The assignment of the sub account within the directory is skipped
+	entitlements := d.Get("entitlements").([]interface{})
+	if len(entitlements) != 2 {
+		t.Fatalf("%d entitlements, expected 2: %v", len(entitlements), entitlements)
+	}
+	expected := []map[string]interface{}{
+		{"service_name": "destination", "plan_name": "lite", "amount": 0.0, "enable": true, "auto_distribute_amount": 0},
+		{"service_name": "xsuaa", "plan_name": "application", "amount": 3.0, "enable": false, "auto_distribute_amount": 1},
+	}
+	for i, fields := range expected {
+		got := entitlements[i].(map[string]interface{})
+		for key, value := range fields {
+			if !reflect.DeepEqual(got[key], value) {
+				t.Errorf("entitlements.%d.%s is %v, expected %v", i, key, got[key], value)
+			}
+		}
+	}
+}
+
+func TestSapBtpDirectoryEntitlementsDataSourceRead_serviceName(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: newFakeDirectoryEntitlements()})
+
+	d := schema.TestResourceDataRaw(t, dataSourceSapBtpDirectoryEntitlements().Schema, map[string]interface{}{
+		"directory_id": "directory-1",
+		"service_name": "xsuaa",
+	})
+	if diags := dataSourceSapBtpDirectoryEntitlementsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if got := d.Get("entitlements.#").(int); got != 1 {
+		t.Errorf("%d entitlements, expected 1", got)
+	}
+}
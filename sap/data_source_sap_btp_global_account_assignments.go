@@ -2,6 +2,7 @@ package sap
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
@@ -9,6 +10,7 @@ import (
 	"github.com/nnicora/sap-sdk-go/sap"
 	"github.com/nnicora/sap-sdk-go/service/btpentitlements"
 	"github.com/pkg/errors"
+	"time"
 )
 
 func dataSourceSapBtpGlobalAccountAssignments() *schema.Resource {
@@ -24,147 +26,188 @@ func dataSourceSapBtpGlobalAccountAssignments() *schema.Resource {
 				Type:     schema.TypeBool,
 				Optional: true,
 			},
+
+			// Filters
 			"sub_account_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+				Description: "Lists only the assignments of the sub account; the entitlements of the global account are listed regardless.",
+			},
+			"service_name": {
 				Type:     schema.TypeString,
 				Optional: true,
-				Default:  "",
+			},
+			"category": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Lists only the service plans of the category, e.g. SERVICE, APPLICATION or ENVIRONMENT.",
 			},
 
+			// Computed
 			"entitled_services": {
 				Type:     schema.TypeList,
-				Optional: true,
 				Computed: true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"name": {
 							Type:     schema.TypeString,
-							Optional: true,
+							Computed: true,
 						},
 						"display_name": {
 							Type:     schema.TypeString,
-							Optional: true,
+							Computed: true,
 						},
 						"description": {
 							Type:     schema.TypeString,
-							Optional: true,
-						},
-						"business_category": {
-							Type:     schema.TypeList,
-							Optional: true,
-							MaxItems: 1,
-							Elem: &schema.Resource{
-								Schema: map[string]*schema.Schema{
-									"id": {
-										Type:     schema.TypeString,
-										Optional: true,
-									},
-									"display_name": {
-										Type:     schema.TypeString,
-										Optional: true,
-									},
-								},
-							},
+							Computed: true,
 						},
+						"business_category": businessCategorySchema(),
 						"owner_type": {
 							Type:     schema.TypeString,
-							Optional: true,
+							Computed: true,
 						},
 						"service_plans": {
 							Type:     schema.TypeList,
-							Optional: true,
+							Computed: true,
 							Elem: &schema.Resource{
 								Schema: map[string]*schema.Schema{
 									"name": {
 										Type:     schema.TypeString,
-										Optional: true,
+										Computed: true,
 									},
 									"unlimited": {
 										Type:     schema.TypeBool,
-										Optional: true,
+										Computed: true,
 									},
 									"display_name": {
 										Type:     schema.TypeString,
-										Optional: true,
+										Computed: true,
 									},
 									"description": {
 										Type:     schema.TypeString,
-										Optional: true,
+										Computed: true,
 									},
 									"unique_identifier": {
 										Type:     schema.TypeString,
-										Optional: true,
+										Computed: true,
 									},
 									"provisioning_method": {
 										Type:     schema.TypeString,
-										Optional: true,
+										Computed: true,
 									},
 									"amount": {
-										Type:     schema.TypeInt,
-										Optional: true,
+										Type:     schema.TypeFloat,
+										Computed: true,
 									},
 									"remaining_amount": {
-										Type:     schema.TypeInt,
-										Optional: true,
+										Type:     schema.TypeFloat,
+										Computed: true,
 									},
 									"provided_by": {
 										Type:     schema.TypeString,
-										Optional: true,
+										Computed: true,
 									},
 									"beta": {
 										Type:     schema.TypeBool,
-										Optional: true,
+										Computed: true,
 									},
 									"available_for_internal": {
 										Type:     schema.TypeBool,
-										Optional: true,
+										Computed: true,
 									},
 									"internal_quota_limit": {
 										Type:     schema.TypeInt,
-										Optional: true,
+										Computed: true,
 									},
 									"auto_assign": {
 										Type:     schema.TypeBool,
-										Optional: true,
+										Computed: true,
 									},
 									"auto_distribute_amount": {
 										Type:     schema.TypeInt,
-										Optional: true,
+										Computed: true,
 									},
 									"max_allowed_sub_account_quota": {
 										Type:     schema.TypeInt,
-										Optional: true,
+										Computed: true,
 									},
 									"category": {
 										Type:     schema.TypeString,
-										Optional: true,
+										Computed: true,
 									},
 									"source_entitlements": {
 										Type:     schema.TypeList,
-										Optional: true,
+										Computed: true,
 										Elem: &schema.Resource{
-											Schema: map[string]*schema.Schema{},
+											Schema: map[string]*schema.Schema{
+												"entitlement_name": {
+													Type:     schema.TypeString,
+													Computed: true,
+												},
+												"amount": {
+													Type:     schema.TypeFloat,
+													Computed: true,
+												},
+												"product_id": {
+													Type:     schema.TypeString,
+													Computed: true,
+												},
+												"commercial_model": {
+													Type:     schema.TypeString,
+													Computed: true,
+												},
+												"consumption_based": {
+													Type:     schema.TypeBool,
+													Computed: true,
+												},
+												"auto_assign": {
+													Type:     schema.TypeBool,
+													Computed: true,
+												},
+											},
 										},
 									},
 									"data_centers": {
 										Type:     schema.TypeList,
-										Optional: true,
-										Elem: &schema.Resource{
-											Schema: map[string]*schema.Schema{},
-										},
-									},
-									"resources": {
-										Type:     schema.TypeList,
-										Optional: true,
+										Computed: true,
 										Elem: &schema.Resource{
-											Schema: map[string]*schema.Schema{},
+											Schema: map[string]*schema.Schema{
+												"name": {
+													Type:     schema.TypeString,
+													Computed: true,
+												},
+												"display_name": {
+													Type:     schema.TypeString,
+													Computed: true,
+												},
+												"region": {
+													Type:     schema.TypeString,
+													Computed: true,
+												},
+												"environment": {
+													Type:     schema.TypeString,
+													Computed: true,
+												},
+												"iaas_provider": {
+													Type:     schema.TypeString,
+													Computed: true,
+												},
+												"domain": {
+													Type:     schema.TypeString,
+													Computed: true,
+												},
+											},
 										},
 									},
+									"resources": entitlementResourcesSchema(),
 								},
 							},
 						},
 					},
 				},
 			},
+			"assigned_services": assignedServicesSchema(),
 
 			"tags": tagsSchemaComputed(),
 		},
@@ -185,14 +228,24 @@ func dataSourceSapBtpGlobalAccountAssignmentsRead(ctx context.Context, d *schema
 		input.SubAccountGuid = val.(string)
 	}
 
-	if output, err := btpEntitlementsV1Client.GetGlobalAccountAssignments(ctx, input); err != nil {
+	output, err := btpEntitlementsV1Client.GetGlobalAccountAssignments(ctx, input)
+	if err != nil {
 		if output != nil && output.Error != nil {
 			return diag.FromErr(
 				errors.Errorf("BTP Global Account assignments can't be read; %s", sap.StringValue(output.Error.Message)))
 		}
 		return diag.FromErr(fmt.Errorf("BTP Global Account assignments can't be read; %w", err))
-	} else {
-		d.Set("entitled_services", output.EntitledServices)
+	}
+
+	filter := &servicePlansFilter{
+		serviceName: d.Get("service_name").(string),
+		category:    d.Get("category").(string),
+	}
+	if err := d.Set("entitled_services", flattenEntitledServices(output.EntitledServices, filter)); err != nil {
+		return diag.FromErr(errors.Errorf("BTP Global Account entitled services can't be set; %v", err))
+	}
+	if err := d.Set("assigned_services", flattenAssignedServices(output.AssignedServices, filter)); err != nil {
+		return diag.FromErr(errors.Errorf("BTP Global Account assigned services can't be set; %v", err))
 	}
 
 	tags := make(map[string]interface{})
@@ -209,3 +262,368 @@ func dataSourceSapBtpGlobalAccountAssignmentsRead(ctx context.Context, d *schema
 
 	return nil
 }
+
+// servicePlansFilter holds the conditions the listed service plans must meet; a service none of whose plans
+// meets them isn't listed.
+type servicePlansFilter struct {
+	serviceName string
+	category    string
+}
+
+func (f *servicePlansFilter) matchesService(name string) bool {
+	return f.serviceName == "" || f.serviceName == name
+}
+
+func (f *servicePlansFilter) matchesPlan(category string) bool {
+	return f.category == "" || f.category == category
+}
+
+func businessCategorySchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Computed: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"id": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"display_name": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+			},
+		},
+	}
+}
+
+func entitlementResourcesSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Computed: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"name": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"provider": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"technical_name": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"type": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"data": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The resource data, as JSON.",
+				},
+			},
+		},
+	}
+}
+
+// assignedServicesSchema returns the schema of services assigned to sub accounts and directories, along with the
+// assignments of their plans.
+func assignedServicesSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Computed: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"name": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"display_name": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"business_category": businessCategorySchema(),
+				"service_plans": {
+					Type:     schema.TypeList,
+					Computed: true,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"name": {
+								Type:     schema.TypeString,
+								Computed: true,
+							},
+							"display_name": {
+								Type:     schema.TypeString,
+								Computed: true,
+							},
+							"unique_identifier": {
+								Type:     schema.TypeString,
+								Computed: true,
+							},
+							"category": {
+								Type:     schema.TypeString,
+								Computed: true,
+							},
+							"beta": {
+								Type:     schema.TypeBool,
+								Computed: true,
+							},
+							"max_allowed_sub_account_quota": {
+								Type:     schema.TypeInt,
+								Computed: true,
+							},
+							"unlimited": {
+								Type:     schema.TypeBool,
+								Computed: true,
+							},
+							"assignment_info": {
+								Type:     schema.TypeList,
+								Computed: true,
+								Elem: &schema.Resource{
+									Schema: map[string]*schema.Schema{
+										"entity_id": {
+											Type:     schema.TypeString,
+											Computed: true,
+										},
+										"entity_type": {
+											Type:     schema.TypeString,
+											Computed: true,
+										},
+										"entity_state": {
+											Type:     schema.TypeString,
+											Computed: true,
+										},
+										"state_message": {
+											Type:     schema.TypeString,
+											Computed: true,
+										},
+										"parent_id": {
+											Type:     schema.TypeString,
+											Computed: true,
+										},
+										"parent_type": {
+											Type:     schema.TypeString,
+											Computed: true,
+										},
+										"amount": {
+											Type:     schema.TypeFloat,
+											Computed: true,
+										},
+										"requested_amount": {
+											Type:     schema.TypeFloat,
+											Computed: true,
+										},
+										"parent_amount": {
+											Type:     schema.TypeFloat,
+											Computed: true,
+										},
+										"parent_remaining_amount": {
+											Type:     schema.TypeFloat,
+											Computed: true,
+										},
+										"unlimited_amount_assigned": {
+											Type:     schema.TypeBool,
+											Computed: true,
+										},
+										"auto_assign": {
+											Type:     schema.TypeBool,
+											Computed: true,
+										},
+										"auto_assigned": {
+											Type:     schema.TypeBool,
+											Computed: true,
+										},
+										"auto_distribute_amount": {
+											Type:     schema.TypeInt,
+											Computed: true,
+										},
+										"created_date": {
+											Type:     schema.TypeString,
+											Computed: true,
+										},
+										"modified_date": {
+											Type:     schema.TypeString,
+											Computed: true,
+										},
+										"resources": entitlementResourcesSchema(),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func flattenEntitledServices(services []btpentitlements.EntitledService, filter *servicePlansFilter) []interface{} {
+	result := make([]interface{}, 0, len(services))
+	for _, service := range services {
+		if !filter.matchesService(service.Name) {
+			continue
+		}
+
+		plans := make([]interface{}, 0, len(service.ServicePlans))
+		for _, plan := range service.ServicePlans {
+			if !filter.matchesPlan(plan.Category) {
+				continue
+			}
+
+			sources := make([]interface{}, 0, len(plan.SourceEntitlements))
+			for _, source := range plan.SourceEntitlements {
+				sources = append(sources, map[string]interface{}{
+					"entitlement_name":  source.EntitlementName,
+					"amount":            float64(source.Amount),
+					"product_id":        source.ProductId,
+					"commercial_model":  source.CommercialModel.Name,
+					"consumption_based": source.CommercialModel.ConsumptionBased,
+					"auto_assign":       source.AutoAssign,
+				})
+			}
+
+			dataCenters := make([]interface{}, 0, len(plan.DataCenters))
+			for _, dc := range plan.DataCenters {
+				dataCenters = append(dataCenters, map[string]interface{}{
+					"name":          dc.Name,
+					"display_name":  dc.DisplayName,
+					"region":        dc.Region,
+					"environment":   dc.Environment,
+					"iaas_provider": dc.IaasProvider,
+					"domain":        dc.Domain,
+				})
+			}
+
+			plans = append(plans, map[string]interface{}{
+				"name":                          plan.Name,
+				"unlimited":                     plan.Unlimited,
+				"display_name":                  plan.DisplayName,
+				"description":                   plan.Description,
+				"unique_identifier":             plan.UniqueIdentifier,
+				"provisioning_method":           plan.ProvisioningMethod,
+				"amount":                        float64(plan.Amount),
+				"remaining_amount":              float64(plan.RemainingAmount),
+				"provided_by":                   plan.ProvidedBy,
+				"beta":                          plan.Beta,
+				"available_for_internal":        plan.AvailableForInternal,
+				"internal_quota_limit":          int(plan.InternalQuotaLimit),
+				"auto_assign":                   plan.AutoAssign,
+				"auto_distribute_amount":        int(plan.AutoDistributeAmount),
+				"max_allowed_sub_account_quota": int(plan.MaxAllowedSubAccountQuota),
+				"category":                      plan.Category,
+				"source_entitlements":           sources,
+				"data_centers":                  dataCenters,
+				"resources":                     flattenEntitlementResources(plan.Resources),
+			})
+		}
+		if len(plans) == 0 && len(service.ServicePlans) > 0 {
+			continue
+		}
+
+		result = append(result, map[string]interface{}{
+			"name":              service.Name,
+			"display_name":      service.DisplayName,
+			"description":       service.Description,
+			"business_category": flattenBusinessCategory(service.BusinessCategory),
+			"owner_type":        service.OwnerType,
+			"service_plans":     plans,
+		})
+	}
+	return result
+}
+
+func flattenAssignedServices(services []btpentitlements.AssignedService, filter *servicePlansFilter) []interface{} {
+	result := make([]interface{}, 0, len(services))
+	for _, service := range services {
+		if !filter.matchesService(service.Name) {
+			continue
+		}
+
+		plans := make([]interface{}, 0, len(service.ServicePlans))
+		for _, plan := range service.ServicePlans {
+			if !filter.matchesPlan(plan.Category) {
+				continue
+			}
+
+			assignments := make([]interface{}, 0, len(plan.AssignmentInfo))
+			for _, info := range plan.AssignmentInfo {
+				assignments = append(assignments, map[string]interface{}{
+					"entity_id":                 info.EntityId,
+					"entity_type":               info.EntityType,
+					"entity_state":              info.EntityState,
+					"state_message":             info.StateMessage,
+					"parent_id":                 info.ParentId,
+					"parent_type":               info.ParentType,
+					"amount":                    float64(info.Amount),
+					"requested_amount":          float64(info.RequestedAmount),
+					"parent_amount":             float64(info.ParentAmount),
+					"parent_remaining_amount":   float64(info.ParentRemainingAmount),
+					"unlimited_amount_assigned": info.UnlimitedAmountAssigned,
+					"auto_assign":               info.AutoAssign,
+					"auto_assigned":             info.AutoAssigned,
+					"auto_distribute_amount":    int(info.AutoDistributeAmount),
+					"created_date":              info.CreatedDate.Format(time.RFC3339),
+					"modified_date":             info.ModifiedDate.Format(time.RFC3339),
+					"resources":                 flattenEntitlementResources(info.Resources),
+				})
+			}
+
+			plans = append(plans, map[string]interface{}{
+				"name":                          plan.Name,
+				"display_name":                  plan.DisplayName,
+				"unique_identifier":             plan.UniqueIdentifier,
+				"category":                      plan.Category,
+				"beta":                          plan.Beta,
+				"max_allowed_sub_account_quota": int(plan.MaxAllowedSubAccountQuota),
+				"unlimited":                     plan.Unlimited,
+				"assignment_info":               assignments,
+			})
+		}
+		if len(plans) == 0 && len(service.ServicePlans) > 0 {
+			continue
+		}
+
+		result = append(result, map[string]interface{}{
+			"name":              service.Name,
+			"display_name":      service.DisplayName,
+			"business_category": flattenBusinessCategory(service.BusinessCategory),
+			"service_plans":     plans,
+		})
+	}
+	return result
+}
+
+func flattenBusinessCategory(category btpentitlements.BusinessCategory) []interface{} {
+	if category.Id == "" && category.DisplayName == "" {
+		return []interface{}{}
+	}
+	return []interface{}{
+		map[string]interface{}{
+			"id":           category.Id,
+			"display_name": category.DisplayName,
+		},
+	}
+}
+
+func flattenEntitlementResources(resources []btpentitlements.Resource) []interface{} {
+	result := make([]interface{}, 0, len(resources))
+	for _, res := range resources {
+		data, ok := res.Data.(string)
+		if !ok && res.Data != nil {
+			if b, err := json.Marshal(res.Data); err == nil {
+				data = string(b)
+			}
+		}
+		result = append(result, map[string]interface{}{
+			"name":           res.Name,
+			"provider":       res.Provider,
+			"technical_name": res.TechnicalName,
+			"type":           res.Type,
+			"data":           data,
+		})
+	}
+	return result
+}
@@ -0,0 +1,133 @@
+package sap
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nnicora/sap-sdk-go/service/btpentitlements"
+	"testing"
+)
+
+// newFakeGlobalAccountEntitlements returns a global account entitled to a quota of xsuaa and to a subscription of
+// an application, each assigned to a sub account.
+func newFakeGlobalAccountEntitlements() *fakeEntitlements {
+	return &fakeEntitlements{
+		entitledServices: []btpentitlements.EntitledService{
+			{
+				Name:             "xsuaa",
+				BusinessCategory: btpentitlements.BusinessCategory{Id: "SECURITY", DisplayName: "Security"},
+				ServicePlans: []btpentitlements.ServicePlan{
+					{
+						Name:            "application",
+						Category:        "SERVICE",
+						Amount:          10,
+						RemainingAmount: 6,
+						SourceEntitlements: []btpentitlements.SourceEntitlement{
+							{EntitlementName: "xsuaa-application", Amount: 10},
+						},
+						DataCenters: []btpentitlements.DataCenter{
+							{Name: "cf-eu10", Region: "eu10"},
+						},
+						Resources: []btpentitlements.Resource{
+							{Name: "xsuaa", Type: "provider", Data: map[string]interface{}{"limit": 10}},
+						},
+					},
+				},
+			},
+			{
+				Name: "workzone",
+				ServicePlans: []btpentitlements.ServicePlan{
+					{Name: "standard", Category: "APPLICATION", Unlimited: true},
+				},
+			},
+		},
+		assignedServices: map[string][]btpentitlements.AssignedService{
+			"sub-account-1": {
+				{
+					Name: "xsuaa",
+					ServicePlans: []btpentitlements.AssignedServicePlan{
+						{
+							Name:     "application",
+							Category: "SERVICE",
+							AssignmentInfo: []btpentitlements.AssignedServicePlanSubAccount{
+								{EntityId: "sub-account-1", EntityType: "SUBACCOUNT", Amount: 4},
+							},
+						},
+					},
+				},
+			},
+			"sub-account-2": {
+				{
+					Name: "workzone",
+					ServicePlans: []btpentitlements.AssignedServicePlan{
+						{
+							Name:     "standard",
+							Category: "APPLICATION",
+							AssignmentInfo: []btpentitlements.AssignedServicePlanSubAccount{
+								{EntityId: "sub-account-2", EntityType: "SUBACCOUNT", UnlimitedAmountAssigned: true},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestSapBtpGlobalAccountAssignmentsRead(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: newFakeGlobalAccountEntitlements()})
+
+	d := schema.TestResourceDataRaw(t, dataSourceSapBtpGlobalAccountAssignments().Schema, map[string]interface{}{})
+	if diags := dataSourceSapBtpGlobalAccountAssignmentsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	expected := map[string]string{
+		"entitled_services.#":                                          "2",
+		"entitled_services.0.name":                                     "xsuaa",
+		"entitled_services.0.business_category.0.id":                   "SECURITY",
+		"entitled_services.0.service_plans.0.amount":                   "10",
+		"entitled_services.0.service_plans.0.remaining_amount":         "6",
+		"entitled_services.0.service_plans.0.source_entitlements.#":    "1",
+		"entitled_services.0.service_plans.0.data_centers.0.region":    "eu10",
+		"entitled_services.0.service_plans.0.resources.0.data":         `{"limit":10}`,
+		"entitled_services.1.service_plans.0.unlimited":                "true",
+		"assigned_services.#":                                          "2",
+		"assigned_services.0.service_plans.0.assignment_info.0.amount": "4",
+	}
+	for key, value := range expected {
+		if got := d.Get(key); fmt.Sprint(got) != value {
+			t.Errorf("%s is %v, expected %s", key, got, value)
+		}
+	}
+}
+
+func TestSapBtpGlobalAccountAssignmentsRead_filter(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: newFakeGlobalAccountEntitlements()})
+
+	cases := []struct {
+		name     string
+		raw      map[string]interface{}
+		entitled int
+		assigned int
+	}{
+		{"service name", map[string]interface{}{"service_name": "xsuaa"}, 1, 1},
+		{"category", map[string]interface{}{"category": "APPLICATION"}, 1, 1},
+		{"sub account", map[string]interface{}{"sub_account_id": "sub-account-2"}, 2, 1},
+		{"no match", map[string]interface{}{"service_name": "xsuaa", "category": "APPLICATION"}, 0, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, dataSourceSapBtpGlobalAccountAssignments().Schema, c.raw)
+			if diags := dataSourceSapBtpGlobalAccountAssignmentsRead(context.Background(), d, meta); diags.HasError() {
+				t.Fatalf("unexpected error: %v", diags)
+			}
+			if got := d.Get("entitled_services.#").(int); got != c.entitled {
+				t.Errorf("%d entitled services, expected %d", got, c.entitled)
+			}
+			if got := d.Get("assigned_services.#").(int); got != c.assigned {
+				t.Errorf("%d assigned services, expected %d", got, c.assigned)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package sap
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nnicora/sap-sdk-go/service/btpmanagment"
+	"github.com/nnicora/terraform-provider-sap/sap/internal/flatten"
+	"github.com/pkg/errors"
+)
+
+func dataSourceSapBtpServiceBinding() *schema.Resource {
+	s := serviceBindingSchema()
+	s["endpoint_id"] = endpointIdSchema("service_management")
+	s["service_management"] = endpointSchema("service_management")
+	s["binding_id"] = &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		Computed:     true,
+		ExactlyOneOf: []string{"binding_id", "name"},
+	}
+	s["name"] = &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		Computed:     true,
+		ExactlyOneOf: []string{"binding_id", "name"},
+		Description:  "Name of the service binding; reading fails unless exactly one binding matches.",
+	}
+	s["service_instance_id"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Computed:    true,
+		Description: "Id of the service instance narrowing the bindings looked up by name.",
+	}
+	s["field_query"] = &schema.Schema{
+		Type:          schema.TypeString,
+		Optional:      true,
+		ConflictsWith: []string{"binding_id"},
+		Description:   "Service Manager field query narrowing the bindings looked up by name.",
+	}
+	s["label_query"] = &schema.Schema{
+		Type:          schema.TypeString,
+		Optional:      true,
+		ConflictsWith: []string{"binding_id"},
+		Description:   "Service Manager label query narrowing the bindings looked up by name.",
+	}
+
+	return &schema.Resource{
+		ReadContext: dataSourceSapBtpServiceBindingRead,
+		Schema:      s,
+	}
+}
+
+func dataSourceSapBtpServiceBindingRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	btpServiceManagementV1Client, err := meta.(*SAPClient).serviceManagementV1Client(d, "service_management")
+	if err != nil {
+		return diag.FromErr(errors.Errorf("BTP Service Management OAuth2;  %v", err))
+	}
+
+	var binding btpmanagment.BindingItem
+	if bindingId, ok := d.GetOk("binding_id"); ok {
+		output, err := btpServiceManagementV1Client.GetServiceBinding(ctx, &btpmanagment.GetServiceBindingInput{
+			ServiceBindingID: bindingId.(string),
+		})
+		if err != nil {
+			return diag.Errorf("BTP Service Binding can't be read; %v",
+				newServiceManagementError(err, output.Error, output.StatusAndBodyFromResponse))
+		}
+		binding = output.BindingItem
+	} else {
+		name := d.Get("name").(string)
+		bindings, err := listServiceBindings(ctx, btpServiceManagementV1Client, &btpmanagment.GetServiceBindingsInput{
+			FieldQuery: serviceManagementFieldQuery(d.Get("field_query").(string),
+				"name", name, "service_instance_id", d.Get("service_instance_id").(string)),
+			LabelQuery: d.Get("label_query").(string),
+		})
+		if err != nil {
+			return diag.FromErr(errors.Errorf("BTP Service Binding %s can't be read; %v", name, err))
+		}
+		switch len(bindings) {
+		case 0:
+			return diag.Errorf("BTP Service Binding %s not found", name)
+		case 1:
+			binding = bindings[0]
+		default:
+			return diag.Errorf("BTP Service Binding %s matches %d bindings; narrow it down with "+
+				"'service_instance_id' or 'label_query'", name, len(bindings))
+		}
+	}
+
+	flattened, err := flattenServiceBinding(&binding)
+	if err != nil {
+		return diag.FromErr(errors.Errorf("BTP Service Binding credentials can't be read; %v", err))
+	}
+
+	d.SetId(binding.Id)
+	d.Set("binding_id", binding.Id)
+	for key, value := range flattened {
+		if err := d.Set(key, value); err != nil {
+			return diag.FromErr(errors.Errorf("BTP Service Binding %s can't be set; %v", key, err))
+		}
+	}
+	return nil
+}
+
+// serviceBindingSchema returns the attributes of a service binding read from Service Manager.
+func serviceBindingSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"name": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"service_instance_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"ready": {
+			Type:     schema.TypeBool,
+			Computed: true,
+		},
+		"context": {
+			Type:     schema.TypeMap,
+			Computed: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+		"resources": {
+			Type:     schema.TypeMap,
+			Computed: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+		"labels": labelsSchemaComputed(),
+		"credentials": {
+			Type:      schema.TypeMap,
+			Computed:  true,
+			Sensitive: true,
+			Elem:      &schema.Schema{Type: schema.TypeString},
+		},
+		"credentials_json": {
+			Type:      schema.TypeString,
+			Computed:  true,
+			Sensitive: true,
+		},
+		"created_at": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"updated_at": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+	}
+}
+
+func flattenServiceBinding(binding *btpmanagment.BindingItem) (map[string]interface{}, error) {
+	credentialsJson, err := serviceBindingCredentialsJson(binding.Credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"name":                binding.Name,
+		"service_instance_id": binding.ServiceInstanceId,
+		"ready":               binding.Ready,
+		"context":             binding.Context,
+		"resources":           binding.BindResource,
+		"labels":              flattenLabels(binding.Labels),
+		"credentials":         flatten.Flatten(binding.Credentials),
+		"credentials_json":    credentialsJson,
+		"created_at":          binding.CreatedAt,
+		"updated_at":          binding.UpdatedAt,
+	}, nil
+}
+
+// listServiceBindings returns all the bindings matching the input, following the pages Service Manager
+// answers with.
+func listServiceBindings(ctx context.Context, client serviceManagementClient,
+	input *btpmanagment.GetServiceBindingsInput) ([]btpmanagment.BindingItem, error) {
+
+	bindings := make([]btpmanagment.BindingItem, 0)
+	for {
+		output, err := client.GetServiceBindings(ctx, input)
+		if err != nil {
+			return nil, newServiceManagementError(err, output.Error, output.StatusAndBodyFromResponse)
+		}
+		bindings = append(bindings, output.Items...)
+		if output.Token == "" {
+			return bindings, nil
+		}
+		input.Token = output.Token
+	}
+}
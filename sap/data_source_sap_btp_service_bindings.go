@@ -0,0 +1,95 @@
+package sap
+
+import (
+	"context"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nnicora/sap-sdk-go/service/btpmanagment"
+	"github.com/pkg/errors"
+)
+
+func dataSourceSapBtpServiceBindings() *schema.Resource {
+	binding := serviceBindingSchema()
+	binding["id"] = &schema.Schema{
+		Type:     schema.TypeString,
+		Computed: true,
+	}
+
+	return &schema.Resource{
+		ReadContext: dataSourceSapBtpServiceBindingsRead,
+		Schema: map[string]*schema.Schema{
+			"endpoint_id":        endpointIdSchema("service_management"),
+			"service_management": endpointSchema("service_management"),
+
+			// Filters
+			"service_instance_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"field_query": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Service Manager field query the bindings must match, e.g. \"ready eq true\".",
+			},
+			"label_query": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Service Manager label query the bindings must match, e.g. \"environment eq 'dev'\".",
+			},
+
+			// Computed
+			"ids": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"bindings": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Resource{Schema: binding},
+			},
+		},
+	}
+}
+
+func dataSourceSapBtpServiceBindingsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	btpServiceManagementV1Client, err := meta.(*SAPClient).serviceManagementV1Client(d, "service_management")
+	if err != nil {
+		return diag.FromErr(errors.Errorf("BTP Service Management OAuth2;  %v", err))
+	}
+
+	bindings, err := listServiceBindings(ctx, btpServiceManagementV1Client, &btpmanagment.GetServiceBindingsInput{
+		FieldQuery: serviceManagementFieldQuery(d.Get("field_query").(string),
+			"service_instance_id", d.Get("service_instance_id").(string)),
+		LabelQuery: d.Get("label_query").(string),
+	})
+	if err != nil {
+		return diag.FromErr(errors.Errorf("BTP Service Bindings can't be read; %v", err))
+	}
+
+	ids := make([]string, 0, len(bindings))
+	result := make([]map[string]interface{}, 0, len(bindings))
+	for idx := range bindings {
+		ids = append(ids, bindings[idx].Id)
+
+		m, err := flattenServiceBinding(&bindings[idx])
+		if err != nil {
+			return diag.FromErr(errors.Errorf("BTP Service Binding %s credentials can't be read; %v", bindings[idx].Id, err))
+		}
+		m["id"] = bindings[idx].Id
+		result = append(result, m)
+	}
+	d.Set("ids", ids)
+	if err := d.Set("bindings", result); err != nil {
+		return diag.FromErr(errors.Errorf("BTP Service Bindings can't be set; %v", err))
+	}
+
+	if uuidString, err := uuid.GenerateUUID(); err != nil {
+		return diag.FromErr(err)
+	} else {
+		d.SetId(uuidString)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package sap
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"regexp"
+	"testing"
+)
+
+func TestAccSapBtpServiceBindingsDataSource_basic(t *testing.T) {
+	btp := newMockBtp(t)
+	dataSourceName := "data.sap_btp_service_bindings.test"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpServiceBindingsDataSourceConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "ids.#", "1"),
+					resource.TestCheckResourceAttrPair(dataSourceName, "ids.0",
+						"sap_btp_sub_account_service_management_bindings.reader", "id"),
+					resource.TestCheckResourceAttr(dataSourceName, "bindings.0.name", "reader-binding"),
+					resource.TestCheckResourceAttr(dataSourceName, "bindings.0.ready", "true"),
+					resource.TestCheckResourceAttr(dataSourceName, "bindings.0.credentials.url", mockBtpHost),
+
+					resource.TestCheckResourceAttrPair("data.sap_btp_service_binding.by_name", "id",
+						"sap_btp_sub_account_service_management_bindings.writer", "id"),
+					resource.TestCheckResourceAttrSet("data.sap_btp_service_binding.by_name", "credentials.clientsecret"),
+					resource.TestCheckResourceAttr("data.sap_btp_service_binding.by_id", "name", "reader-binding"),
+				),
+			},
+			{
+				Config: testAccSapBtpServiceBindingsResourcesConfig + `
+data "sap_btp_service_binding" "test" {
+  endpoint_id = "service-manager"
+  name        = "missing-binding"
+}
+`,
+				ExpectError: regexp.MustCompile("BTP Service Binding missing-binding not found"),
+			},
+		},
+	})
+}
+
+const testAccSapBtpServiceBindingsResourcesConfig = testAccProviderConfig + `
+resource "sap_btp_sub_account_service_management_instances" "test" {
+  endpoint_id           = "service-manager"
+  name                  = "test-instance"
+  service_offering_name = "destination"
+  service_plan_name     = "lite"
+}
+
+resource "sap_btp_sub_account_service_management_bindings" "reader" {
+  endpoint_id         = "service-manager"
+  name                = "reader-binding"
+  service_instance_id = sap_btp_sub_account_service_management_instances.test.id
+
+  labels {
+    key    = "access"
+    values = ["read"]
+  }
+}
+
+resource "sap_btp_sub_account_service_management_bindings" "writer" {
+  endpoint_id         = "service-manager"
+  name                = "writer-binding"
+  service_instance_id = sap_btp_sub_account_service_management_instances.test.id
+
+  labels {
+    key    = "access"
+    values = ["write"]
+  }
+}
+`
+
+const testAccSapBtpServiceBindingsDataSourceConfig = testAccSapBtpServiceBindingsResourcesConfig + `
+data "sap_btp_service_bindings" "test" {
+  endpoint_id         = "service-manager"
+  service_instance_id = sap_btp_sub_account_service_management_instances.test.id
+  label_query         = "access eq 'read'"
+
+  depends_on = [
+    sap_btp_sub_account_service_management_bindings.reader,
+    sap_btp_sub_account_service_management_bindings.writer,
+  ]
+}
+
+data "sap_btp_service_binding" "by_name" {
+  endpoint_id         = "service-manager"
+  name                = sap_btp_sub_account_service_management_bindings.writer.name
+  service_instance_id = sap_btp_sub_account_service_management_instances.test.id
+}
+
+data "sap_btp_service_binding" "by_id" {
+  endpoint_id = "service-manager"
+  binding_id  = sap_btp_sub_account_service_management_bindings.reader.id
+}
+`
@@ -0,0 +1,175 @@
+package sap
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nnicora/sap-sdk-go/service/btpmanagment"
+	"github.com/pkg/errors"
+)
+
+func dataSourceSapBtpServiceInstance() *schema.Resource {
+	s := serviceInstanceSchema()
+	s["endpoint_id"] = endpointIdSchema("service_management")
+	s["service_management"] = endpointSchema("service_management")
+	s["instance_id"] = &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		Computed:     true,
+		ExactlyOneOf: []string{"instance_id", "name"},
+	}
+	s["name"] = &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		Computed:     true,
+		ExactlyOneOf: []string{"instance_id", "name"},
+		Description:  "Name of the service instance; reading fails unless exactly one instance matches.",
+	}
+	s["field_query"] = &schema.Schema{
+		Type:          schema.TypeString,
+		Optional:      true,
+		ConflictsWith: []string{"instance_id"},
+		Description:   "Service Manager field query narrowing the instances looked up by name.",
+	}
+	s["label_query"] = &schema.Schema{
+		Type:          schema.TypeString,
+		Optional:      true,
+		ConflictsWith: []string{"instance_id"},
+		Description:   "Service Manager label query narrowing the instances looked up by name.",
+	}
+
+	return &schema.Resource{
+		ReadContext: dataSourceSapBtpServiceInstanceRead,
+		Schema:      s,
+	}
+}
+
+func dataSourceSapBtpServiceInstanceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	btpServiceManagementV1Client, err := meta.(*SAPClient).serviceManagementV1Client(d, "service_management")
+	if err != nil {
+		return diag.FromErr(errors.Errorf("BTP Service Management OAuth2;  %v", err))
+	}
+
+	var instance btpmanagment.InstanceItem
+	if instanceId, ok := d.GetOk("instance_id"); ok {
+		output, err := btpServiceManagementV1Client.GetServiceInstance(ctx, &btpmanagment.GetServiceInstanceInput{
+			ServiceInstanceID: instanceId.(string),
+		})
+		if err != nil {
+			return diag.Errorf("BTP Service Instance can't be read; %v",
+				newServiceManagementError(err, output.Error, output.StatusAndBodyFromResponse))
+		}
+		instance = output.InstanceItem
+	} else {
+		name := d.Get("name").(string)
+		instances, err := listServiceInstances(ctx, btpServiceManagementV1Client, &btpmanagment.GetServiceInstancesInput{
+			FieldQuery: serviceManagementFieldQuery(d.Get("field_query").(string), "name", name),
+			LabelQuery: d.Get("label_query").(string),
+		})
+		if err != nil {
+			return diag.FromErr(errors.Errorf("BTP Service Instance %s can't be read; %v", name, err))
+		}
+		switch len(instances) {
+		case 0:
+			return diag.Errorf("BTP Service Instance %s not found", name)
+		case 1:
+			instance = instances[0]
+		default:
+			return diag.Errorf("BTP Service Instance %s matches %d instances; narrow it down with 'label_query'",
+				name, len(instances))
+		}
+	}
+
+	d.SetId(instance.Id)
+	d.Set("instance_id", instance.Id)
+	for key, value := range flattenServiceInstance(&instance) {
+		if err := d.Set(key, value); err != nil {
+			return diag.FromErr(errors.Errorf("BTP Service Instance %s can't be set; %v", key, err))
+		}
+	}
+	return nil
+}
+
+// serviceInstanceSchema returns the attributes of a service instance read from Service Manager.
+func serviceInstanceSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"name": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"service_plan_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"platform_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"dashboard_url": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"ready": {
+			Type:     schema.TypeBool,
+			Computed: true,
+		},
+		"usable": {
+			Type:     schema.TypeBool,
+			Computed: true,
+		},
+		"context": {
+			Type:     schema.TypeMap,
+			Computed: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+		"maintenance_info": {
+			Type:     schema.TypeMap,
+			Computed: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+		"labels": labelsSchemaComputed(),
+		"created_at": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"updated_at": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+	}
+}
+
+func flattenServiceInstance(instance *btpmanagment.InstanceItem) map[string]interface{} {
+	return map[string]interface{}{
+		"name":             instance.Name,
+		"service_plan_id":  instance.ServicePlanId,
+		"platform_id":      instance.PlatformId,
+		"dashboard_url":    instance.DashboardUrl,
+		"ready":            instance.Ready,
+		"usable":           instance.Usable,
+		"context":          instance.Context,
+		"maintenance_info": instance.MaintenanceInfo,
+		"labels":           flattenLabels(instance.Labels),
+		"created_at":       instance.CreatedAt,
+		"updated_at":       instance.UpdatedAt,
+	}
+}
+
+// listServiceInstances returns all the instances matching the input, following the pages Service Manager
+// answers with.
+func listServiceInstances(ctx context.Context, client serviceManagementClient,
+	input *btpmanagment.GetServiceInstancesInput) ([]btpmanagment.InstanceItem, error) {
+
+	instances := make([]btpmanagment.InstanceItem, 0)
+	for {
+		output, err := client.GetServiceInstances(ctx, input)
+		if err != nil {
+			return nil, newServiceManagementError(err, output.Error, output.StatusAndBodyFromResponse)
+		}
+		instances = append(instances, output.Items...)
+		if output.Token == "" {
+			return instances, nil
+		}
+		input.Token = output.Token
+	}
+}
@@ -0,0 +1,92 @@
+package sap
+
+import (
+	"context"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nnicora/sap-sdk-go/service/btpmanagment"
+	"github.com/pkg/errors"
+)
+
+func dataSourceSapBtpServiceInstances() *schema.Resource {
+	instance := serviceInstanceSchema()
+	instance["id"] = &schema.Schema{
+		Type:     schema.TypeString,
+		Computed: true,
+	}
+
+	return &schema.Resource{
+		ReadContext: dataSourceSapBtpServiceInstancesRead,
+		Schema: map[string]*schema.Schema{
+			"endpoint_id":        endpointIdSchema("service_management"),
+			"service_management": endpointSchema("service_management"),
+
+			// Filters
+			"service_plan_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"field_query": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Service Manager field query the instances must match, e.g. \"usable eq true\".",
+			},
+			"label_query": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Service Manager label query the instances must match, e.g. \"environment eq 'dev'\".",
+			},
+
+			// Computed
+			"ids": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"instances": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Resource{Schema: instance},
+			},
+		},
+	}
+}
+
+func dataSourceSapBtpServiceInstancesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	btpServiceManagementV1Client, err := meta.(*SAPClient).serviceManagementV1Client(d, "service_management")
+	if err != nil {
+		return diag.FromErr(errors.Errorf("BTP Service Management OAuth2;  %v", err))
+	}
+
+	instances, err := listServiceInstances(ctx, btpServiceManagementV1Client, &btpmanagment.GetServiceInstancesInput{
+		FieldQuery: serviceManagementFieldQuery(d.Get("field_query").(string),
+			"service_plan_id", d.Get("service_plan_id").(string)),
+		LabelQuery: d.Get("label_query").(string),
+	})
+	if err != nil {
+		return diag.FromErr(errors.Errorf("BTP Service Instances can't be read; %v", err))
+	}
+
+	ids := make([]string, 0, len(instances))
+	result := make([]map[string]interface{}, 0, len(instances))
+	for idx := range instances {
+		ids = append(ids, instances[idx].Id)
+
+		m := flattenServiceInstance(&instances[idx])
+		m["id"] = instances[idx].Id
+		result = append(result, m)
+	}
+	d.Set("ids", ids)
+	if err := d.Set("instances", result); err != nil {
+		return diag.FromErr(errors.Errorf("BTP Service Instances can't be set; %v", err))
+	}
+
+	if uuidString, err := uuid.GenerateUUID(); err != nil {
+		return diag.FromErr(err)
+	} else {
+		d.SetId(uuidString)
+	}
+
+	return nil
+}
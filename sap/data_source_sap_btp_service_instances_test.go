@@ -0,0 +1,95 @@
+package sap
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"regexp"
+	"testing"
+)
+
+func TestAccSapBtpServiceInstancesDataSource_basic(t *testing.T) {
+	btp := newMockBtp(t)
+	dataSourceName := "data.sap_btp_service_instances.test"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpServiceInstancesDataSourceConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "ids.#", "1"),
+					resource.TestCheckResourceAttrPair(dataSourceName, "ids.0",
+						"sap_btp_sub_account_service_management_instances.dev", "id"),
+					resource.TestCheckResourceAttr(dataSourceName, "instances.0.name", "dev-instance"),
+					resource.TestCheckResourceAttr(dataSourceName, "instances.0.ready", "true"),
+					resource.TestCheckResourceAttr(dataSourceName, "instances.0.usable", "true"),
+					resource.TestCheckResourceAttr(dataSourceName, "instances.0.context.platform", "sapcp"),
+					resource.TestCheckResourceAttr(dataSourceName, "instances.0.labels.#", "1"),
+
+					resource.TestCheckResourceAttrPair("data.sap_btp_service_instance.by_name", "id",
+						"sap_btp_sub_account_service_management_instances.dev", "id"),
+					resource.TestCheckResourceAttr("data.sap_btp_service_instance.by_id", "name", "prod-instance"),
+					resource.TestCheckResourceAttr("data.sap_btp_service_instance.by_id", "service_plan_id",
+						"plan-destination-lite"),
+				),
+			},
+			{
+				Config: testAccSapBtpServiceInstancesResourcesConfig + `
+data "sap_btp_service_instance" "test" {
+  endpoint_id = "service-manager"
+  name        = "missing-instance"
+}
+`,
+				ExpectError: regexp.MustCompile("BTP Service Instance missing-instance not found"),
+			},
+		},
+	})
+}
+
+const testAccSapBtpServiceInstancesResourcesConfig = testAccProviderConfig + `
+resource "sap_btp_sub_account_service_management_instances" "dev" {
+  endpoint_id           = "service-manager"
+  name                  = "dev-instance"
+  service_offering_name = "destination"
+  service_plan_name     = "lite"
+
+  labels {
+    key    = "environment"
+    values = ["dev"]
+  }
+}
+
+resource "sap_btp_sub_account_service_management_instances" "prod" {
+  endpoint_id           = "service-manager"
+  name                  = "prod-instance"
+  service_offering_name = "destination"
+  service_plan_name     = "lite"
+
+  labels {
+    key    = "environment"
+    values = ["prod"]
+  }
+}
+`
+
+const testAccSapBtpServiceInstancesDataSourceConfig = testAccSapBtpServiceInstancesResourcesConfig + `
+data "sap_btp_service_instances" "test" {
+  endpoint_id     = "service-manager"
+  service_plan_id = "plan-destination-lite"
+  label_query     = "environment eq 'dev'"
+
+  depends_on = [
+    sap_btp_sub_account_service_management_instances.dev,
+    sap_btp_sub_account_service_management_instances.prod,
+  ]
+}
+
+data "sap_btp_service_instance" "by_name" {
+  endpoint_id = "service-manager"
+  name        = sap_btp_sub_account_service_management_instances.dev.name
+}
+
+data "sap_btp_service_instance" "by_id" {
+  endpoint_id = "service-manager"
+  instance_id = sap_btp_sub_account_service_management_instances.prod.id
+}
+`
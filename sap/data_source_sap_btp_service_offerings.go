@@ -0,0 +1,207 @@
+package sap
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nnicora/sap-sdk-go/service/btpmanagment"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+func dataSourceSapBtpServiceOfferings() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceSapBtpServiceOfferingsRead,
+		Schema: map[string]*schema.Schema{
+			"endpoint_id":        endpointIdSchema("service_management"),
+			"service_management": endpointSchema("service_management"),
+
+			// Filters
+			"name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Name of the service offering; reading fails when there's no such offering.",
+			},
+			"field_query": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Service Manager field query the offerings must match, e.g. \"bindable eq true\".",
+			},
+			"label_query": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Service Manager label query the offerings must match.",
+			},
+
+			// Computed
+			"ids": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"offerings": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"display_name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"description": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"catalog_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"catalog_name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"broker_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"ready": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"bindable": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"instances_retrievable": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"bindings_retrievable": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"plan_updateable": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"allow_context_updates": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"tags": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"created_at": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"updated_at": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceSapBtpServiceOfferingsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	btpServiceManagementV1Client, err := meta.(*SAPClient).serviceManagementV1Client(d, "service_management")
+	if err != nil {
+		return diag.FromErr(errors.Errorf("BTP Service Management OAuth2;  %v", err))
+	}
+
+	name := d.Get("name").(string)
+	offerings, err := listServiceOfferings(ctx, btpServiceManagementV1Client, &btpmanagment.GetServiceOfferingsInput{
+		FieldQuery: serviceManagementFieldQuery(d.Get("field_query").(string), "name", name),
+		LabelQuery: d.Get("label_query").(string),
+	})
+	if err != nil {
+		return diag.FromErr(errors.Errorf("BTP Service Offerings can't be read; %v", err))
+	}
+	if name != "" && len(offerings) == 0 {
+		return diag.Errorf("BTP Service Offering %s not found", name)
+	}
+
+	ids := make([]string, 0, len(offerings))
+	result := make([]map[string]interface{}, 0, len(offerings))
+	for _, offering := range offerings {
+		ids = append(ids, offering.Id)
+		result = append(result, map[string]interface{}{
+			"id":                    offering.Id,
+			"name":                  offering.Name,
+			"display_name":          offering.Metadata.DisplayName,
+			"description":           offering.Description,
+			"catalog_id":            offering.CatalogId,
+			"catalog_name":          offering.CatalogName,
+			"broker_id":             offering.BrokerId,
+			"ready":                 offering.Ready,
+			"bindable":              offering.Bindable,
+			"instances_retrievable": offering.InstancesRetrievable,
+			"bindings_retrievable":  offering.BindingsRetrievable,
+			"plan_updateable":       offering.PlanUpdateable,
+			"allow_context_updates": offering.AllowContextUpdates,
+			"tags":                  offering.Tags,
+			"created_at":            offering.CreatedAt,
+			"updated_at":            offering.UpdatedAt,
+		})
+	}
+	d.Set("ids", ids)
+	d.Set("offerings", result)
+
+	if uuidString, err := uuid.GenerateUUID(); err != nil {
+		return diag.FromErr(err)
+	} else {
+		d.SetId(uuidString)
+	}
+
+	return nil
+}
+
+// listServiceOfferings returns all the offerings matching the input, following the pages Service Manager
+// answers with.
+func listServiceOfferings(ctx context.Context, client serviceManagementClient,
+	input *btpmanagment.GetServiceOfferingsInput) ([]btpmanagment.OfferingItem, error) {
+
+	offerings := make([]btpmanagment.OfferingItem, 0)
+	for {
+		output, err := client.GetServiceOfferings(ctx, input)
+		if err != nil {
+			return nil, newServiceManagementError(err, output.Error, output.StatusAndBodyFromResponse)
+		}
+		offerings = append(offerings, output.Items...)
+		if output.Token == "" {
+			return offerings, nil
+		}
+		input.Token = output.Token
+	}
+}
+
+// serviceManagementFieldQuery joins the configured field query with the criteria, pairs of a field and the value
+// it must equal; criteria without a value are left out.
+func serviceManagementFieldQuery(query string, criteria ...string) string {
+	clauses := make([]string, 0, len(criteria)/2+1)
+	if query != "" {
+		clauses = append(clauses, query)
+	}
+	for idx := 0; idx+1 < len(criteria); idx += 2 {
+		if criteria[idx+1] != "" {
+			value := strings.ReplaceAll(criteria[idx+1], "'", "''")
+			clauses = append(clauses, fmt.Sprintf("%s eq '%s'", criteria[idx], value))
+		}
+	}
+	return strings.Join(clauses, " and ")
+}
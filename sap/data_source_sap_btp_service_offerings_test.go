@@ -0,0 +1,68 @@
+package sap
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"regexp"
+	"testing"
+)
+
+func TestAccSapBtpServiceOfferingsDataSource_basic(t *testing.T) {
+	btp := newMockBtp(t)
+	dataSourceName := "data.sap_btp_service_offerings.test"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpServiceOfferingsDataSourceConfig(""),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "ids.#", "2"),
+					resource.TestCheckResourceAttr(dataSourceName, "offerings.#", "2"),
+				),
+			},
+			{
+				Config: testAccSapBtpServiceOfferingsDataSourceConfig(`name = "xsuaa"`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "ids.#", "1"),
+					resource.TestCheckResourceAttr(dataSourceName, "ids.0", "offering-xsuaa"),
+					resource.TestCheckResourceAttr(dataSourceName, "offerings.0.catalog_name", "xsuaa"),
+					resource.TestCheckResourceAttr(dataSourceName, "offerings.0.bindable", "true"),
+					resource.TestCheckResourceAttr(dataSourceName, "offerings.0.plan_updateable", "true"),
+				),
+			},
+			{
+				Config:      testAccSapBtpServiceOfferingsDataSourceConfig(`name = "xsuaaa"`),
+				ExpectError: regexp.MustCompile("BTP Service Offering xsuaaa not found"),
+			},
+		},
+	})
+}
+
+func testAccSapBtpServiceOfferingsDataSourceConfig(filters string) string {
+	return testAccProviderConfig + fmt.Sprintf(`
+data "sap_btp_service_offerings" "test" {
+  endpoint_id = "service-manager"
+  %s
+}
+`, filters)
+}
+
+func TestServiceManagementFieldQuery(t *testing.T) {
+	cases := []struct {
+		query    string
+		criteria []string
+		expected string
+	}{
+		{"", nil, ""},
+		{"", []string{"name", "xsuaa"}, "name eq 'xsuaa'"},
+		{"", []string{"name", "", "service_offering_id", "offering-1"}, "service_offering_id eq 'offering-1'"},
+		{"free eq true", []string{"name", "lite"}, "free eq true and name eq 'lite'"},
+		{"", []string{"name", "it's"}, "name eq 'it''s'"},
+	}
+	for _, c := range cases {
+		if got := serviceManagementFieldQuery(c.query, c.criteria...); got != c.expected {
+			t.Errorf("field query of %q %v is %q, expected %q", c.query, c.criteria, got, c.expected)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package sap
+
+import (
+	"context"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nnicora/sap-sdk-go/service/btpmanagment"
+	"github.com/pkg/errors"
+)
+
+func dataSourceSapBtpServicePlans() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceSapBtpServicePlansRead,
+		Schema: map[string]*schema.Schema{
+			"endpoint_id":        endpointIdSchema("service_management"),
+			"service_management": endpointSchema("service_management"),
+
+			// Filters
+			"name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Name of the service plan; reading fails when there's no such plan.",
+			},
+			"service_offering_id": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"service_offering_name"},
+			},
+			"service_offering_name": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"service_offering_id"},
+				Description:   "Name of the service offering of the plans; reading fails when there's no such offering.",
+			},
+			"field_query": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Service Manager field query the plans must match, e.g. \"free eq true\".",
+			},
+			"label_query": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Service Manager label query the plans must match.",
+			},
+
+			// Computed
+			"ids": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"plans": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"description": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"catalog_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"catalog_name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"service_offering_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"ready": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"free": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"bindable": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"supported_platforms": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"schemas": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The JSON schemas of the parameters the plan's instances and bindings take, as JSON.",
+						},
+						"created_at": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"updated_at": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceSapBtpServicePlansRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	btpServiceManagementV1Client, err := meta.(*SAPClient).serviceManagementV1Client(d, "service_management")
+	if err != nil {
+		return diag.FromErr(errors.Errorf("BTP Service Management OAuth2;  %v", err))
+	}
+
+	offeringId := d.Get("service_offering_id").(string)
+	if offeringName, ok := d.GetOk("service_offering_name"); ok {
+		offerings, err := listServiceOfferings(ctx, btpServiceManagementV1Client, &btpmanagment.GetServiceOfferingsInput{
+			FieldQuery: serviceManagementFieldQuery("", "name", offeringName.(string)),
+		})
+		if err != nil {
+			return diag.FromErr(errors.Errorf("BTP Service Offering %s can't be read; %v", offeringName, err))
+		}
+		if len(offerings) == 0 {
+			return diag.Errorf("BTP Service Offering %s not found", offeringName)
+		}
+		offeringId = offerings[0].Id
+	}
+
+	name := d.Get("name").(string)
+	plans, err := listServicePlans(ctx, btpServiceManagementV1Client, &btpmanagment.GetServicePlansInput{
+		FieldQuery: serviceManagementFieldQuery(d.Get("field_query").(string),
+			"name", name, "service_offering_id", offeringId),
+		LabelQuery: d.Get("label_query").(string),
+	})
+	if err != nil {
+		return diag.FromErr(errors.Errorf("BTP Service Plans can't be read; %v", err))
+	}
+	if name != "" && len(plans) == 0 {
+		return diag.Errorf("BTP Service Plan %s not found", name)
+	}
+
+	ids := make([]string, 0, len(plans))
+	result := make([]map[string]interface{}, 0, len(plans))
+	for _, plan := range plans {
+		ids = append(ids, plan.Id)
+		result = append(result, map[string]interface{}{
+			"id":                  plan.Id,
+			"name":                plan.Name,
+			"description":         plan.Description,
+			"catalog_id":          plan.CatalogId,
+			"catalog_name":        plan.CatalogName,
+			"service_offering_id": plan.ServiceOfferingId,
+			"ready":               plan.Ready,
+			"free":                plan.Free,
+			"bindable":            plan.Bindable,
+			"supported_platforms": plan.Metadata.SupportedPlatforms,
+			"schemas":             string(plan.Schemas),
+			"created_at":          plan.CreatedAt,
+			"updated_at":          plan.UpdatedAt,
+		})
+	}
+	d.Set("ids", ids)
+	d.Set("plans", result)
+
+	if uuidString, err := uuid.GenerateUUID(); err != nil {
+		return diag.FromErr(err)
+	} else {
+		d.SetId(uuidString)
+	}
+
+	return nil
+}
+
+// listServicePlans returns all the plans matching the input, following the pages Service Manager answers with.
+func listServicePlans(ctx context.Context, client serviceManagementClient,
+	input *btpmanagment.GetServicePlansInput) ([]servicePlanItem, error) {
+
+	plans := make([]servicePlanItem, 0)
+	for {
+		output, err := client.getServicePlans(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, output.Items...)
+		if output.Token == "" {
+			return plans, nil
+		}
+		input.Token = output.Token
+	}
+}
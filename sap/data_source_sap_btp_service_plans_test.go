@@ -0,0 +1,60 @@
+package sap
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"regexp"
+	"testing"
+)
+
+func TestAccSapBtpServicePlansDataSource_basic(t *testing.T) {
+	btp := newMockBtp(t)
+	dataSourceName := "data.sap_btp_service_plans.test"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpServicePlansDataSourceConfig(`service_offering_name = "xsuaa"`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "ids.#", "2"),
+					resource.TestCheckResourceAttr(dataSourceName, "plans.0.service_offering_id", "offering-xsuaa"),
+				),
+			},
+			{
+				Config: testAccSapBtpServicePlansDataSourceConfig(`
+  service_offering_name = "xsuaa"
+  name                  = "application"
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "ids.#", "1"),
+					resource.TestCheckResourceAttr(dataSourceName, "ids.0", "plan-xsuaa-application"),
+					resource.TestCheckResourceAttr(dataSourceName, "plans.0.catalog_name", "application"),
+					resource.TestCheckResourceAttr(dataSourceName, "plans.0.bindable", "true"),
+					resource.TestCheckResourceAttr(dataSourceName, "plans.0.schemas",
+						`{"service_instance":{"create":{"parameters":{"type":"object"}}}}`),
+				),
+			},
+			{
+				Config: testAccSapBtpServicePlansDataSourceConfig(`
+  service_offering_name = "xsuaa"
+  name                  = "aplication"
+`),
+				ExpectError: regexp.MustCompile("BTP Service Plan aplication not found"),
+			},
+			{
+				Config:      testAccSapBtpServicePlansDataSourceConfig(`service_offering_name = "xsuua"`),
+				ExpectError: regexp.MustCompile("BTP Service Offering xsuua not found"),
+			},
+		},
+	})
+}
+
+func testAccSapBtpServicePlansDataSourceConfig(filters string) string {
+	return testAccProviderConfig + fmt.Sprintf(`
+data "sap_btp_service_plans" "test" {
+  endpoint_id = "service-manager"
+  %s
+}
+`, filters)
+}
@@ -0,0 +1,22 @@
+package sap
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nnicora/sap-sdk-go/service/btpentitlements"
+)
+
+func dataSourceSapBtpSubAccountEntitlements() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceSapBtpSubAccountEntitlementsRead,
+		Schema:      entityEntitlementsSchema("sub_account_id"),
+	}
+}
+
+func dataSourceSapBtpSubAccountEntitlementsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	subAccountId := d.Get("sub_account_id").(string)
+	return readEntityEntitlements(ctx, d, meta, "Sub Account", subAccountId, &btpentitlements.GetAssignmentsInput{
+		SubAccountGuid: subAccountId,
+	})
+}
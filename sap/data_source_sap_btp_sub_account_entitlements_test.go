@@ -0,0 +1,42 @@
+package sap
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"testing"
+)
+
+func TestSapBtpSubAccountEntitlementsDataSourceRead(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: newFakeSubAccountEntitlements()})
+
+	d := schema.TestResourceDataRaw(t, dataSourceSapBtpSubAccountEntitlements().Schema, map[string]interface{}{
+		"sub_account_id": "sub-account-1",
+	})
+	if diags := dataSourceSapBtpSubAccountEntitlementsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	expected := map[string]interface{}{
+		"entitlements.#":              1,
+		"entitlements.0.service_name": "xsuaa",
+		"entitlements.0.plan_name":    "application",
+		"entitlements.0.amount":       4.0,
+		"entitlements.0.enable":       false,
+	}
+	for key, value := range expected {
+		if got := d.Get(key); got != value {
+			t.Errorf("%s is %v, expected %v", key, got, value)
+		}
+	}
+}
+
+func TestSapBtpSubAccountEntitlementsDataSourceRead_notFound(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: newFakeSubAccountEntitlements()})
+
+	d := schema.TestResourceDataRaw(t, dataSourceSapBtpSubAccountEntitlements().Schema, map[string]interface{}{
+		"sub_account_id": "sub-account-2",
+	})
+	if diags := dataSourceSapBtpSubAccountEntitlementsRead(context.Background(), d, meta); !diags.HasError() {
+		t.Fatal("expected an error")
+	}
+}
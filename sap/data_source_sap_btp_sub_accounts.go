@@ -0,0 +1,271 @@
+package sap
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/nnicora/sap-sdk-go/sap"
+	"github.com/nnicora/sap-sdk-go/service/btpaccounts"
+	"github.com/pkg/errors"
+	"regexp"
+	"sort"
+	"time"
+)
+
+func dataSourceSapBtpSubAccounts() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceSapBtpSubAccountsRead,
+		Schema: map[string]*schema.Schema{
+			"derived_authorizations": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
+			},
+
+			// Filters
+			"directory_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Lists only the sub accounts of the directory.",
+			},
+			"region": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"used_for_production": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ValidateFunc: validation.StringInSlice([]string{
+					"UNSET", "USED_FOR_PRODUCTION", "NOT_USED_FOR_PRODUCTION",
+				}, false),
+			},
+			"state": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"display_name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+			"custom_properties": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Lists only the sub accounts holding all of these custom properties, with these values.",
+			},
+
+			// Computed
+			"ids": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"sub_accounts": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"global_account_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"beta_enabled": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"created_by": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"created_date": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"description": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"display_name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"modified_date": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"parent_features": {
+							Type:     schema.TypeSet,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+							Computed: true,
+							Set:      schema.HashString,
+						},
+						"parent_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"region": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"state": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"state_message": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"subdomain": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"used_for_production": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"zone_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"custom_properties": {
+							Type:     schema.TypeMap,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceSapBtpSubAccountsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	btpAccountsClient := meta.(*SAPClient).btpAccountsV1Client
+
+	filter, err := subAccountsFilterFrom(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	input := &btpaccounts.GetSubAccountsInput{
+		DerivedAuthorizations: d.Get("derived_authorizations").(string),
+		DirectoryGuid:         d.Get("directory_id").(string),
+	}
+	output, err := btpAccountsClient.GetSubAccounts(ctx, input)
+	if err != nil {
+		if output != nil && output.Error != nil {
+			return diag.FromErr(
+				errors.Errorf("BTP Sub Accounts can't be read; %s", sap.StringValue(output.Error.Message)))
+		}
+		return diag.FromErr(errors.Errorf("BTP Sub Accounts can't be read; %v", err))
+	}
+
+	subAccounts := make([]btpaccounts.SubAccount, 0, len(output.Value))
+	for _, sa := range output.Value {
+		if filter.matches(&sa) {
+			subAccounts = append(subAccounts, sa)
+		}
+	}
+	// The API doesn't guarantee an order, which would show up as changes
+	sort.Slice(subAccounts, func(i, j int) bool {
+		return subAccounts[i].Guid < subAccounts[j].Guid
+	})
+
+	ids := make([]string, 0, len(subAccounts))
+	result := make([]map[string]interface{}, 0, len(subAccounts))
+	for _, sa := range subAccounts {
+		ids = append(ids, sa.Guid)
+		result = append(result, flattenSubAccount(&sa))
+	}
+	d.Set("ids", ids)
+	d.Set("sub_accounts", result)
+
+	if uuidString, err := uuid.GenerateUUID(); err != nil {
+		return diag.FromErr(err)
+	} else {
+		d.SetId(uuidString)
+	}
+
+	return nil
+}
+
+// subAccountsFilter holds the conditions a sub account must meet to be listed; the Accounts API filters by
+// directory only.
+type subAccountsFilter struct {
+	region            string
+	usedForProduction string
+	state             string
+	displayName       *regexp.Regexp
+	customProperties  map[string]string
+}
+
+func subAccountsFilterFrom(d *schema.ResourceData) (*subAccountsFilter, error) {
+	filter := &subAccountsFilter{
+		region:            d.Get("region").(string),
+		usedForProduction: d.Get("used_for_production").(string),
+		state:             d.Get("state").(string),
+		customProperties:  expandMapString(d.Get("custom_properties")),
+	}
+	if val, ok := d.GetOk("display_name_regex"); ok {
+		re, err := regexp.Compile(val.(string))
+		if err != nil {
+			return nil, fmt.Errorf("display_name_regex is invalid; %v", err)
+		}
+		filter.displayName = re
+	}
+	return filter, nil
+}
+
+func (f *subAccountsFilter) matches(sa *btpaccounts.SubAccount) bool {
+	if f.region != "" && sa.Region != f.region {
+		return false
+	}
+	if f.usedForProduction != "" && sa.UsedForProduction != f.usedForProduction {
+		return false
+	}
+	if f.state != "" && sa.State != f.state {
+		return false
+	}
+	if f.displayName != nil && !f.displayName.MatchString(sa.DisplayName) {
+		return false
+	}
+	if len(f.customProperties) > 0 {
+		properties := flattenCustomProperties(sa.CustomProperties)
+		for key, value := range f.customProperties {
+			if properties[key] != value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func flattenSubAccount(sa *btpaccounts.SubAccount) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                  sa.Guid,
+		"global_account_id":   sa.GlobalAccountGuid,
+		"beta_enabled":        sa.BetaEnabled,
+		"created_by":          sa.CreatedBy,
+		"created_date":        sa.CreatedDate.Format(time.RFC3339),
+		"description":         sa.Description,
+		"display_name":        sa.DisplayName,
+		"modified_date":       sa.ModifiedDate.Format(time.RFC3339),
+		"parent_features":     sa.ParentFeatures,
+		"parent_id":           sa.ParentGuid,
+		"region":              sa.Region,
+		"state":               sa.State,
+		"state_message":       sa.StateMessage,
+		"subdomain":           sa.Subdomain,
+		"used_for_production": sa.UsedForProduction,
+		"zone_id":             sa.ZoneId,
+		"custom_properties":   flattenCustomProperties(sa.CustomProperties),
+	}
+}
@@ -0,0 +1,132 @@
+package sap
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/nnicora/sap-sdk-go/service/btpaccounts"
+	"regexp"
+	"testing"
+)
+
+func TestAccSapBtpSubAccountsDataSource_filter(t *testing.T) {
+	btp := newMockBtp(t)
+	dataSourceName := "data.sap_btp_sub_accounts.test"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		CheckDestroy:      testAccCheckDestroyed(btp, "sap_btp_sub_account", btp.subAccounts),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpSubAccountsDataSourceConfig(`
+  region              = "eu10"
+  used_for_production = "USED_FOR_PRODUCTION"
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "ids.#", "2"),
+					resource.TestCheckResourceAttr(dataSourceName, "sub_accounts.#", "2"),
+					resource.TestCheckTypeSetElemAttrPair(dataSourceName, "ids.*", "sap_btp_sub_account.prod_eu", "id"),
+					resource.TestCheckTypeSetElemAttrPair(dataSourceName, "ids.*", "sap_btp_sub_account.prod_eu_billing", "id"),
+				),
+			},
+			{
+				Config: testAccSapBtpSubAccountsDataSourceConfig(`
+  display_name_regex = "^Billing"
+  custom_properties  = {
+    cost_center = "2000"
+  }
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "ids.#", "1"),
+					resource.TestCheckResourceAttrPair(dataSourceName, "ids.0", "sap_btp_sub_account.prod_eu_billing", "id"),
+					resource.TestCheckResourceAttr(dataSourceName, "sub_accounts.0.display_name", "Billing Production"),
+					resource.TestCheckResourceAttr(dataSourceName, "sub_accounts.0.region", "eu10"),
+					resource.TestCheckResourceAttr(dataSourceName, "sub_accounts.0.state", "OK"),
+					resource.TestCheckResourceAttr(dataSourceName, "sub_accounts.0.custom_properties.cost_center", "2000"),
+				),
+			},
+		},
+	})
+}
+
+func testAccSapBtpSubAccountsDataSourceConfig(filters string) string {
+	return testAccProviderConfig + fmt.Sprintf(`
+resource "sap_btp_sub_account" "prod_eu" {
+  global_account_id   = %[1]q
+  region              = "eu10"
+  display_name        = "Sales Production"
+  subdomain           = "sales-production"
+  used_for_production = "USED_FOR_PRODUCTION"
+  origin              = "test"
+  custom_properties   = {
+    cost_center = "1000"
+  }
+}
+
+resource "sap_btp_sub_account" "prod_eu_billing" {
+  global_account_id   = %[1]q
+  region              = "eu10"
+  display_name        = "Billing Production"
+  subdomain           = "billing-production"
+  used_for_production = "USED_FOR_PRODUCTION"
+  origin              = "test"
+  custom_properties   = {
+    cost_center = "2000"
+  }
+}
+
+resource "sap_btp_sub_account" "prod_us" {
+  global_account_id   = %[1]q
+  region              = "us10"
+  display_name        = "Billing Production US"
+  subdomain           = "billing-production-us"
+  used_for_production = "USED_FOR_PRODUCTION"
+  origin              = "test"
+}
+
+data "sap_btp_sub_accounts" "test" {
+%[2]s
+  depends_on = [
+    sap_btp_sub_account.prod_eu,
+    sap_btp_sub_account.prod_eu_billing,
+    sap_btp_sub_account.prod_us,
+  ]
+}
+`, mockBtpGlobalAccountId, filters)
+}
+
+func TestSapBtpSubAccountsFilter(t *testing.T) {
+	subAccount := &btpaccounts.SubAccount{
+		DisplayName:       "Billing Production",
+		Region:            "eu10",
+		State:             "OK",
+		UsedForProduction: "USED_FOR_PRODUCTION",
+		CustomProperties: []btpaccounts.CustomProperties{
+			{KeyValue: btpaccounts.KeyValue{Key: "cost_center", Value: "2000"}},
+		},
+	}
+
+	cases := []struct {
+		name    string
+		filter  subAccountsFilter
+		matches bool
+	}{
+		{"none", subAccountsFilter{}, true},
+		{"region", subAccountsFilter{region: "eu10"}, true},
+		{"other region", subAccountsFilter{region: "us10"}, false},
+		{"production", subAccountsFilter{usedForProduction: "USED_FOR_PRODUCTION"}, true},
+		{"not production", subAccountsFilter{usedForProduction: "NOT_USED_FOR_PRODUCTION"}, false},
+		{"state", subAccountsFilter{state: "CREATING"}, false},
+		{"display name", subAccountsFilter{displayName: regexp.MustCompile("^Billing")}, true},
+		{"other display name", subAccountsFilter{displayName: regexp.MustCompile("^Sales")}, false},
+		{"custom property", subAccountsFilter{customProperties: map[string]string{"cost_center": "2000"}}, true},
+		{"other custom property", subAccountsFilter{customProperties: map[string]string{"cost_center": "1000"}}, false},
+		{"missing custom property", subAccountsFilter{customProperties: map[string]string{"owner": "team-a"}}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.filter.matches(subAccount); got != c.matches {
+				t.Errorf("matches is %t, expected %t", got, c.matches)
+			}
+		})
+	}
+}
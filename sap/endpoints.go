@@ -0,0 +1,103 @@
+package sap
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// endpointIdSchema returns the schema of the reference to a provider level 'service_endpoint'; it is the
+// alternative of the inline endpoint block named by blockName.
+func endpointIdSchema(blockName string) *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		ExactlyOneOf: []string{"endpoint_id", blockName},
+		Description:  "Id of a provider level 'service_endpoint' to use.",
+	}
+}
+
+// endpointSchema returns the schema of an inline service endpoint, having its own host and OAuth2 client.
+func endpointSchema(blockName string) *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeList,
+		Optional:     true,
+		MaxItems:     1,
+		ExactlyOneOf: []string{"endpoint_id", blockName},
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"host": {
+					Type:     schema.TypeString,
+					Required: true,
+				},
+				"oauth2": oauth2Schema(true),
+			},
+		},
+	}
+}
+
+func oauth2Schema(required bool) *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Required: required,
+		Optional: !required,
+		MaxItems: 1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"grant_type": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Default:     "client_credentials",
+					Description: "SAP OAuth2 Grant Type.",
+				},
+				"client_id": {
+					Type:        schema.TypeString,
+					Required:    true,
+					Description: "SAP OAuth2 Client Id.",
+				},
+				"client_secret": {
+					Type:        schema.TypeString,
+					Required:    true,
+					Sensitive:   true,
+					Description: "SAP OAuth2 Client Secret.",
+				},
+				"token_url": {
+					Type:        schema.TypeString,
+					Required:    true,
+					Description: "SAP OAuth2 Token Url.",
+				},
+				"authorization_url": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Default:     "",
+					Description: "SAP OAuth2 Authorization Url.",
+				},
+				"redirect_url": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Default:     "",
+					Description: "SAP OAuth2 Redirect Url.",
+				},
+
+				"username": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Default:     "",
+					Description: "SAP OAuth2 Username. Used in case if 'grant_type=password'.",
+				},
+				"password": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Default:     "",
+					Sensitive:   true,
+					Description: "SAP OAuth2 Password. Used in case if 'grant_type=password'.",
+				},
+
+				"timeout_seconds": {
+					Type:        schema.TypeInt,
+					Optional:    true,
+					Default:     60,
+					Description: "SAP OAuth2 HTTP Client timeout.",
+				},
+			},
+		},
+	}
+}
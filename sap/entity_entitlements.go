@@ -0,0 +1,137 @@
+package sap
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/nnicora/sap-sdk-go/sap"
+	"github.com/nnicora/sap-sdk-go/service/btpentitlements"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+// entityEntitlementsSchema returns the schema of the data sources listing the entitlements assigned to a sub
+// account or a directory, which idKey holds the id of. The entitlements are flat, in the shape the entitlement
+// resources take them.
+func entityEntitlementsSchema(idKey string) map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		idKey: {
+			Type:         schema.TypeString,
+			Required:     true,
+			ValidateFunc: validation.StringIsNotWhiteSpace,
+		},
+		"include_auto_managed_plans": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  false,
+		},
+		"service_name": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Lists only the entitlements of the service.",
+		},
+
+		"entitlements": {
+			Type:     schema.TypeList,
+			Computed: true,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"service_name": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+					"plan_name": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+					"category": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+					"amount": {
+						Type:     schema.TypeFloat,
+						Computed: true,
+					},
+					"unlimited": {
+						Type:        schema.TypeBool,
+						Computed:    true,
+						Description: "Whether the plan has no numeric quota.",
+					},
+					"enable": {
+						Type:        schema.TypeBool,
+						Computed:    true,
+						Description: "Whether the plan is enabled without a numeric quota.",
+					},
+					"auto_assign": {
+						Type:     schema.TypeBool,
+						Computed: true,
+					},
+					"auto_assigned": {
+						Type:     schema.TypeBool,
+						Computed: true,
+					},
+					"auto_distribute_amount": {
+						Type:     schema.TypeInt,
+						Computed: true,
+					},
+					"state": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+				},
+			},
+		},
+	}
+}
+
+// readEntityEntitlements reads the entitlements assigned to the sub account or directory the input asks for,
+// skipping the ones of the sub accounts within a directory.
+func readEntityEntitlements(ctx context.Context, d *schema.ResourceData, meta interface{}, kind string,
+	entityId string, input *btpentitlements.GetAssignmentsInput) diag.Diagnostics {
+	btpEntitlementsV1Client := meta.(*SAPClient).btpEntitlementsV1Client
+
+	input.IncludeAutoManagedPlans = d.Get("include_auto_managed_plans").(bool)
+	output, err := btpEntitlementsV1Client.GetAssignments(ctx, input)
+	if err != nil {
+		if output != nil && output.Error != nil {
+			return diag.FromErr(errors.Errorf("BTP %s Entitlements can't be read; Operation code %v; %s",
+				kind, output.StatusCode, sap.StringValue(output.Error.Message)))
+		}
+		return diag.FromErr(errors.Errorf("BTP %s Entitlements can't be read; %v", kind, err))
+	}
+
+	serviceName := d.Get("service_name").(string)
+	entitlements := make([]map[string]interface{}, 0)
+	forEachEntityAssignment(output.AssignedServices, entityId, func(name string,
+		plan *btpentitlements.AssignedServicePlan, info *btpentitlements.AssignedServicePlanSubAccount) {
+
+		if serviceName != "" && name != serviceName {
+			return
+		}
+		entitlements = append(entitlements, map[string]interface{}{
+			"service_name":           name,
+			"plan_name":              plan.Name,
+			"category":               plan.Category,
+			"amount":                 float64(info.Amount),
+			"unlimited":              plan.Unlimited,
+			"enable":                 info.UnlimitedAmountAssigned,
+			"auto_assign":            info.AutoAssign,
+			"auto_assigned":          info.AutoAssigned,
+			"auto_distribute_amount": int(info.AutoDistributeAmount),
+			"state":                  info.EntityState,
+		})
+	})
+	sort.Slice(entitlements, func(i, j int) bool {
+		if entitlements[i]["service_name"] != entitlements[j]["service_name"] {
+			return entitlements[i]["service_name"].(string) < entitlements[j]["service_name"].(string)
+		}
+		return entitlements[i]["plan_name"].(string) < entitlements[j]["plan_name"].(string)
+	})
+
+	d.SetId(entityId)
+	if err := d.Set("entitlements", entitlements); err != nil {
+		return diag.FromErr(errors.Errorf("BTP %s Entitlements can't be set; %v", kind, err))
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package flatten
 import (
 	"fmt"
 	"reflect"
+	"strconv"
 )
 
 // Based on the Terraform implementation at https://github.com/hashicorp/terraform/blob/master/flatmap/flatten.go
@@ -12,7 +13,9 @@ import (
 //
 // Within the "thing" parameter, only primitive values are allowed. Structs are
 // not supported. Therefore, it can only be slices, maps, primitives, and
-// any combination of those together.
+// any combination of those together. Nil values turn into empty strings and
+// floats are printed without exponent nor trailing zeros, so the numbers
+// decoded from JSON keep their original look.
 //
 // See the tests for examples of what inputs are turned into.
 func Flatten(thing map[string]interface{}) map[string]string {
@@ -26,22 +29,30 @@ func Flatten(thing map[string]interface{}) map[string]string {
 }
 
 func flatten(result map[string]string, prefix string, v reflect.Value) {
-	if v.Kind() == reflect.Interface {
+	if v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
 		v = v.Elem()
 	}
 
 	switch v.Kind() {
+	case reflect.Invalid:
+		result[prefix] = ""
 	case reflect.Bool:
 		if v.Bool() {
 			result[prefix] = "true"
 		} else {
 			result[prefix] = "false"
 		}
-	case reflect.Int:
-		result[prefix] = fmt.Sprintf("%d", v.Int())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		result[prefix] = strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		result[prefix] = strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32:
+		result[prefix] = strconv.FormatFloat(v.Float(), 'f', -1, 32)
+	case reflect.Float64:
+		result[prefix] = strconv.FormatFloat(v.Float(), 'f', -1, 64)
 	case reflect.Map:
 		flattenMap(result, prefix, v)
-	case reflect.Slice:
+	case reflect.Slice, reflect.Array:
 		flattenSlice(result, prefix, v)
 	case reflect.String:
 		result[prefix] = v.String()
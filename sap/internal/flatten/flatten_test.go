@@ -0,0 +1,95 @@
+package flatten
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestFlatten(t *testing.T) {
+	cases := []struct {
+		input    map[string]interface{}
+		expected map[string]string
+	}{
+		{
+			input: map[string]interface{}{
+				"foo": "bar",
+				"bar": true,
+				"baz": 42,
+			},
+			expected: map[string]string{
+				"foo": "bar",
+				"bar": "true",
+				"baz": "42",
+			},
+		},
+		{
+			input: map[string]interface{}{
+				"port":    float64(30015),
+				"ratio":   0.25,
+				"big":     int64(9007199254740993),
+				"missing": nil,
+			},
+			expected: map[string]string{
+				"port":    "30015",
+				"ratio":   "0.25",
+				"big":     "9007199254740993",
+				"missing": "",
+			},
+		},
+		{
+			input: map[string]interface{}{
+				"uaa": map[string]interface{}{
+					"clientid": "id",
+					"tenant": map[string]interface{}{
+						"subdomain": "sub",
+					},
+				},
+			},
+			expected: map[string]string{
+				"uaa.clientid":         "id",
+				"uaa.tenant.subdomain": "sub",
+			},
+		},
+		{
+			input: map[string]interface{}{
+				"certificates": []interface{}{
+					[]interface{}{"root", "intermediate"},
+					map[string]interface{}{"pem": "leaf"},
+				},
+			},
+			expected: map[string]string{
+				"certificates.#":     "2",
+				"certificates.0.#":   "2",
+				"certificates.0.0":   "root",
+				"certificates.0.1":   "intermediate",
+				"certificates.1.pem": "leaf",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		actual := Flatten(c.input)
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("Flatten(%v) = %v, expected %v", c.input, actual, c.expected)
+		}
+	}
+}
+
+func TestFlattenJson(t *testing.T) {
+	var credentials map[string]interface{}
+	if err := json.Unmarshal([]byte(`{"host":"hana","port":30015,"encrypt":true,"schema":null}`),
+		&credentials); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"host":    "hana",
+		"port":    "30015",
+		"encrypt": "true",
+		"schema":  "",
+	}
+	if actual := Flatten(credentials); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Flatten(%v) = %v, expected %v", credentials, actual, expected)
+	}
+}
@@ -0,0 +1,185 @@
+package sap
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nnicora/sap-sdk-go/service/btpmanagment"
+	"sort"
+)
+
+// labelsSchema returns the schema to use for Service Manager labels; a label has a key and a list of values.
+func labelsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeSet,
+		Optional: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"key": {
+					Type:     schema.TypeString,
+					Required: true,
+				},
+				"values": {
+					Type:     schema.TypeSet,
+					Required: true,
+					MinItems: 1,
+					Elem:     &schema.Schema{Type: schema.TypeString},
+				},
+			},
+		},
+	}
+}
+
+// labelsSchemaForceNew returns the schema of Service Manager labels which can't be updated, hence replace the object.
+func labelsSchemaForceNew() *schema.Schema {
+	labels := labelsSchema()
+	labels.ForceNew = true
+	for _, attribute := range labels.Elem.(*schema.Resource).Schema {
+		attribute.ForceNew = true
+	}
+	return labels
+}
+
+// labelsSchemaComputed returns the schema of Service Manager labels read from the API only.
+func labelsSchemaComputed() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeSet,
+		Computed: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"key": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"values": {
+					Type:     schema.TypeSet,
+					Computed: true,
+					Elem:     &schema.Schema{Type: schema.TypeString},
+				},
+			},
+		},
+	}
+}
+
+func expandLabels(data interface{}) map[string][]string {
+	result := make(map[string][]string)
+
+	set, ok := data.(*schema.Set)
+	if !ok {
+		return result
+	}
+	for _, item := range set.List() {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		key := m["key"].(string)
+		if values, ok := m["values"].(*schema.Set); ok {
+			result[key] = append(result[key], expandStringSet(values)...)
+		}
+	}
+	return result
+}
+
+func flattenLabels(labels map[string][]string) []interface{} {
+	result := make([]interface{}, 0, len(labels))
+	for key, values := range labels {
+		result = append(result, map[string]interface{}{
+			"key":    key,
+			"values": values,
+		})
+	}
+	return result
+}
+
+// labelOperations returns the add/remove operations which turn the old labels into the new ones; Service Manager
+// updates labels only through such operations.
+func labelOperations(oldLabels, newLabels map[string][]string) []btpmanagment.Label {
+	ops := make([]btpmanagment.Label, 0)
+
+	for _, key := range sortedLabelKeys(oldLabels) {
+		newValues, ok := newLabels[key]
+		if !ok {
+			ops = append(ops, btpmanagment.Label{Op: "remove", Key: key})
+			continue
+		}
+		if removed := labelValuesMissingIn(oldLabels[key], newValues); len(removed) > 0 {
+			ops = append(ops, btpmanagment.Label{Op: "remove", Key: key, Values: removed})
+		}
+	}
+	for _, key := range sortedLabelKeys(newLabels) {
+		if added := labelValuesMissingIn(newLabels[key], oldLabels[key]); len(added) > 0 {
+			ops = append(ops, btpmanagment.Label{Op: "add", Key: key, Values: added})
+		}
+	}
+	return ops
+}
+
+func labelValuesMissingIn(values, in []string) []string {
+	existing := make(map[string]bool, len(in))
+	for _, v := range in {
+		existing[v] = true
+	}
+
+	missing := make([]string, 0)
+	for _, v := range values {
+		if !existing[v] {
+			missing = append(missing, v)
+		}
+	}
+	return missing
+}
+
+func sortedLabelKeys(labels map[string][]string) []string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// labelsStateUpgraderV0 upgrades the state of a resource which held its labels as a map of value lists, up to
+// version 0, into the set of key/values blocks of labelsSchema.
+func labelsStateUpgraderV0(current *schema.Resource) schema.StateUpgrader {
+	v0 := &schema.Resource{Schema: make(map[string]*schema.Schema, len(current.Schema))}
+	for name, s := range current.Schema {
+		v0.Schema[name] = s
+	}
+	v0.Schema["labels"] = &schema.Schema{
+		Type:     schema.TypeMap,
+		Optional: true,
+		Elem:     &schema.Schema{Type: schema.TypeList},
+	}
+
+	return schema.StateUpgrader{
+		Version: 0,
+		Type:    v0.CoreConfigSchema().ImpliedType(),
+		Upgrade: labelsStateUpgradeV0,
+	}
+}
+
+func labelsStateUpgradeV0(ctx context.Context, rawState map[string]interface{},
+	meta interface{}) (map[string]interface{}, error) {
+
+	v0Labels, _ := rawState["labels"].(map[string]interface{})
+	keys := make([]string, 0, len(v0Labels))
+	for key := range v0Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	labels := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		values, _ := v0Labels[key].([]interface{})
+		if len(values) == 0 {
+			// A label needs at least one value
+			continue
+		}
+		labels = append(labels, map[string]interface{}{
+			"key":    key,
+			"values": values,
+		})
+	}
+	rawState["labels"] = labels
+	return rawState, nil
+}
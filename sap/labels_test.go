@@ -0,0 +1,64 @@
+package sap
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestLabelsStateUpgradeV0(t *testing.T) {
+	for name, r := range map[string]*schema.Resource{
+		"instances": resourceSapBtpSubAccountServiceManagementInstances(),
+		"platforms": resourceSapBtpSubAccountServiceManagementPlatforms(),
+		"bindings":  resourceSapBtpSubAccountServiceManagementBindings(),
+	} {
+		if r.SchemaVersion != 1 || len(r.StateUpgraders) != 1 || r.StateUpgraders[0].Version != 0 {
+			t.Fatalf("%s: expected an upgrader from version 0 to 1", name)
+		}
+
+		rawState := map[string]interface{}{
+			"id":   "object-1",
+			"name": "existing",
+			"labels": map[string]interface{}{
+				"team":  []interface{}{"core", "platform"},
+				"stage": []interface{}{"dev"},
+				"empty": []interface{}{},
+			},
+		}
+		got, err := r.StateUpgraders[0].Upgrade(context.Background(), rawState, nil)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if got["id"] != "object-1" || got["name"] != "existing" {
+			t.Errorf("%s: attributes other than labels changed: %v", name, got)
+		}
+
+		// The upgraded labels decode into the current schema
+		d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+			"labels": got["labels"],
+		})
+		labels := expandLabels(d.Get("labels"))
+		for key := range labels {
+			sort.Strings(labels[key])
+		}
+		expected := map[string][]string{
+			"stage": {"dev"},
+			"team":  {"core", "platform"},
+		}
+		if !reflect.DeepEqual(labels, expected) {
+			t.Errorf("%s: labels are %v, expected %v", name, labels, expected)
+		}
+	}
+}
+
+func TestLabelsStateUpgradeV0_noLabels(t *testing.T) {
+	got, err := labelsStateUpgradeV0(context.Background(), map[string]interface{}{"id": "object-1"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if labels, ok := got["labels"].([]interface{}); !ok || len(labels) != 0 {
+		t.Errorf("labels are %#v, expected none", got["labels"])
+	}
+}
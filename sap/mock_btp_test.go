@@ -0,0 +1,1715 @@
+package sap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nnicora/sap-sdk-go/sap"
+	"github.com/nnicora/sap-sdk-go/sap/session"
+	"github.com/nnicora/sap-sdk-go/service/btpmanagment"
+	"github.com/nnicora/sap-sdk-go/service/btpprovisioning"
+	"github.com/nnicora/sap-sdk-go/service/btpsaasmanager"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	// The sdk checks the OAuth2 token url resolves, port excluded; hence the fake is addressed through localhost,
+	// which mockBtpTransport redirects to the real listener.
+	mockBtpHost = "http://localhost"
+
+	mockBtpAccessToken     = "mock-access-token"
+	mockBtpGlobalAccountId = "mock-global-account"
+
+	// Polls an asynchronous operation answers with its transitional state, before settling
+	mockBtpPendingPolls = 1
+)
+
+// testAccProviderConfig configures the provider against the fake BTP APIs started by newMockBtp.
+const testAccProviderConfig = `
+provider "sap" {
+  oauth2 {
+    client_id     = "mock-client"
+    client_secret = "mock-secret"
+    token_url     = "http://localhost/oauth/token"
+  }
+
+  service_endpoint {
+    id   = "accounts"
+    host = "http://localhost"
+  }
+  service_endpoint {
+    id   = "entitlements"
+    host = "http://localhost"
+  }
+  service_endpoint {
+    id   = "provisioning"
+    host = "http://localhost"
+  }
+  service_endpoint {
+    id   = "service-manager"
+    host = "http://localhost"
+  }
+  service_endpoint {
+    id   = "saas-manager"
+    host = "http://localhost"
+  }
+  service_endpoint {
+    id   = "sub-account-authorization"
+    host = "http://mock-sub-account.authentication.localhost"
+  }
+  service_endpoint {
+    id   = "directory-authorization"
+    host = "http://mock-directory.authentication.localhost"
+  }
+}
+`
+
+type mockObject map[string]interface{}
+
+// mockProgress is an asynchronous operation still pending for a number of polls; once they are consumed it
+// settles, running its effect on the fake's state.
+type mockProgress struct {
+	polls    int
+	onSettle func()
+}
+
+// mockBtp is an in-memory fake of the BTP APIs the provider talks to: Accounts, Entitlements, Provisioning,
+// Service Manager, SaaS Manager, the SCIM API of XSUAA and the OAuth2 token endpoint. Asynchronous operations go through their
+// transitional states (CREATING, IN_PROGRESS, in progress, ...) before settling, like the real services do.
+type mockBtp struct {
+	server *httptest.Server
+
+	lock sync.Mutex
+	seq  int
+
+	subAccounts          map[string]mockObject
+	smBindings           map[string]mockObject
+	directories          map[string]mockObject
+	assignments          map[string]map[string]mockObject
+	directoryAssignments map[string]map[string]mockObject
+	planCategories       map[string]string
+	environments         map[string]mockObject
+	offerings            map[string]mockObject
+	plans                map[string]mockObject
+	instances            map[string]mockObject
+	bindings             map[string]mockObject
+	platforms            map[string]mockObject
+	subscriptions        map[string]mockObject
+	scimGroups           map[string]mockObject
+	scimUsers            map[string]mockObject
+
+	// Transitional states of objects, jobs and Service Manager operations, by id
+	transitions map[string]*mockProgress
+	jobs        map[string]*mockProgress
+	operations  map[string]*mockProgress
+}
+
+// newMockBtp starts the fake BTP APIs until the test ends; the provider reaches them through providerFactories.
+func newMockBtp(t *testing.T) *mockBtp {
+	m := &mockBtp{
+		subAccounts:          make(map[string]mockObject),
+		smBindings:           make(map[string]mockObject),
+		directories:          make(map[string]mockObject),
+		assignments:          make(map[string]map[string]mockObject),
+		directoryAssignments: make(map[string]map[string]mockObject),
+		planCategories:       make(map[string]string),
+		environments:         make(map[string]mockObject),
+		offerings:            make(map[string]mockObject),
+		plans:                make(map[string]mockObject),
+		instances:            make(map[string]mockObject),
+		bindings:             make(map[string]mockObject),
+		platforms:            make(map[string]mockObject),
+		subscriptions:        make(map[string]mockObject),
+		scimGroups:           make(map[string]mockObject),
+		scimUsers:            make(map[string]mockObject),
+		transitions:          make(map[string]*mockProgress),
+		jobs:                 make(map[string]*mockProgress),
+		operations:           make(map[string]*mockProgress),
+	}
+	m.addOffering("xsuaa", true, "application", "broker")
+	m.addOffering("destination", false, "lite")
+	m.addScimGroup("Subaccount Administrator")
+	m.addScimGroup("Directory Administrator")
+
+	m.server = httptest.NewServer(http.HandlerFunc(m.serveHTTP))
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+// providerFactories returns the provider sending its requests to the fake through mockBtpTransport, hence
+// acceptance tests need no BTP account; they still need TF_ACC set and a Terraform CLI, see README.md.
+func (m *mockBtp) providerFactories() map[string]func() (*schema.Provider, error) {
+	return map[string]func() (*schema.Provider, error){
+		"sap": func() (*schema.Provider, error) {
+			return m.provider(), nil
+		},
+	}
+}
+
+// provider returns the provider configured as usual, but for its clients, built by mockBtpClientFactory.
+func (m *mockBtp) provider() *schema.Provider {
+	provider := Provider()
+	provider.ConfigureContextFunc = func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+		meta, diags := providerConfigure(ctx, d, "mock")
+		if diags.HasError() {
+			return nil, diags
+		}
+		client := meta.(*SAPClient)
+		factory := newMockBtpClientFactory(client.factory.(*sessionClientFactory), &mockBtpTransport{btp: m})
+		return newSAPClient(factory, client.endpoints), nil
+	}
+	return provider
+}
+
+// mockBtpClientFactory builds the sdk clients as the provider does, but points their endpoints to the transport, in
+// place of the OAuth2 clients of the sdk, which always go through http.DefaultTransport.
+type mockBtpClientFactory struct {
+	*sessionClientFactory
+	transport http.RoundTripper
+
+	lock sync.Mutex
+	// The sessions already pointed to the transport
+	sessions map[*session.RuntimeSession]bool
+}
+
+func newMockBtpClientFactory(sessions *sessionClientFactory, transport http.RoundTripper) *mockBtpClientFactory {
+	f := &mockBtpClientFactory{
+		sessionClientFactory: sessions,
+		transport:            transport,
+		sessions:             make(map[*session.RuntimeSession]bool),
+	}
+	f.useTransport(sessions.session)
+	return f
+}
+
+func (f *mockBtpClientFactory) serviceManagement(cfg *sap.EndpointConfig) (serviceManagementClient, error) {
+	if err := f.useEndpointTransport(btpmanagment.EndpointsID, cfg); err != nil {
+		return nil, err
+	}
+	return f.sessionClientFactory.serviceManagement(cfg)
+}
+
+func (f *mockBtpClientFactory) provisioning(cfg *sap.EndpointConfig) (provisioningClient, error) {
+	if err := f.useEndpointTransport(btpprovisioning.EndpointsID, cfg); err != nil {
+		return nil, err
+	}
+	return f.sessionClientFactory.provisioning(cfg)
+}
+
+func (f *mockBtpClientFactory) saasManager(cfg *sap.EndpointConfig) (saasManagerClient, error) {
+	if err := f.useEndpointTransport(btpsaasmanager.EndpointsID, cfg); err != nil {
+		return nil, err
+	}
+	return f.sessionClientFactory.saasManager(cfg)
+}
+
+func (f *mockBtpClientFactory) authorization(cfg *sap.EndpointConfig) (authorizationClient, error) {
+	if err := f.useEndpointTransport(authorizationEndpointsId, cfg); err != nil {
+		return nil, err
+	}
+	return f.sessionClientFactory.authorization(cfg)
+}
+
+// useEndpointTransport points the cached session of the endpoint, which the client is built from next, to the
+// transport.
+func (f *mockBtpClientFactory) useEndpointTransport(endpointId string, cfg *sap.EndpointConfig) error {
+	sess, err := f.endpointSession(endpointId, cfg)
+	if err != nil {
+		return err
+	}
+	f.useTransport(sess)
+	return nil
+}
+
+func (f *mockBtpClientFactory) useTransport(sess *session.RuntimeSession) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.sessions[sess] {
+		return
+	}
+	for _, endpoint := range sess.RuntimeConfig.Endpoints {
+		endpoint.Client = &http.Client{Transport: f.transport}
+	}
+	f.sessions[sess] = true
+}
+
+// mockBtpTransport redirects the requests for mockBtpHost to the fake's listener. It stands for the OAuth2
+// clients of the sdk, hence, like them, it fetches an access token from the token endpoint and sends it along.
+type mockBtpTransport struct {
+	btp *mockBtp
+
+	tokenLock sync.Mutex
+	token     string
+}
+
+func (t *mockBtpTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	token, err := t.accessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	r = r.Clone(r.Context())
+	r.URL.Scheme = "http"
+	r.URL.Host = t.btp.server.Listener.Addr().String()
+	r.Host = ""
+	r.Header.Set("Authorization", "Bearer "+token)
+	return t.btp.server.Client().Transport.RoundTrip(r)
+}
+
+func (t *mockBtpTransport) accessToken() (string, error) {
+	t.tokenLock.Lock()
+	defer t.tokenLock.Unlock()
+
+	if t.token != "" {
+		return t.token, nil
+	}
+	response, err := t.btp.server.Client().PostForm(t.btp.server.URL+"/oauth/token",
+		url.Values{"grant_type": {"client_credentials"}})
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("mock access token can't be decoded; %v", err)
+	}
+	t.token = token.AccessToken
+	return t.token, nil
+}
+
+func (m *mockBtp) addOffering(name string, planUpdateable bool, planNames ...string) {
+	offeringId := "offering-" + name
+	m.offerings[offeringId] = mockObject{
+		"id":              offeringId,
+		"name":            name,
+		"catalog_name":    name,
+		"ready":           true,
+		"bindable":        true,
+		"plan_updateable": planUpdateable,
+	}
+	for _, planName := range planNames {
+		planId := "plan-" + name + "-" + planName
+		m.plans[planId] = mockObject{
+			"id":                  planId,
+			"name":                planName,
+			"catalog_name":        planName,
+			"ready":               true,
+			"bindable":            true,
+			"service_offering_id": offeringId,
+			"schemas": mockObject{
+				"service_instance": mockObject{
+					"create": mockObject{
+						"parameters": mockObject{"type": "object"},
+					},
+				},
+			},
+		}
+	}
+}
+
+// exists tells whether an object, as kept by the fake in the given collection, is still there.
+func (m *mockBtp) addScimGroup(displayName string) {
+	id := m.newId("group")
+	m.scimGroups[id] = mockObject{
+		"id":          id,
+		"displayName": displayName,
+		"members":     []interface{}{},
+	}
+}
+
+// addSubAccount adds a sub account, created outside of the provider, within the global account
+func (m *mockBtp) addSubAccount(id, subdomain string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.subAccounts[id] = mockObject{
+		"guid":              id,
+		"globalAccountGUID": mockBtpGlobalAccountId,
+		"parentGUID":        mockBtpGlobalAccountId,
+		"displayName":       id,
+		"subdomain":         subdomain,
+		"region":            "eu10",
+		"state":             "OK",
+	}
+}
+
+// addDirectory adds a directory, created outside of the provider, within the global account; one having a
+// subdomain has the AUTHORIZATIONS feature.
+func (m *mockBtp) addDirectory(id, subdomain string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	directory := mockObject{
+		"guid":              id,
+		"parentGuid":        mockBtpGlobalAccountId,
+		"displayName":       id,
+		"contractStatus":    "ACTIVE",
+		"entityState":       "OK",
+		"directoryFeatures": []interface{}{"DEFAULT"},
+	}
+	if subdomain != "" {
+		directory["subdomain"] = subdomain
+		directory["directoryFeatures"] = []interface{}{"DEFAULT", "ENTITLEMENTS", "AUTHORIZATIONS"}
+	}
+	m.directories[id] = directory
+}
+
+// addScimMember adds a user, created outside of the provider, to the named group
+func (m *mockBtp) addScimMember(displayName, userName, origin string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	userId := m.newId("user")
+	m.scimUsers[userId] = mockObject{"id": userId, "userName": userName, "origin": origin}
+	for _, group := range m.scimGroups {
+		if group["displayName"] == displayName {
+			group["members"] = append(group["members"].([]interface{}),
+				mockObject{"value": userId, "origin": origin, "type": "USER"})
+		}
+	}
+}
+
+// scimMembers returns the user names of the members of the named group
+func (m *mockBtp) scimMembers(displayName string) []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	userNames := make([]string, 0)
+	for _, group := range m.scimGroups {
+		if group["displayName"] != displayName {
+			continue
+		}
+		for _, member := range group["members"].([]interface{}) {
+			userId := mockString(member.(mockObject)["value"])
+			userNames = append(userNames, mockString(m.scimUsers[userId]["userName"]))
+		}
+	}
+	sort.Strings(userNames)
+	return userNames
+}
+
+func (m *mockBtp) exists(collection map[string]mockObject, id string) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	_, ok := collection[id]
+	return ok
+}
+
+// object returns a copy of an object of the fake, nil if there is none.
+func (m *mockBtp) object(collection map[string]mockObject, id string) mockObject {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	obj, ok := collection[id]
+	if !ok {
+		return nil
+	}
+	result := make(mockObject, len(obj))
+	for k, v := range obj {
+		result[k] = v
+	}
+	return result
+}
+
+// assignment returns the entitlement of a sub account to a service plan, nil if it isn't entitled.
+func (m *mockBtp) assignment(subAccountId, serviceName, planName string) mockObject {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.assignments[subAccountId][serviceName+"/"+planName]
+}
+
+// directoryAssignment returns the entitlement of a directory to a service plan, nil if it isn't entitled.
+func (m *mockBtp) directoryAssignment(directoryId, serviceName, planName string) mockObject {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.directoryAssignments[directoryId][serviceName+"/"+planName]
+}
+
+// revokeAssignments drops the entitlements of every sub account and directory to the plan, as if revoked outside
+// of Terraform.
+func (m *mockBtp) revokeAssignments(serviceName, planName string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, assignments := range m.assignments {
+		delete(assignments, serviceName+"/"+planName)
+	}
+	for _, assignments := range m.directoryAssignments {
+		delete(assignments, serviceName+"/"+planName)
+	}
+}
+
+// unsubscribe drops the subscription of the tenant, as if unsubscribed outside of Terraform.
+func (m *mockBtp) unsubscribe(tenantId string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.subscriptions, tenantId)
+}
+
+func (m *mockBtp) newId(kind string) string {
+	m.seq++
+	return fmt.Sprintf("%s-%04d", kind, m.seq)
+}
+
+// transition keeps the object with the given id in its transitional state for a few polls, then settles it.
+func (m *mockBtp) transition(id string, onSettle func()) {
+	m.transitions[id] = &mockProgress{polls: mockBtpPendingPolls, onSettle: onSettle}
+}
+
+// settle advances the transition of the object with the given id, if any, by one poll.
+func (m *mockBtp) settle(id string) {
+	if p, ok := m.transitions[id]; ok {
+		if p.polls > 0 {
+			p.polls--
+			return
+		}
+		delete(m.transitions, id)
+		p.onSettle()
+	}
+}
+
+func (m *mockBtp) newJob() string {
+	id := m.newId("job")
+	m.jobs[id] = &mockProgress{polls: mockBtpPendingPolls, onSettle: func() {}}
+	return id
+}
+
+func (m *mockBtp) newOperation(w http.ResponseWriter, resourceType, resourceId string) {
+	id := m.newId("operation")
+	m.operations[id] = &mockProgress{polls: mockBtpPendingPolls, onSettle: func() {}}
+	w.Header().Set("Location", fmt.Sprintf("/v1/%s/%s/operations/%s", resourceType, resourceId, id))
+}
+
+func mockNow() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
+
+func (m *mockBtp) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if r.URL.Path == "/oauth/token" {
+		m.serveToken(w, r)
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer "+mockBtpAccessToken {
+		writeMockError(w, http.StatusUnauthorized, "missing or invalid access token")
+		return
+	}
+
+	body := mockObject{}
+	if r.Body != nil && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && err.Error() != "EOF" {
+			writeMockError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	switch {
+	case len(path) >= 3 && path[0] == "accounts" && path[1] == "v1" && path[2] == "subaccounts":
+		m.serveSubAccounts(w, r, path[3:], body)
+	case len(path) >= 3 && path[0] == "accounts" && path[1] == "v1" && path[2] == "directories":
+		m.serveDirectories(w, r, path[3:], body)
+	case len(path) >= 3 && path[0] == "entitlements" && path[1] == "v1":
+		m.serveEntitlements(w, r, path[2:], body)
+	case len(path) >= 3 && path[0] == "provisioning" && path[1] == "v1" && path[2] == "environments":
+		m.serveEnvironments(w, r, path[3:], body)
+	case len(path) == 6 && path[0] == "saas-manager" && path[1] == "v1" && path[2] == "application" &&
+		path[3] == "tenants" && path[5] == "subscriptions":
+		m.serveSubscriptions(w, r, path[4])
+	case len(path) == 4 && path[0] == "saas-manager" && path[1] == "v1" && path[2] == "application" &&
+		path[3] == "subscriptions" && r.Method == http.MethodGet:
+		m.getSubscriptions(w, r.URL.Query().Get("tenantId"))
+	case len(path) == 5 && path[0] == "jobs-management" && path[2] == "jobs" && path[4] == "status":
+		m.serveJobStatus(w, path[3])
+	case len(path) >= 2 && path[0] == "v1":
+		m.serveServiceManagement(w, r, path[1:], body)
+	case path[0] == "Groups":
+		m.serveScimGroups(w, r, path[1:], body)
+	case len(path) == 1 && path[0] == "Users":
+		m.serveScimUsers(w, r, body)
+	default:
+		writeMockError(w, http.StatusNotFound, "no route for "+r.Method+" "+r.URL.Path)
+	}
+}
+
+func (m *mockBtp) serveToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMockError(w, http.StatusMethodNotAllowed, "token is issued only through POST")
+		return
+	}
+	writeMockJson(w, http.StatusOK, mockObject{
+		"access_token": mockBtpAccessToken,
+		"token_type":   "bearer",
+		"expires_in":   3600,
+	})
+}
+
+func (m *mockBtp) serveJobStatus(w http.ResponseWriter, id string) {
+	job, ok := m.jobs[id]
+	if !ok {
+		writeMockError(w, http.StatusNotFound, "job "+id+" not found")
+		return
+	}
+	if job.polls > 0 {
+		job.polls--
+		writeMockJson(w, http.StatusOK, mockObject{"status": "IN_PROGRESS", "description": "job " + id})
+		return
+	}
+	writeMockJson(w, http.StatusOK, mockObject{"status": "COMPLETED", "description": "job " + id})
+}
+
+// Accounts
+
+func (m *mockBtp) serveSubAccounts(w http.ResponseWriter, r *http.Request, path []string, body mockObject) {
+	if len(path) == 0 && r.Method == http.MethodGet {
+		directoryId := r.URL.Query().Get("directoryGUID")
+		subAccounts := make([]mockObject, 0, len(m.subAccounts))
+		for id := range m.subAccounts {
+			m.settle(id)
+		}
+		for _, subAccount := range m.subAccounts {
+			if directoryId == "" || subAccount["parentGUID"] == directoryId {
+				subAccounts = append(subAccounts, subAccount)
+			}
+		}
+		writeMockJson(w, http.StatusOK, mockObject{"value": subAccounts})
+		return
+	}
+	if len(path) == 0 {
+		if r.Method != http.MethodPost {
+			writeMockError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+			return
+		}
+		parentId := mockString(body["parentGUID"])
+		if parentId == "" {
+			parentId = mockBtpGlobalAccountId
+		}
+		if !m.isParent(parentId) {
+			writeMockError(w, http.StatusBadRequest, "parent "+parentId+" not found")
+			return
+		}
+		id := m.newId("subaccount")
+		subAccount := mockObject{
+			"guid":              id,
+			"globalAccountGUID": mockBtpGlobalAccountId,
+			"parentGUID":        parentId,
+			"displayName":       body["displayName"],
+			"description":       body["description"],
+			"subdomain":         body["subdomain"],
+			"region":            body["region"],
+			"usedForProduction": body["usedForProduction"],
+			"betaEnabled":       body["betaEnabled"],
+			"customProperties":  mockCustomProperties(id, body["customProperties"]),
+			"createdBy":         "mock",
+			"createdDate":       mockNow(),
+			"modifiedDate":      mockNow(),
+			"state":             "CREATING",
+		}
+		m.subAccounts[id] = subAccount
+		m.transition(id, func() { subAccount["state"] = "OK" })
+		writeMockJson(w, http.StatusCreated, subAccount)
+		return
+	}
+
+	id := path[0]
+	m.settle(id)
+	subAccount, ok := m.subAccounts[id]
+	if !ok {
+		writeMockError(w, http.StatusNotFound, "sub account "+id+" not found")
+		return
+	}
+	if len(path) == 2 && path[1] == "serviceManagementBinding" {
+		m.serveServiceManagementBinding(w, r, id)
+		return
+	}
+	if len(path) == 2 && path[1] == "move" {
+		if r.Method != http.MethodPost {
+			writeMockError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+			return
+		}
+		targetId := mockString(body["targetAccountGUID"])
+		if !m.isParent(targetId) {
+			writeMockError(w, http.StatusBadRequest, "target "+targetId+" not found")
+			return
+		}
+		subAccount["parentGUID"] = targetId
+		subAccount["state"] = "MOVING"
+		m.transition(id, func() { subAccount["state"] = "OK" })
+		writeMockJson(w, http.StatusOK, subAccount)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeMockJson(w, http.StatusOK, subAccount)
+	case http.MethodPatch:
+		for _, key := range []string{"displayName", "description", "usedForProduction", "betaEnabled"} {
+			if val, ok := body[key]; ok {
+				subAccount[key] = val
+			}
+		}
+		if val, ok := body["customProperties"]; ok {
+			subAccount["customProperties"] = mockUpdateCustomProperties(id, subAccount["customProperties"], val)
+		}
+		subAccount["modifiedDate"] = mockNow()
+		subAccount["state"] = "UPDATING"
+		m.transition(id, func() { subAccount["state"] = "OK" })
+		writeMockJson(w, http.StatusOK, subAccount)
+	case http.MethodDelete:
+		subAccount["state"] = "DELETING"
+		m.transition(id, func() {
+			delete(m.subAccounts, id)
+			delete(m.smBindings, id)
+			delete(m.assignments, id)
+		})
+		writeMockJson(w, http.StatusOK, subAccount)
+	default:
+		writeMockError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+	}
+}
+
+// isParent tells whether accounts can be created in or moved to the given id, the global account or a directory
+func (m *mockBtp) isParent(id string) bool {
+	_, isDirectory := m.directories[id]
+	return id == mockBtpGlobalAccountId || isDirectory
+}
+
+func (m *mockBtp) serveServiceManagementBinding(w http.ResponseWriter, r *http.Request, subAccountId string) {
+	switch r.Method {
+	case http.MethodPost:
+		if _, ok := m.smBindings[subAccountId]; ok {
+			writeMockError(w, http.StatusConflict, "sub account "+subAccountId+" has already a binding")
+			return
+		}
+		binding := mockObject{
+			"clientid":     "sm-client-" + subAccountId,
+			"clientsecret": "sm-secret-" + subAccountId,
+			"sm_url":       mockBtpHost,
+			"url":          mockBtpHost,
+			"xsappname":    "sm-app-" + subAccountId,
+		}
+		m.smBindings[subAccountId] = binding
+		writeMockJson(w, http.StatusCreated, binding)
+	case http.MethodGet:
+		binding, ok := m.smBindings[subAccountId]
+		if !ok {
+			writeMockError(w, http.StatusNotFound, "sub account "+subAccountId+" has no binding")
+			return
+		}
+		writeMockJson(w, http.StatusOK, binding)
+	case http.MethodDelete:
+		if _, ok := m.smBindings[subAccountId]; !ok {
+			writeMockError(w, http.StatusNotFound, "sub account "+subAccountId+" has no binding")
+			return
+		}
+		delete(m.smBindings, subAccountId)
+		writeMockJson(w, http.StatusOK, mockObject{})
+	default:
+		writeMockError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+	}
+}
+
+func (m *mockBtp) serveDirectories(w http.ResponseWriter, r *http.Request, path []string, body mockObject) {
+	if len(path) == 0 {
+		if r.Method != http.MethodPost {
+			writeMockError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+			return
+		}
+		parentId := r.URL.Query().Get("parentId")
+		if parentId == "" {
+			parentId = mockBtpGlobalAccountId
+		}
+		if !m.isParent(parentId) {
+			writeMockError(w, http.StatusBadRequest, "parent "+parentId+" not found")
+			return
+		}
+		id := m.newId("directory")
+		directory := mockObject{
+			"guid":             id,
+			"parentGuid":       parentId,
+			"displayName":      body["displayName"],
+			"description":      body["description"],
+			"subdomain":        body["subdomain"],
+			"customProperties": mockCustomProperties(id, body["customProperties"]),
+			"contractStatus":   "ACTIVE",
+			"entityState":      "OK",
+			"createdBy":        "mock",
+			"createdDate":      mockNow(),
+			"modifiedDate":     mockNow(),
+		}
+		directory["directoryFeatures"] = mockDirectoryFeatures(body["directoryFeatures"])
+		if admins, ok := body["directoryAdmins"]; ok {
+			directory["directoryAdmins"] = admins
+		}
+		m.directories[id] = directory
+		writeMockJson(w, http.StatusCreated, directory)
+		return
+	}
+
+	id := path[0]
+	directory, ok := m.directories[id]
+	if !ok {
+		writeMockError(w, http.StatusNotFound, "directory "+id+" not found")
+		return
+	}
+	if len(path) == 2 && path[1] == "changeDirectoryFeatures" {
+		if r.Method != http.MethodPatch {
+			writeMockError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+			return
+		}
+		directory["directoryFeatures"] = mockDirectoryFeatures(body["directoryFeatures"])
+		if admins, ok := body["directoryAdmins"]; ok {
+			directory["directoryAdmins"] = admins
+		}
+		writeMockJson(w, http.StatusOK, directory)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeMockJson(w, http.StatusOK, directory)
+	case http.MethodPatch:
+		for _, key := range []string{"displayName", "description"} {
+			if val, ok := body[key]; ok {
+				directory[key] = val
+			}
+		}
+		if val, ok := body["customProperties"]; ok {
+			directory["customProperties"] = mockUpdateCustomProperties(id, directory["customProperties"], val)
+		}
+		directory["modifiedDate"] = mockNow()
+		writeMockJson(w, http.StatusOK, directory)
+	case http.MethodDelete:
+		delete(m.directories, id)
+		delete(m.directoryAssignments, id)
+		writeMockJson(w, http.StatusOK, directory)
+	default:
+		writeMockError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+	}
+}
+
+func mockCustomProperties(accountId string, data interface{}) []interface{} {
+	result := make([]interface{}, 0)
+	items, _ := data.([]interface{})
+	for _, item := range items {
+		property, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		result = append(result, mockObject{
+			"accountGUID": accountId,
+			"key":         property["key"],
+			"value":       property["value"],
+		})
+	}
+	return result
+}
+
+func mockUpdateCustomProperties(accountId string, current, changes interface{}) []interface{} {
+	values := make(map[string]interface{})
+	keys := make([]string, 0)
+	for _, item := range mockCustomProperties(accountId, current) {
+		property := item.(mockObject)
+		key := mockString(property["key"])
+		values[key] = property["value"]
+		keys = append(keys, key)
+	}
+
+	items, _ := changes.([]interface{})
+	for _, item := range items {
+		property, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		key := mockString(property["key"])
+		if deleted, _ := property["delete"].(bool); deleted {
+			delete(values, key)
+			continue
+		}
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+		values[key] = property["value"]
+	}
+
+	result := make([]interface{}, 0, len(values))
+	for _, key := range keys {
+		if value, ok := values[key]; ok {
+			result = append(result, mockObject{"accountGUID": accountId, "key": key, "value": value})
+			delete(values, key)
+		}
+	}
+	return result
+}
+
+// Entitlements
+
+func (m *mockBtp) serveEntitlements(w http.ResponseWriter, r *http.Request, path []string, body mockObject) {
+	switch {
+	case len(path) == 1 && path[0] == "subaccountServicePlans" && r.Method == http.MethodPut:
+		m.updateSubAccountServicePlans(w, body)
+	case len(path) == 1 && path[0] == "assignments" && r.Method == http.MethodGet:
+		if directoryId := r.URL.Query().Get("directoryGUID"); directoryId != "" {
+			m.getDirectoryAssignments(w, directoryId)
+		} else {
+			m.getAssignments(w, r.URL.Query().Get("subaccountGUID"))
+		}
+	case len(path) == 3 && path[0] == "directories" && path[2] == "assignments" && r.Method == http.MethodPut:
+		m.updateDirectoryAssignments(w, path[1], body)
+	default:
+		writeMockError(w, http.StatusNotFound, "no route for "+r.Method+" "+r.URL.Path)
+	}
+}
+
+func (m *mockBtp) updateSubAccountServicePlans(w http.ResponseWriter, body mockObject) {
+	plans, _ := body["subaccountServicePlans"].([]interface{})
+	for _, p := range plans {
+		plan, _ := p.(map[string]interface{})
+		infos, _ := plan["assignmentInfo"].([]interface{})
+		for _, i := range infos {
+			info, _ := i.(map[string]interface{})
+			subAccountId := mockString(info["subaccountGUID"])
+			if _, ok := m.subAccounts[subAccountId]; !ok {
+				writeMockError(w, http.StatusBadRequest, "sub account "+subAccountId+" not found")
+				return
+			}
+		}
+	}
+
+	for _, p := range plans {
+		plan := p.(map[string]interface{})
+		key := mockString(plan["serviceName"]) + "/" + mockString(plan["servicePlanName"])
+		infos, _ := plan["assignmentInfo"].([]interface{})
+		for _, i := range infos {
+			info := i.(map[string]interface{})
+			subAccountId := mockString(info["subaccountGUID"])
+			if m.assignments[subAccountId] == nil {
+				m.assignments[subAccountId] = make(map[string]mockObject)
+			}
+
+			enable, hasEnable := info["enable"].(bool)
+			amount, _ := info["amount"].(float64)
+			switch {
+			case hasEnable && enable:
+				m.assignments[subAccountId][key] = mockObject{"unlimited": true}
+			case amount > 0:
+				m.assignments[subAccountId][key] = mockObject{"amount": amount}
+			default:
+				delete(m.assignments[subAccountId], key)
+			}
+		}
+	}
+	writeMockJobId(w, m.newJob())
+}
+
+func (m *mockBtp) getAssignments(w http.ResponseWriter, subAccountId string) {
+	if _, ok := m.subAccounts[subAccountId]; !ok {
+		writeMockError(w, http.StatusNotFound, "sub account "+subAccountId+" not found")
+		return
+	}
+
+	keys := make([]string, 0)
+	for key := range m.assignments[subAccountId] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	services := make([]interface{}, 0)
+	byName := make(map[string]mockObject)
+	for _, key := range keys {
+		names := strings.SplitN(key, "/", 2)
+		assignment := m.assignments[subAccountId][key]
+
+		service, ok := byName[names[0]]
+		if !ok {
+			service = mockObject{"name": names[0], "servicePlans": make([]interface{}, 0)}
+			byName[names[0]] = service
+			services = append(services, service)
+		}
+		info := mockObject{
+			"entityId":                subAccountId,
+			"entityType":              "SUBACCOUNT",
+			"entityState":             "OK",
+			"unlimitedAmountAssigned": assignment["unlimited"] == true,
+		}
+		if amount, ok := assignment["amount"]; ok {
+			info["amount"] = amount
+		}
+		service["servicePlans"] = append(service["servicePlans"].([]interface{}), mockObject{
+			"name":           names[1],
+			"category":       m.planCategory(key),
+			"unlimited":      assignment["unlimited"] == true,
+			"assignmentInfo": []interface{}{info},
+		})
+	}
+	writeMockJson(w, http.StatusOK, mockObject{"assignedServices": services})
+}
+
+// planCategory returns the category of the '<service>/<plan>', SERVICE unless the test says otherwise.
+func (m *mockBtp) planCategory(key string) string {
+	if category, ok := m.planCategories[key]; ok {
+		return category
+	}
+	return "SERVICE"
+}
+
+func (m *mockBtp) getDirectoryAssignments(w http.ResponseWriter, directoryId string) {
+	if _, ok := m.directories[directoryId]; !ok {
+		writeMockError(w, http.StatusNotFound, "directory "+directoryId+" not found")
+		return
+	}
+
+	keys := make([]string, 0)
+	for key := range m.directoryAssignments[directoryId] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	services := make([]interface{}, 0)
+	byName := make(map[string]mockObject)
+	for _, key := range keys {
+		names := strings.SplitN(key, "/", 2)
+		assignment := m.directoryAssignments[directoryId][key]
+
+		service, ok := byName[names[0]]
+		if !ok {
+			service = mockObject{"name": names[0], "servicePlans": make([]interface{}, 0)}
+			byName[names[0]] = service
+			services = append(services, service)
+		}
+		info := mockObject{
+			"entityId":                directoryId,
+			"entityType":              "DIRECTORY",
+			"entityState":             "OK",
+			"autoAssign":              true,
+			"unlimitedAmountAssigned": assignment["unlimited"] == true,
+		}
+		if amount, ok := assignment["amount"]; ok {
+			info["amount"] = amount
+		}
+		if amount, ok := assignment["autoDistributeAmount"].(float64); ok {
+			info["autoDistributeAmount"] = amount
+		}
+		service["servicePlans"] = append(service["servicePlans"].([]interface{}), mockObject{
+			"name":           names[1],
+			"category":       m.planCategory(key),
+			"assignmentInfo": []interface{}{info},
+		})
+	}
+	writeMockJson(w, http.StatusOK, mockObject{"assignedServices": services})
+}
+
+func (m *mockBtp) updateDirectoryAssignments(w http.ResponseWriter, directoryId string, body mockObject) {
+	if _, ok := m.directories[directoryId]; !ok {
+		writeMockError(w, http.StatusNotFound, "directory "+directoryId+" not found")
+		return
+	}
+	if m.directoryAssignments[directoryId] == nil {
+		m.directoryAssignments[directoryId] = make(map[string]mockObject)
+	}
+
+	entitlements, _ := body["entitlements"].([]interface{})
+	for _, e := range entitlements {
+		entitlement, _ := e.(map[string]interface{})
+		key := mockString(entitlement["service"]) + "/" + mockString(entitlement["plan"])
+
+		enable, hasEnable := entitlement["enable"].(bool)
+		amount, _ := entitlement["amount"].(float64)
+		switch {
+		case hasEnable && enable:
+			m.directoryAssignments[directoryId][key] = mockObject{"unlimited": true, "distribute": entitlement["distribute"]}
+		case amount > 0:
+			m.directoryAssignments[directoryId][key] = mockObject{
+				"amount":               amount,
+				"distribute":           entitlement["distribute"],
+				"autoDistributeAmount": entitlement["autoDistributeAmount"],
+			}
+		default:
+			delete(m.directoryAssignments[directoryId], key)
+		}
+	}
+	writeMockJson(w, http.StatusOK, mockObject{})
+}
+
+// mockDirectoryFeatures returns the requested features along with DEFAULT, which BTP gives every directory.
+func mockDirectoryFeatures(requested interface{}) []interface{} {
+	features, _ := requested.([]interface{})
+	for _, feature := range features {
+		if feature == "DEFAULT" {
+			return features
+		}
+	}
+	return append([]interface{}{"DEFAULT"}, features...)
+}
+
+// Provisioning
+
+func (m *mockBtp) serveEnvironments(w http.ResponseWriter, r *http.Request, path []string, body mockObject) {
+	if len(path) == 0 {
+		if r.Method != http.MethodPost {
+			writeMockError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+			return
+		}
+		id := m.newId("environment")
+		parameters, _ := json.Marshal(body["parameters"])
+		environment := mockObject{
+			"id":                id,
+			"environmentType":   body["environmentType"],
+			"planName":          body["planName"],
+			"description":       body["description"],
+			"landscapeLabel":    body["landscapeLabel"],
+			"name":              body["name"],
+			"serviceName":       body["serviceName"],
+			"parameters":        string(parameters),
+			"brokerId":          "mock-broker",
+			"commercialType":    body["planName"],
+			"globalAccountGUID": mockBtpGlobalAccountId,
+			"planId":            "plan-" + mockString(body["planName"]),
+			"platformId":        "mock-platform",
+			"serviceId":         "service-" + mockString(body["serviceName"]),
+			"tenantId":          "tenant-" + id,
+			"operation":         "provision",
+			"type":              "Provision",
+			"createdDate":       mockNow(),
+			"modifiedDate":      mockNow(),
+			"state":             "CREATING",
+		}
+		m.environments[id] = environment
+		m.transition(id, func() { environment["state"] = "OK" })
+		writeMockJson(w, http.StatusAccepted, mockObject{"id": id})
+		return
+	}
+
+	id := path[0]
+	m.settle(id)
+	environment, ok := m.environments[id]
+	if !ok {
+		writeMockError(w, http.StatusNotFound, "environment instance "+id+" not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeMockJson(w, http.StatusOK, environment)
+	case http.MethodPatch:
+		if val, ok := body["planName"]; ok {
+			environment["planName"] = val
+		}
+		if val, ok := body["parameters"]; ok {
+			parameters, _ := json.Marshal(val)
+			environment["parameters"] = string(parameters)
+		}
+		environment["operation"] = "update"
+		environment["modifiedDate"] = mockNow()
+		environment["state"] = "UPDATING"
+		m.transition(id, func() { environment["state"] = "OK" })
+		writeMockJson(w, http.StatusAccepted, environment)
+	case http.MethodDelete:
+		environment["operation"] = "deprovision"
+		environment["state"] = "DELETING"
+		m.transition(id, func() { delete(m.environments, id) })
+		writeMockJson(w, http.StatusAccepted, environment)
+	default:
+		writeMockError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+	}
+}
+
+// SaaS Manager
+
+func (m *mockBtp) serveSubscriptions(w http.ResponseWriter, r *http.Request, tenantId string) {
+	switch r.Method {
+	case http.MethodPost:
+		m.subscriptions[tenantId] = mockObject{"tenantId": tenantId}
+	case http.MethodPatch, http.MethodDelete:
+		if _, ok := m.subscriptions[tenantId]; !ok {
+			writeMockSaasError(w, http.StatusNotFound, "tenant "+tenantId+" isn't subscribed")
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(m.subscriptions, tenantId)
+		}
+	default:
+		writeMockSaasError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+		return
+	}
+
+	jobId := m.newJob()
+	w.Header().Set("Location", "/api/v2.0/jobs/"+jobId)
+	writeMockJobId(w, jobId)
+}
+
+func (m *mockBtp) getSubscriptions(w http.ResponseWriter, tenantId string) {
+	tenantIds := make([]string, 0)
+	for id := range m.subscriptions {
+		if tenantId == "" || id == tenantId {
+			tenantIds = append(tenantIds, id)
+		}
+	}
+	sort.Strings(tenantIds)
+
+	values := make([]interface{}, 0, len(tenantIds))
+	for _, id := range tenantIds {
+		values = append(values, mockObject{"consumerTenantId": id, "state": "SUBSCRIBED"})
+	}
+	writeMockJson(w, http.StatusOK, mockObject{"values": values})
+}
+
+// Service Manager
+
+var mockFieldQuery = regexp.MustCompile(`(\w+) eq '([^']*)'`)
+
+func (m *mockBtp) serveServiceManagement(w http.ResponseWriter, r *http.Request, path []string, body mockObject) {
+	async := r.URL.Query().Get("async") == "true"
+
+	switch {
+	case len(path) == 4 && path[2] == "operations":
+		m.getOperation(w, path[0], path[1], path[3])
+	case path[0] == "service_offerings" && len(path) == 2 && r.Method == http.MethodGet:
+		writeMockItem(w, m.offerings, path[1], "service offering")
+	case path[0] == "service_plans" && len(path) == 2 && r.Method == http.MethodGet:
+		writeMockItem(w, m.plans, path[1], "service plan")
+	case path[0] == "service_offerings" && len(path) == 1 && r.Method == http.MethodGet:
+		writeMockItems(w, m.offerings, r.URL.Query().Get("fieldQuery"), r.URL.Query().Get("labelQuery"))
+	case path[0] == "service_plans" && len(path) == 1 && r.Method == http.MethodGet:
+		writeMockItems(w, m.plans, r.URL.Query().Get("fieldQuery"), r.URL.Query().Get("labelQuery"))
+	case path[0] == "service_instances":
+		m.serveServiceInstances(w, r, path[1:], body, async)
+	case path[0] == "service_bindings":
+		m.serveServiceBindings(w, r, path[1:], body, async)
+	case path[0] == "platforms":
+		m.servePlatforms(w, r, path[1:], body)
+	default:
+		writeMockSmError(w, http.StatusNotFound, "no route for "+r.Method+" "+r.URL.Path)
+	}
+}
+
+func (m *mockBtp) getOperation(w http.ResponseWriter, resourceType, resourceId, id string) {
+	operation, ok := m.operations[id]
+	if !ok {
+		writeMockSmError(w, http.StatusNotFound, "operation "+id+" not found")
+		return
+	}
+	state := "succeeded"
+	if operation.polls > 0 {
+		operation.polls--
+		state = "in progress"
+	}
+	writeMockJson(w, http.StatusOK, mockObject{
+		"id":            id,
+		"state":         state,
+		"resource_id":   resourceId,
+		"resource_type": "/v1/" + resourceType,
+	})
+}
+
+// writeMockItems answers the objects of the collection matching the field and label queries, in the order of
+// their ids
+func writeMockItems(w http.ResponseWriter, collection map[string]mockObject, fieldQuery, labelQuery string) {
+	criteria := make(map[string]string)
+	for _, match := range mockFieldQuery.FindAllStringSubmatch(fieldQuery, -1) {
+		criteria[match[1]] = match[2]
+	}
+	labelCriteria := make(map[string]string)
+	for _, match := range mockFieldQuery.FindAllStringSubmatch(labelQuery, -1) {
+		labelCriteria[match[1]] = match[2]
+	}
+
+	ids := make([]string, 0, len(collection))
+	for id := range collection {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	items := make([]interface{}, 0)
+	for _, id := range ids {
+		matches := true
+		for field, value := range criteria {
+			if mockString(collection[id][field]) != value {
+				matches = false
+			}
+		}
+		for key, value := range labelCriteria {
+			if !mockHasLabel(collection[id]["labels"], key, value) {
+				matches = false
+			}
+		}
+		if matches {
+			items = append(items, collection[id])
+		}
+	}
+	writeMockJson(w, http.StatusOK, mockObject{"num_items": len(items), "items": items})
+}
+
+func mockHasLabel(labels interface{}, key, value string) bool {
+	values, _ := labels.(map[string]interface{})[key].([]interface{})
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// planOf resolves the plan of an instance, either by its id or by the names of the offering and the plan.
+func (m *mockBtp) planOf(body mockObject) mockObject {
+	if planId := mockString(body["service_plan_id"]); planId != "" {
+		return m.plans[planId]
+	}
+	offeringId := "offering-" + mockString(body["service_offering_name"])
+	for _, plan := range m.plans {
+		if plan["service_offering_id"] == offeringId && plan["name"] == body["service_plan_name"] {
+			return plan
+		}
+	}
+	return nil
+}
+
+func (m *mockBtp) serveServiceInstances(w http.ResponseWriter, r *http.Request, path []string, body mockObject,
+	async bool) {
+
+	if len(path) == 0 {
+		if r.Method == http.MethodGet {
+			writeMockItems(w, m.instances, r.URL.Query().Get("fieldQuery"), r.URL.Query().Get("labelQuery"))
+			return
+		}
+		if r.Method != http.MethodPost {
+			writeMockSmError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+			return
+		}
+		plan := m.planOf(body)
+		if plan == nil {
+			writeMockSmError(w, http.StatusBadRequest, "service plan not found")
+			return
+		}
+		id := m.newId("instance")
+		instance := mockObject{
+			"id":               id,
+			"name":             body["name"],
+			"service_plan_id":  plan["id"],
+			"platform_id":      "service-manager",
+			"dashboard_url":    mockBtpHost + "/dashboard/" + id,
+			"context":          mockObject{"platform": "sapcp", "instance_name": body["name"]},
+			"maintenance_info": mockObject{"version": "1"},
+			"parameters":       body["parameters"],
+			"labels":           body["labels"],
+			"ready":            true,
+			"usable":           true,
+			"created_at":       time.Now().Format(time.RFC3339),
+		}
+		m.instances[id] = instance
+		if async {
+			m.newOperation(w, "service_instances", id)
+			writeMockJson(w, http.StatusAccepted, mockObject{})
+			return
+		}
+		writeMockJson(w, http.StatusCreated, instance)
+		return
+	}
+
+	id := path[0]
+	instance, ok := m.instances[id]
+	if !ok {
+		writeMockSmError(w, http.StatusNotFound, "service instance "+id+" not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeMockJson(w, http.StatusOK, instance)
+	case http.MethodPatch:
+		if planId := mockString(body["service_plan_id"]); planId != "" && planId != instance["service_plan_id"] {
+			plan, ok := m.plans[planId]
+			current := m.plans[mockString(instance["service_plan_id"])]
+			if !ok || plan["service_offering_id"] != current["service_offering_id"] {
+				writeMockSmError(w, http.StatusBadRequest, "service plan "+planId+" not found")
+				return
+			}
+			if m.offerings[mockString(current["service_offering_id"])]["plan_updateable"] != true {
+				writeMockSmError(w, http.StatusUnprocessableEntity, "service plan of the instance can't be changed")
+				return
+			}
+			instance["service_plan_id"] = planId
+		}
+		if name := mockString(body["name"]); name != "" {
+			instance["name"] = name
+		}
+		if parameters, ok := body["parameters"]; ok {
+			instance["parameters"] = parameters
+		}
+		if labels, ok := body["labels"].([]interface{}); ok {
+			instance["labels"] = mockApplyLabels(instance["labels"], labels)
+		}
+		if async {
+			m.newOperation(w, "service_instances", id)
+			writeMockJson(w, http.StatusAccepted, mockObject{})
+			return
+		}
+		writeMockJson(w, http.StatusOK, instance)
+	case http.MethodDelete:
+		delete(m.instances, id)
+		if async {
+			m.newOperation(w, "service_instances", id)
+			writeMockJson(w, http.StatusAccepted, mockObject{})
+			return
+		}
+		writeMockJson(w, http.StatusOK, mockObject{})
+	default:
+		writeMockSmError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+	}
+}
+
+func (m *mockBtp) serveServiceBindings(w http.ResponseWriter, r *http.Request, path []string, body mockObject,
+	async bool) {
+
+	if len(path) == 0 {
+		if r.Method == http.MethodGet {
+			writeMockItems(w, m.bindings, r.URL.Query().Get("fieldQuery"), r.URL.Query().Get("labelQuery"))
+			return
+		}
+		if r.Method != http.MethodPost {
+			writeMockSmError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+			return
+		}
+		instanceId := mockString(body["service_instance_id"])
+		if _, ok := m.instances[instanceId]; !ok {
+			writeMockSmError(w, http.StatusBadRequest, "service instance "+instanceId+" not found")
+			return
+		}
+		id := m.newId("binding")
+		binding := mockObject{
+			"id":                  id,
+			"name":                body["name"],
+			"service_instance_id": instanceId,
+			"bind_resource":       body["bind_resource"],
+			"labels":              body["labels"],
+			"context":             mockObject{"platform": "sapcp", "instance_name": m.instances[instanceId]["name"]},
+			"credentials": mockObject{
+				"clientid":     "binding-client-" + id,
+				"clientsecret": "binding-secret-" + id,
+				"url":          mockBtpHost,
+				"port":         30015,
+				"uaa":          mockObject{"tenantid": "tenant-" + id},
+			},
+			"ready":      true,
+			"created_at": time.Now().Format(time.RFC3339),
+		}
+		m.bindings[id] = binding
+		if async {
+			m.newOperation(w, "service_bindings", id)
+			writeMockJson(w, http.StatusAccepted, mockObject{})
+			return
+		}
+		writeMockJson(w, http.StatusCreated, binding)
+		return
+	}
+
+	id := path[0]
+	binding, ok := m.bindings[id]
+	if !ok {
+		writeMockSmError(w, http.StatusNotFound, "service binding "+id+" not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeMockJson(w, http.StatusOK, binding)
+	case http.MethodDelete:
+		delete(m.bindings, id)
+		if async {
+			m.newOperation(w, "service_bindings", id)
+			writeMockJson(w, http.StatusAccepted, mockObject{})
+			return
+		}
+		writeMockJson(w, http.StatusOK, mockObject{})
+	default:
+		writeMockSmError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+	}
+}
+
+func (m *mockBtp) servePlatforms(w http.ResponseWriter, r *http.Request, path []string, body mockObject) {
+	if len(path) == 0 {
+		if r.Method != http.MethodPost {
+			writeMockSmError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+			return
+		}
+		id := m.newId("platform")
+		platform := mockObject{
+			"id":          id,
+			"name":        body["name"],
+			"type":        body["type"],
+			"description": body["description"],
+			"labels":      body["labels"],
+			"ready":       true,
+			"created_at":  time.Now().Format(time.RFC3339),
+		}
+		m.platforms[id] = platform
+
+		created := make(mockObject, len(platform)+1)
+		for k, v := range platform {
+			created[k] = v
+		}
+		created["credentials"] = mockObject{
+			"basic": mockObject{"username": "platform-user-" + id, "password": "platform-password-" + id},
+		}
+		writeMockJson(w, http.StatusCreated, created)
+		return
+	}
+
+	id := path[0]
+	platform, ok := m.platforms[id]
+	if !ok {
+		writeMockSmError(w, http.StatusNotFound, "platform "+id+" not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeMockJson(w, http.StatusOK, platform)
+	case http.MethodPatch:
+		for _, key := range []string{"name", "type", "description"} {
+			if val := mockString(body[key]); val != "" {
+				platform[key] = val
+			}
+		}
+		if labels, ok := body["labels"].([]interface{}); ok {
+			platform["labels"] = mockApplyLabels(platform["labels"], labels)
+		}
+
+		// The sdk takes 'ready' of an updated platform for a string, so it's left out
+		updated := make(mockObject, len(platform))
+		for k, v := range platform {
+			if k != "ready" {
+				updated[k] = v
+			}
+		}
+		writeMockJson(w, http.StatusOK, updated)
+	case http.MethodDelete:
+		delete(m.platforms, id)
+		writeMockJson(w, http.StatusOK, mockObject{})
+	default:
+		writeMockSmError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+	}
+}
+
+// mockApplyLabels runs Service Manager label operations over the current labels.
+func mockApplyLabels(current interface{}, operations []interface{}) map[string]interface{} {
+	labels := make(map[string][]string)
+	if m, ok := current.(map[string]interface{}); ok {
+		for key, values := range m {
+			for _, v := range values.([]interface{}) {
+				labels[key] = append(labels[key], mockString(v))
+			}
+		}
+	}
+
+	for _, o := range operations {
+		operation, _ := o.(map[string]interface{})
+		key := mockString(operation["key"])
+		values, _ := operation["values"].([]interface{})
+		switch operation["op"] {
+		case "add":
+			for _, v := range values {
+				labels[key] = appendUniqueString(labels[key], mockString(v))
+			}
+		case "remove":
+			if len(values) == 0 {
+				delete(labels, key)
+				continue
+			}
+			kept := make([]string, 0)
+			for _, existing := range labels[key] {
+				removed := false
+				for _, v := range values {
+					removed = removed || existing == mockString(v)
+				}
+				if !removed {
+					kept = append(kept, existing)
+				}
+			}
+			labels[key] = kept
+		}
+	}
+
+	result := make(map[string]interface{}, len(labels))
+	for key, values := range labels {
+		items := make([]interface{}, len(values))
+		for idx := range values {
+			items[idx] = values[idx]
+		}
+		result[key] = items
+	}
+	return result
+}
+
+// XSUAA SCIM
+
+// mockScimFilter matches objects against a SCIM filter made of 'attribute eq "value"' clauses, all joined either
+// by 'and' or by 'or'.
+func mockScimFilter(filter string) func(mockObject) bool {
+	clause := regexp.MustCompile(`(\w+) eq "([^"]*)"`)
+	anyOf := strings.Contains(filter, " or ")
+	clauses := clause.FindAllStringSubmatch(filter, -1)
+	return func(obj mockObject) bool {
+		for _, c := range clauses {
+			matches := mockString(obj[c[1]]) == c[2]
+			if anyOf && matches {
+				return true
+			}
+			if !anyOf && !matches {
+				return false
+			}
+		}
+		return !anyOf
+	}
+}
+
+func (m *mockBtp) serveScimGroups(w http.ResponseWriter, r *http.Request, path []string, body mockObject) {
+	if len(path) == 0 {
+		if r.Method != http.MethodGet {
+			writeMockError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+			return
+		}
+		matches := mockScimFilter(r.URL.Query().Get("filter"))
+		resources := make([]interface{}, 0)
+		for _, group := range m.scimGroups {
+			if matches(group) {
+				resources = append(resources, group)
+			}
+		}
+		writeMockJson(w, http.StatusOK, mockObject{"resources": resources, "totalResults": len(resources)})
+		return
+	}
+
+	group, ok := m.scimGroups[path[0]]
+	if !ok || len(path) < 2 || path[1] != "members" {
+		writeMockScimError(w, http.StatusNotFound, "scim_resource_not_found", "group "+path[0]+" not found")
+		return
+	}
+	members := group["members"].([]interface{})
+	switch {
+	case len(path) == 2 && r.Method == http.MethodPost:
+		userId := mockString(body["value"])
+		if _, ok := m.scimUsers[userId]; !ok {
+			writeMockScimError(w, http.StatusBadRequest, "invalid_scim_resource", "user "+userId+" not found")
+			return
+		}
+		for _, member := range members {
+			if mockString(member.(mockObject)["value"]) == userId {
+				writeMockScimError(w, http.StatusConflict, "member_already_exists", "user "+userId+" is a member")
+				return
+			}
+		}
+		member := mockObject{"value": userId, "origin": body["origin"], "type": body["type"]}
+		group["members"] = append(members, member)
+		writeMockJson(w, http.StatusCreated, member)
+	case len(path) == 3 && r.Method == http.MethodDelete:
+		for idx, member := range members {
+			if mockString(member.(mockObject)["value"]) == path[2] {
+				group["members"] = append(members[:idx:idx], members[idx+1:]...)
+				writeMockJson(w, http.StatusOK, member)
+				return
+			}
+		}
+		writeMockScimError(w, http.StatusNotFound, "scim_resource_not_found", "member "+path[2]+" not found")
+	default:
+		writeMockError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+	}
+}
+
+func (m *mockBtp) serveScimUsers(w http.ResponseWriter, r *http.Request, body mockObject) {
+	switch r.Method {
+	case http.MethodGet:
+		matches := mockScimFilter(r.URL.Query().Get("filter"))
+		resources := make([]interface{}, 0)
+		for _, user := range m.scimUsers {
+			if matches(user) {
+				resources = append(resources, user)
+			}
+		}
+		writeMockJson(w, http.StatusOK, mockObject{"resources": resources, "totalResults": len(resources)})
+	case http.MethodPost:
+		id := m.newId("user")
+		user := mockObject{"id": id, "userName": body["userName"], "origin": body["origin"], "emails": body["emails"]}
+		m.scimUsers[id] = user
+		writeMockJson(w, http.StatusCreated, user)
+	default:
+		writeMockError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+	}
+}
+
+func writeMockItem(w http.ResponseWriter, collection map[string]mockObject, id, kind string) {
+	item, ok := collection[id]
+	if !ok {
+		writeMockSmError(w, http.StatusNotFound, kind+" "+id+" not found")
+		return
+	}
+	writeMockJson(w, http.StatusOK, item)
+}
+
+func writeMockJson(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// Accepted jobs are answered with their bare id, which the sdk wraps itself
+func writeMockJobId(w http.ResponseWriter, id string) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = w.Write([]byte(id))
+}
+
+// Accounts, Entitlements and Provisioning answer errors the same way
+func writeMockError(w http.ResponseWriter, status int, message string) {
+	writeMockJson(w, status, mockObject{
+		"error": mockObject{
+			"code":    status,
+			"message": message,
+		},
+	})
+}
+
+func writeMockSmError(w http.ResponseWriter, status int, description string) {
+	writeMockJson(w, status, mockObject{
+		"error":       http.StatusText(status),
+		"description": description,
+	})
+}
+
+func writeMockScimError(w http.ResponseWriter, status int, err, description string) {
+	writeMockJson(w, status, mockObject{"error": err, "error_description": description})
+}
+
+func writeMockSaasError(w http.ResponseWriter, status int, description string) {
+	writeMockJson(w, status, mockObject{
+		"error":             http.StatusText(status),
+		"error_description": description,
+	})
+}
+
+func mockString(val interface{}) string {
+	if s, ok := val.(string); ok {
+		return s
+	}
+	return ""
+}
@@ -2,13 +2,10 @@ package sap
 
 import (
 	"context"
-	"encoding/json"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/nnicora/sap-sdk-go/sap"
 	"github.com/nnicora/sap-sdk-go/sap/session"
-	"github.com/nnicora/sap-sdk-go/service/btpaccounts"
-	"github.com/nnicora/sap-sdk-go/service/btpentitlements"
 	"log"
 )
 
@@ -21,31 +18,42 @@ func init() {
 func Provider() *schema.Provider {
 	provider := &schema.Provider{
 		Schema: map[string]*schema.Schema{
+			"credentials_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SAP_BTP_CREDENTIALS_FILE", nil),
+				Description: "Path to a SAP BTP service key JSON file; its 'uaa' section is used for the OAuth2 values not set otherwise.",
+			},
+
 			"oauth2": {
 				Type:     schema.TypeList,
-				Required: true,
+				Optional: true,
 				MaxItems: 1,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"grant_type": {
 							Type:        schema.TypeString,
 							Optional:    true,
-							Default:     "client_credentials",
+							DefaultFunc: schema.EnvDefaultFunc("SAP_BTP_GRANT_TYPE", "client_credentials"),
 							Description: "SAP OAuth2 Grant Type.",
 						},
 						"client_id": {
 							Type:        schema.TypeString,
-							Required:    true,
+							Optional:    true,
+							DefaultFunc: schema.EnvDefaultFunc("SAP_BTP_CLIENT_ID", nil),
 							Description: "SAP OAuth2 Client Id.",
 						},
 						"client_secret": {
 							Type:        schema.TypeString,
-							Required:    true,
+							Optional:    true,
+							Sensitive:   true,
+							DefaultFunc: schema.EnvDefaultFunc("SAP_BTP_CLIENT_SECRET", nil),
 							Description: "SAP OAuth2 Client Secret.",
 						},
 						"token_url": {
 							Type:        schema.TypeString,
-							Required:    true,
+							Optional:    true,
+							DefaultFunc: schema.EnvDefaultFunc("SAP_BTP_TOKEN_URL", nil),
 							Description: "SAP OAuth2 Token Url.",
 						},
 						"authorization_url": {
@@ -64,13 +72,14 @@ func Provider() *schema.Provider {
 						"username": {
 							Type:        schema.TypeString,
 							Optional:    true,
-							Default:     "",
+							DefaultFunc: schema.EnvDefaultFunc("SAP_BTP_USERNAME", ""),
 							Description: "SAP OAuth2 Username. Used in case if 'grant_type=password'.",
 						},
 						"password": {
 							Type:        schema.TypeString,
 							Optional:    true,
-							Default:     "",
+							Sensitive:   true,
+							DefaultFunc: schema.EnvDefaultFunc("SAP_BTP_PASSWORD", ""),
 							Description: "SAP OAuth2 Password. Used in case if 'grant_type=password'.",
 						},
 
@@ -98,90 +107,40 @@ func Provider() *schema.Provider {
 							Required: true,
 						},
 
-						"oauth2": {
-							Type:     schema.TypeList,
-							Optional: true,
-							MaxItems: 1,
-							Elem: &schema.Resource{
-								Schema: map[string]*schema.Schema{
-									"grant_type": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "client_credentials",
-										Description: "SAP OAuth2 Grant Type.",
-									},
-									"client_id": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Client Id.",
-									},
-									"client_secret": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Client Secret.",
-									},
-									"token_url": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Token Url.",
-									},
-									"authorization_url": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Authorization Url.",
-									},
-									"redirect_url": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Redirect Url.",
-									},
-
-									"username": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Username. Used in case if 'grant_type=password'.",
-									},
-									"password": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Password. Used in case if 'grant_type=password'.",
-									},
-
-									"timeout_seconds": {
-										Type:        schema.TypeInt,
-										Optional:    true,
-										Default:     60,
-										Description: "SAP OAuth2 HTTP Client timeout.",
-									},
-								},
-							},
-						},
+						"oauth2": oauth2Schema(false),
 					},
 				},
 			},
 		},
 
 		DataSourcesMap: map[string]*schema.Resource{
-			"sap_btp_global_account": dataSourceSapBtpGlobalAccount(),
-			//"sap_btp_global_account_assignments": dataSourceSapBtpGlobalAccountAssignments(),
+			"sap_btp_global_account":                     dataSourceSapBtpGlobalAccount(),
+			"sap_btp_global_account_assignments":         dataSourceSapBtpGlobalAccountAssignments(),
 			"sap_btp_directory":                          dataSourceSapBtpDirectory(),
 			"sap_btp_sub_account":                        dataSourceSapBtpSubAccount(),
+			"sap_btp_sub_accounts":                       dataSourceSapBtpSubAccounts(),
 			"sap_btp_sub_account_custom_properties":      dataSourceSapBtpSubAccountCustomProperties(),
+			"sap_btp_sub_account_entitlements":           dataSourceSapBtpSubAccountEntitlements(),
 			"sap_btp_sub_account_environments_instances": dataSourceSapBtpSubAccountEnvironmentsInstances(),
 
 			"sap_btp_directory_custom_properties": dataSourceSapBtpDirectoryCustomProperties(),
+			"sap_btp_directory_entitlements":      dataSourceSapBtpDirectoryEntitlements(),
 
 			"sap_btp_provisioning_available_environments": dataSourceSapBtpProvisioningAvailableEnvironments(),
 			"sap_btp_application_registration":            dataSourceSapBtpApplicationRegistration(),
 			"sap_btp_application_subscriptions":           dataSourceSapBtpApplicationSubscriptions(),
+
+			"sap_btp_service_offerings": dataSourceSapBtpServiceOfferings(),
+			"sap_btp_service_plans":     dataSourceSapBtpServicePlans(),
+			"sap_btp_service_instance":  dataSourceSapBtpServiceInstance(),
+			"sap_btp_service_instances": dataSourceSapBtpServiceInstances(),
+			"sap_btp_service_binding":   dataSourceSapBtpServiceBinding(),
+			"sap_btp_service_bindings":  dataSourceSapBtpServiceBindings(),
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
 			"sap_btp_sub_account":                              resourceSapBtpSubAccount(),
+			"sap_btp_sub_account_admins":                       resourceSapBtpSubAccountAdmins(),
 			"sap_btp_sub_account_service_management":           resourceSapBtpSubAccountServiceManagement(),
 			"sap_btp_sub_account_service_management_platforms": resourceSapBtpSubAccountServiceManagementPlatforms(),
 			"sap_btp_sub_account_service_management_instances": resourceSapBtpSubAccountServiceManagementInstances(),
@@ -189,6 +148,7 @@ func Provider() *schema.Provider {
 
 			"sap_btp_directory":                        resourceSapBtpDirectory(),
 			"sap_btp_directory_features":               resourceSapBtpDirectoryFeatures(),
+			"sap_btp_directory_admins":                 resourceSapBtpDirectoryAdmins(),
 			"sap_btp_directory_entitlements":           resourceSapBtpDirectoryEntitlements(),
 			"sap_btp_directory_saas_entitlements":      resourceSapBtpDirectoryDynamicEntitlements("saas"),
 			"sap_btp_directory_elastic_entitlements":   resourceSapBtpDirectoryDynamicEntitlements("elastic"),
@@ -222,22 +182,22 @@ type KubeProvider struct {
 }
 
 func providerConfigure(ctx context.Context, d *schema.ResourceData, terraformVersion string) (interface{}, diag.Diagnostics) {
+	// The OAuth2 blocks hold secrets, hence they're never logged
 	oauth2Map := mapFrom(d.Get("oauth2"))
-	log.Printf("[DEBUG] Default OAuth2 configuration: %v", oauth2Map)
-
-	defaultOAuth2 := oauth2ConfigFrom(oauth2Map)
+	defaultOAuth2, err := providerOAuth2Config(oauth2Map, d.Get("credentials_file").(string))
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
 
 	rawEndpoints := listFrom(d.Get("service_endpoint"))
-	log.Printf("[DEBUG] Service Endpoints: %v", rawEndpoints)
 
 	endpointsCfg := make(map[string]*sap.EndpointConfig)
 	for _, rawEndpoint := range rawEndpoints {
-		log.Printf("[DEBUG] Processing Service Endpoints: %v", rawEndpoint)
-
 		endpoint := mapFrom(rawEndpoint)
 
 		serviceId := endpoint["id"].(string)
 		serviceHost := endpoint["host"].(string)
+		log.Printf("[DEBUG] Processing Service Endpoint %s at %s", serviceId, serviceHost)
 
 		serviceOAuth2 := defaultOAuth2
 		oauth2Map := mapFrom(endpoint["oauth2"])
@@ -255,26 +215,19 @@ func providerConfigure(ctx context.Context, d *schema.ResourceData, terraformVer
 		DefaultOAuth2: defaultOAuth2,
 	}
 
-	cfgBytes, _ := json.Marshal(cfg)
-	log.Printf("[DEBUG] SAP BTP OAuth2 config: %s", string(cfgBytes))
-
 	sess, err := session.BuildFromConfig(cfg)
 	if err != nil {
 		return nil, diag.FromErr(err)
 	}
 
-	return &SAPClient{
-		session:                 sess,
-		btpAccountsV1Client:     btpaccounts.New(sess),
-		btpEntitlementsV1Client: btpentitlements.New(sess),
-		//btpProvisioningV1Client:     btpprovisioning.New(sess),
-		//btpSaasManagerV1Client: btpsaasmanager.New(sess),
-	}, nil
+	factory := &sessionClientFactory{
+		session:       sess,
+		defaultOAuth2: defaultOAuth2,
+	}
+	return newSAPClient(factory, endpointsCfg), nil
 }
 
 func mapFrom(block interface{}) map[string]interface{} {
-	log.Printf("[DEBUG] RAW Block configuration: %v", block)
-
 	if block == nil {
 		return nil
 	}
@@ -290,8 +243,6 @@ func mapFrom(block interface{}) map[string]interface{} {
 }
 
 func listFrom(block interface{}) []interface{} {
-	log.Printf("[DEBUG] RAW Block configuration: %v", block)
-
 	if block == nil {
 		return nil
 	}
@@ -1 +1,202 @@
 package sap
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/nnicora/sap-sdk-go/service/btpaccounts"
+	"github.com/nnicora/sap-sdk-go/service/btpprovisioning"
+	"reflect"
+	"testing"
+)
+
+func TestProvider(t *testing.T) {
+	if err := Provider().InternalValidate(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+// TestProviderSensitiveSchema guards the attributes holding secrets from showing up in plans and logs.
+func TestProviderSensitiveSchema(t *testing.T) {
+	secrets := map[string]bool{
+		"client_secret":    true,
+		"password":         true,
+		"credentials":      true,
+		"credentials_json": true,
+	}
+
+	var check func(path string, s map[string]*schema.Schema)
+	check = func(path string, s map[string]*schema.Schema) {
+		for name, attribute := range s {
+			if secrets[name] && !attribute.Sensitive {
+				t.Errorf("%s.%s isn't sensitive", path, name)
+			}
+			if elem, ok := attribute.Elem.(*schema.Resource); ok {
+				check(path+"."+name, elem.Schema)
+			}
+		}
+	}
+
+	p := Provider()
+	check("provider", p.Schema)
+	for name, r := range p.ResourcesMap {
+		check(name, r.Schema)
+	}
+	for name, r := range p.DataSourcesMap {
+		check("data."+name, r.Schema)
+	}
+}
+
+// TestProviderTransport verifies the provider of the fake sends its requests through mockBtpTransport, rather than
+// through the OAuth2 clients of the sdk and http.DefaultTransport, which reach nothing on localhost.
+func TestProviderTransport(t *testing.T) {
+	btp := newMockBtp(t)
+	btp.subAccounts["sub-account-1"] = mockObject{"guid": "sub-account-1", "displayName": "Test Sub Account"}
+	btp.environments["environment-1"] = mockObject{"id": "environment-1", "state": "OK"}
+
+	p := btp.provider()
+	diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"oauth2": []interface{}{
+			map[string]interface{}{
+				"client_id":     "mock-client",
+				"client_secret": "mock-secret",
+				"token_url":     mockBtpHost + "/oauth/token",
+			},
+		},
+		"service_endpoint": []interface{}{
+			map[string]interface{}{"id": "accounts", "host": mockBtpHost},
+			map[string]interface{}{"id": "provisioning", "host": mockBtpHost},
+		},
+	}))
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	output, err := p.Meta().(*SAPClient).btpAccountsV1Client.GetSubAccounts(context.Background(),
+		&btpaccounts.GetSubAccountsInput{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(output.Value) != 1 || output.Value[0].Guid != "sub-account-1" {
+		t.Errorf("sub accounts are %+v, expected sub-account-1 only", output.Value)
+	}
+
+	// The endpoints resources refer to go through it as well
+	d := schema.TestResourceDataRaw(t, resourceSapBtpProvisioningEnvironments().Schema, map[string]interface{}{
+		"endpoint_id": "provisioning",
+	})
+	client, err := p.Meta().(*SAPClient).provisioningV1Client(d, "provisioning_service")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	environment, err := client.GetEnvironmentInstance(context.Background(),
+		&btpprovisioning.GetEnvironmentInstanceInput{EnvironmentInstanceId: "environment-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if environment.State != "OK" {
+		t.Errorf("environment instance is %s, expected OK", environment.State)
+	}
+}
+
+// testAccCheckDestroyed verifies no resource of the given type is left in the collection of the fake.
+func testAccCheckDestroyed(btp *mockBtp, resourceType string, collection map[string]mockObject) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type != resourceType {
+				continue
+			}
+			if btp.exists(collection, rs.Primary.ID) {
+				return fmt.Errorf("%s %s still exists", resourceType, rs.Primary.ID)
+			}
+		}
+		return nil
+	}
+}
+
+// testAccCheckMockObject verifies an attribute of the object the resource is bound to, as the fake holds it.
+func testAccCheckMockObject(btp *mockBtp, name string, collection map[string]mockObject,
+	key string, value interface{}) resource.TestCheckFunc {
+
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("%s not found in state", name)
+		}
+		obj := btp.object(collection, rs.Primary.ID)
+		if obj == nil {
+			return fmt.Errorf("%s %s doesn't exist", name, rs.Primary.ID)
+		}
+		if fmt.Sprint(obj[key]) != fmt.Sprint(value) {
+			return fmt.Errorf("%s %s has %s %v, expected %v", name, rs.Primary.ID, key, obj[key], value)
+		}
+		return nil
+	}
+}
+
+// testAccCheckMockObjectId verifies an attribute of the object the resource is bound to holds the id of another resource.
+func testAccCheckMockObjectId(btp *mockBtp, name string, collection map[string]mockObject,
+	key string, otherName string) resource.TestCheckFunc {
+
+	return func(s *terraform.State) error {
+		other, ok := s.RootModule().Resources[otherName]
+		if !ok {
+			return fmt.Errorf("%s not found in state", otherName)
+		}
+		return testAccCheckMockObject(btp, name, collection, key, other.Primary.ID)(s)
+	}
+}
+
+// testAccCheckMockCustomProperties verifies the object the resource is bound to holds exactly the given custom properties.
+func testAccCheckMockCustomProperties(btp *mockBtp, name string, collection map[string]mockObject,
+	expected map[string]string) resource.TestCheckFunc {
+
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("%s not found in state", name)
+		}
+		obj := btp.object(collection, rs.Primary.ID)
+		if obj == nil {
+			return fmt.Errorf("%s %s doesn't exist", name, rs.Primary.ID)
+		}
+		properties, _ := obj["customProperties"].([]interface{})
+		got := make(map[string]string, len(properties))
+		for _, item := range properties {
+			property := item.(mockObject)
+			got[mockString(property["key"])] = mockString(property["value"])
+		}
+		if !reflect.DeepEqual(got, expected) {
+			return fmt.Errorf("%s %s has custom properties %v, expected %v", name, rs.Primary.ID, got, expected)
+		}
+		return nil
+	}
+}
+
+// testAccImportStateIdFunc returns the import id built from the id of the named resource and the given suffix.
+func testAccImportStateIdFunc(name, suffix string) resource.ImportStateIdFunc {
+	return func(s *terraform.State) (string, error) {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return "", fmt.Errorf("%s not found in state", name)
+		}
+		return rs.Primary.ID + suffix, nil
+	}
+}
+
+// testAccCheckImportedAttributes verifies the single imported resource holds the expected attributes.
+func testAccCheckImportedAttributes(expected map[string]string) resource.ImportStateCheckFunc {
+	return func(states []*terraform.InstanceState) error {
+		if len(states) != 1 {
+			return fmt.Errorf("%d resources imported, expected 1", len(states))
+		}
+		for key, value := range expected {
+			if got := states[0].Attributes[key]; got != value {
+				return fmt.Errorf("imported %s is %q, expected %q", key, got, value)
+			}
+		}
+		return nil
+	}
+}
@@ -7,15 +7,18 @@ import (
 	"github.com/nnicora/sap-sdk-go/sap"
 	"github.com/nnicora/sap-sdk-go/service/btpaccounts"
 	"github.com/pkg/errors"
+	"log"
 	"time"
 )
 
 func resourceSapBtpDirectory() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
+		SchemaVersion: 1,
 		CreateContext: resourceSapBtpDirectoryCreate,
 		ReadContext:   resourceSapBtpDirectoryRead,
 		UpdateContext: resourceSapBtpDirectoryUpdate,
 		DeleteContext: resourceSapBtpDirectoryDelete,
+		CustomizeDiff: resourceSapBtpDirectoryCustomizeDiff,
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
@@ -54,37 +57,14 @@ func resourceSapBtpDirectory() *schema.Resource {
 				Default:  "",
 			},
 			"admins": {
-				Type:     schema.TypeSet,
-				Elem:     &schema.Schema{Type: schema.TypeString},
-				Set:      schema.HashString,
-				Optional: true,
-			},
-			"features": {
-				Type:     schema.TypeSet,
-				Elem:     &schema.Schema{Type: schema.TypeString},
-				Set:      schema.HashString,
-				Optional: true,
-			},
-			"custom_properties": {
-				Type:     schema.TypeList,
-				Optional: true,
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-						"key": {
-							Type:     schema.TypeString,
-							Optional: true,
-						},
-						"value": {
-							Type:     schema.TypeString,
-							Optional: true,
-						},
-						"account_id": {
-							Type:     schema.TypeString,
-							Optional: true,
-						},
-					},
-				},
+				Type:       schema.TypeSet,
+				Elem:       &schema.Schema{Type: schema.TypeString},
+				Set:        schema.HashString,
+				Optional:   true,
+				Deprecated: "Applied on creation only; manage the admins through 'sap_btp_directory_admins'.",
 			},
+			"features":          directoryFeaturesSchemaComputed(),
+			"custom_properties": customPropertiesSchema(),
 
 			"contract_status": {
 				Type:     schema.TypeString,
@@ -139,6 +119,8 @@ func resourceSapBtpDirectory() *schema.Resource {
 				Type:     schema.TypeString,
 				Optional: true,
 				Computed: true,
+				Description: "The directory to create the directory in; the global account when not set. Directories " +
+					"can't be moved, hence changing it fails the plan, while removing it leaves the directory where it is.",
 			},
 			"children": {
 				Type:     schema.TypeList,
@@ -150,17 +132,44 @@ func resourceSapBtpDirectory() *schema.Resource {
 			"tags": tagsSchema(),
 		},
 	}
+	r.StateUpgraders = []schema.StateUpgrader{
+		customPropertiesStateUpgraderV0(r, resourceSapBtpDirectoryCustomPropertiesV0()),
+	}
+	return r
+}
+
+// resourceSapBtpDirectoryCustomPropertiesV0 is the schema custom properties had up to version 0
+func resourceSapBtpDirectoryCustomPropertiesV0() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"key": {
+					Type:     schema.TypeString,
+					Optional: true,
+				},
+				"value": {
+					Type:     schema.TypeString,
+					Optional: true,
+				},
+				"account_id": {
+					Type:     schema.TypeString,
+					Optional: true,
+				},
+			},
+		},
+	}
 }
 
 func resourceSapBtpDirectoryCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	btpAccountsClient := meta.(*SAPClient).btpAccountsV1Client
 
-	cp, err := toBtpAccountsCustomProperties(d.Get("custom_properties"))
-	if err != nil {
-		diag.FromErr(err)
+	input := &createDirectoryInput{
+		CustomProperties: expandCustomProperties(d.Get("custom_properties")),
 	}
-	input := &btpaccounts.CreateDirectoryInput{
-		CustomProperties: cp,
+	if val, ok := d.GetOk("parent_id"); ok {
+		input.ParentId = sap.String(val.(string))
 	}
 	if val, ok := d.GetOk("subdomain"); ok {
 		input.Subdomain = val.(string)
@@ -172,14 +181,14 @@ func resourceSapBtpDirectoryCreate(ctx context.Context, d *schema.ResourceData,
 		input.DirectoryAdmins = expandStringSet(val.(*schema.Set))
 	}
 	if val, ok := d.GetOk("features"); ok {
-		input.DirectoryFeatures = expandStringSet(val.(*schema.Set))
+		input.DirectoryFeatures = expandDirectoryFeatures(val.(*schema.Set))
 	}
 	if val, ok := d.GetOk("display_name"); ok {
 		input.DisplayName = val.(string)
 	}
 
 	logDebug(input, "CreateDirectory Input")
-	if output, err := btpAccountsClient.CreateDirectory(ctx, input); err != nil {
+	if output, err := btpAccountsClient.createDirectory(ctx, input); err != nil {
 		if output != nil && output.Error != nil {
 			return diag.FromErr(
 				errors.Errorf("BTP Directory can't be created; Status Code: %d; %s",
@@ -209,6 +218,11 @@ func resourceSapBtpDirectoryRead(ctx context.Context, d *schema.ResourceData, me
 
 	logDebug(input, "GetDirectory Input")
 	if output, err := btpAccountsClient.GetDirectory(ctx, input); err != nil {
+		if output != nil && isNotFound(output.StatusAndBodyFromResponse, output.Error) {
+			log.Printf("[WARN] BTP Directory %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
 		if output != nil && output.Error != nil {
 			return diag.FromErr(
 				errors.Errorf("BTP Directory can't be read; Status Code: %d; %s",
@@ -226,13 +240,9 @@ func resourceSapBtpDirectoryRead(ctx context.Context, d *schema.ResourceData, me
 func resourceSapBtpDirectoryUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	btpAccountsClient := meta.(*SAPClient).btpAccountsV1Client
 
-	cp, err := toBtpAccountsCustomProperties(d.Get("custom_properties"))
-	if err != nil {
-		diag.FromErr(err)
-	}
-	input := &btpaccounts.UpdateDirectoryInput{
+	input := &updateDirectoryInput{
 		DirectoryGuid:    d.Id(),
-		CustomProperties: cp,
+		CustomProperties: customPropertiesUpdates(d.GetChange("custom_properties")),
 	}
 	if val, ok := d.GetOk("display_name"); ok {
 		input.DisplayName = val.(string)
@@ -242,7 +252,7 @@ func resourceSapBtpDirectoryUpdate(ctx context.Context, d *schema.ResourceData,
 	}
 
 	logDebug(input, "UpdateDirectory Input")
-	if output, err := btpAccountsClient.UpdateDirectory(ctx, input); err != nil {
+	if output, err := btpAccountsClient.updateDirectory(ctx, input); err != nil {
 		if output != nil && output.Error != nil {
 			return diag.FromErr(
 				errors.Errorf("BTP Directory can't be updated; Status Code: %d; %s",
@@ -257,12 +267,26 @@ func resourceSapBtpDirectoryUpdate(ctx context.Context, d *schema.ResourceData,
 	return nil
 }
 
+// The Accounts API moves sub accounts only; a directory stays where it was created, and replacing it along with
+// everything inside is never what a changed parent means.
+func resourceSapBtpDirectoryCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.HasChange("parent_id") {
+		return nil
+	}
+	oldParentId, newParentId := d.GetChange("parent_id")
+	if newParentId.(string) == "" {
+		return nil
+	}
+	return errors.Errorf("BTP Directory %s can't be moved from %s to %s; directories can't change their parent",
+		d.Id(), oldParentId, newParentId)
+}
+
 func readFromDirectoryIntoResourceData(dir btpaccounts.Directory, d *schema.ResourceData) {
 	d.Set("contract_status", dir.ContractStatus)
 	d.Set("created_date", dir.CreatedDate.Format(time.RFC3339))
 	d.Set("display_name", dir.DisplayName)
 	d.Set("modified_date", dir.ModifiedDate.Format(time.RFC3339))
-	d.Set("features", dir.DirectoryFeatures)
+	d.Set("features", flattenDirectoryFeatures(d, dir.DirectoryFeatures))
 	d.Set("parent_id", dir.ParentGuid)
 	d.Set("state_message", dir.StateMessage)
 	d.Set("children", dir.Children)
@@ -278,16 +302,7 @@ func readFromDirectoryIntoResourceData(dir btpaccounts.Directory, d *schema.Reso
 		d.Set("description", dir.Description)
 	}
 
-	cpm := make([]map[string]interface{}, len(dir.CustomProperties))
-	for idx, saCP := range dir.CustomProperties {
-		dm := make(map[string]interface{})
-		dm["key"] = saCP.Key
-		dm["value"] = saCP.Value
-		dm["account_id"] = saCP.AccountGuid
-
-		cpm[idx] = dm
-	}
-	d.Set("custom_properties", cpm)
+	d.Set("custom_properties", flattenCustomProperties(dir.CustomProperties))
 
 	subAccs := make([]map[string]string, len(dir.SubAccounts))
 	for idx, subAcc := range dir.SubAccounts {
@@ -297,7 +312,7 @@ func readFromDirectoryIntoResourceData(dir btpaccounts.Directory, d *schema.Reso
 
 		subAccs[idx] = dm
 	}
-	d.Set("sub_accounts", cpm)
+	d.Set("sub_accounts", subAccs)
 }
 
 func resourceSapBtpDirectoryDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -320,43 +335,3 @@ func resourceSapBtpDirectoryDelete(ctx context.Context, d *schema.ResourceData,
 	}
 	return nil
 }
-
-func toBtpAccountsCustomProperties(input interface{}) ([]btpaccounts.CustomProperties, error) {
-	if input != nil {
-		if cpValues, ok := input.([]interface{}); ok {
-			result := make([]btpaccounts.CustomProperties, 0, len(cpValues))
-			for idx := range cpValues {
-				mps, ok := cpValues[idx].(map[string]interface{})
-				if !ok {
-					continue
-				}
-
-				key := ""
-				if val, ok := mps["key"]; ok {
-					key = val.(string)
-				}
-				value := ""
-				if val, ok := mps["value"]; ok {
-					value = val.(string)
-				}
-
-				if len(key) <= 0 {
-					return nil, errors.Errorf("BTP Directory; Custom Properties 'key' is empty:  %v", mps)
-				}
-				if len(value) <= 0 {
-					return nil, errors.Errorf("BTP Directory; Custom Properties 'value' is empty:  %v", mps)
-				}
-
-				cp := btpaccounts.CustomProperties{
-					KeyValue: btpaccounts.KeyValue{
-						Key:   key,
-						Value: value,
-					},
-				}
-				result = append(result, cp)
-			}
-			return result, nil
-		}
-	}
-	return nil, nil
-}
@@ -0,0 +1,69 @@
+package sap
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nnicora/sap-sdk-go/sap"
+	"github.com/nnicora/sap-sdk-go/service/btpaccounts"
+	"github.com/pkg/errors"
+)
+
+var directoryAdministrators = &accountAdmins{
+	kind:           "Directory",
+	idKey:          "directory_id",
+	roleCollection: "Directory Administrator",
+	subdomain:      directorySubdomain,
+}
+
+func resourceSapBtpDirectoryAdmins() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceSapBtpDirectoryAdminsCreate,
+		ReadContext:   resourceSapBtpDirectoryAdminsRead,
+		UpdateContext: resourceSapBtpDirectoryAdminsUpdate,
+		DeleteContext: resourceSapBtpDirectoryAdminsDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: directoryAdministrators.importState,
+		},
+		Schema: directoryAdministrators.schema("The directory, having the AUTHORIZATIONS feature, whose " +
+			"Authorization Service the endpoint refers to; the host of the endpoint must start with the subdomain of the " +
+			"directory."),
+	}
+}
+
+func resourceSapBtpDirectoryAdminsCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return directoryAdministrators.create(ctx, d, meta)
+}
+
+func resourceSapBtpDirectoryAdminsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return directoryAdministrators.read(ctx, d, meta)
+}
+
+func resourceSapBtpDirectoryAdminsUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return directoryAdministrators.update(ctx, d, meta)
+}
+
+func resourceSapBtpDirectoryAdminsDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return directoryAdministrators.delete(ctx, d, meta)
+}
+
+// directorySubdomain returns the subdomain of the directory, which it only has along with the AUTHORIZATIONS feature
+func directorySubdomain(ctx context.Context, client accountsClient, id string) (string, bool, error) {
+	output, err := client.GetDirectory(ctx, &btpaccounts.GetDirectoryInput{
+		DirectoryGuid: id,
+	})
+	if err != nil {
+		if output != nil && isNotFound(output.StatusAndBodyFromResponse, output.Error) {
+			return "", false, nil
+		}
+		if output != nil && output.Error != nil {
+			return "", false, errors.Errorf("BTP Directory can't be read; %s", sap.StringValue(output.Error.Message))
+		}
+		return "", false, errors.Errorf("BTP Directory can't be read; %v", err)
+	}
+	if output.Subdomain == "" {
+		return "", true, errors.Errorf("BTP Directory %s has no Authorization Service, lacking the AUTHORIZATIONS "+
+			"feature", id)
+	}
+	return output.Subdomain, true, nil
+}
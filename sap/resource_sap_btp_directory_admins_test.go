@@ -0,0 +1,80 @@
+package sap
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nnicora/sap-sdk-go/service/btpaccounts"
+	"strings"
+	"testing"
+)
+
+func TestAccSapBtpDirectoryAdmins_basic(t *testing.T) {
+	btp := newMockBtp(t)
+	btp.addDirectory("mock-directory", "mock-directory")
+	btp.addScimMember("Directory Administrator", "outsider@example.com", "ldap")
+	resourceName := "sap_btp_directory_admins.test"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		CheckDestroy:      testAccCheckMockScimMembers(btp, "Directory Administrator", "outsider@example.com"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpDirectoryAdminsConfig("alice@example.com", "bob@example.com"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "directory_id", "mock-directory"),
+					resource.TestCheckResourceAttr(resourceName, "admins.#", "2"),
+					testAccCheckMockScimMembers(btp, "Directory Administrator",
+						"alice@example.com", "bob@example.com", "outsider@example.com"),
+				),
+			},
+			{
+				Config: testAccSapBtpDirectoryAdminsConfig("bob@example.com"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "admins.#", "1"),
+					testAccCheckMockScimMembers(btp, "Directory Administrator",
+						"bob@example.com", "outsider@example.com"),
+				),
+			},
+		},
+	})
+}
+
+func testAccSapBtpDirectoryAdminsConfig(admins ...string) string {
+	return testAccProviderConfig + fmt.Sprintf(`
+resource "sap_btp_directory_admins" "test" {
+  endpoint_id  = "directory-authorization"
+  directory_id = "mock-directory"
+  admins       = ["%s"]
+}
+`, strings.Join(admins, `", "`))
+}
+
+func TestSapBtpDirectoryAdminsCreate_withoutAuthorizations(t *testing.T) {
+	authorization := newFakeAdminsAuthorization()
+	meta := newFakeSAPClient(&fakeClientFactory{
+		accountsClient: &fakeAccounts{
+			directories: map[string]btpaccounts.Directory{
+				"directory-1": {Guid: "directory-1", DirectoryFeatures: []string{"DEFAULT"}},
+			},
+		},
+		authorizationClient: authorization,
+	})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpDirectoryAdmins().Schema, map[string]interface{}{
+		"endpoint_id":  fakeEndpointId,
+		"directory_id": "directory-1",
+		"admins":       []interface{}{"alice@example.com"},
+	})
+	diags := resourceSapBtpDirectoryAdminsCreate(context.Background(), d, meta)
+	if !diags.HasError() {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(diags[0].Summary, "AUTHORIZATIONS") {
+		t.Errorf("unexpected error: %s", diags[0].Summary)
+	}
+	if len(authorization.createdUsers) > 0 {
+		t.Errorf("users %v were created for a directory without Authorization Service", authorization.createdUsers)
+	}
+}
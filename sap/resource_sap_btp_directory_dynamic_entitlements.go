@@ -2,11 +2,13 @@ package sap
 
 import (
 	"context"
+	"fmt"
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/nnicora/sap-sdk-go/sap"
+	"github.com/nnicora/sap-sdk-go/service/btpentitlements"
 	"time"
 )
 
@@ -17,7 +19,7 @@ func resourceSapBtpDirectoryDynamicEntitlements(plan string) *schema.Resource {
 		UpdateContext: resourceSapBtpDirectoryDynamicEntitlementUpdate(plan),
 		DeleteContext: resourceSapBtpDirectoryDynamicEntitlementDelete(plan),
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: resourceSapBtpDirectoryDynamicEntitlementImport(plan),
 		},
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(3 * time.Minute),
@@ -81,9 +83,65 @@ func resourceSapBtpDirectoryDynamicEntitlementCreate(plan string) func(ctx conte
 
 func resourceSapBtpDirectoryDynamicEntitlementRead(plan string) func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-		//btpAccountsV1Client := meta.(*SAPClient).btpAccountsV1Client
+		return refreshDirectoryAssignments(ctx, d, meta, func(assignment map[string]interface{},
+			plan *btpentitlements.AssignedServicePlan, info *btpentitlements.AssignedServicePlanSubAccount) bool {
 
-		return nil
+			return plan.Unlimited || info.UnlimitedAmountAssigned
+		})
+	}
+}
+
+// Imported by '<directory_id>', the entitlements without a numeric quota assigned to the directory whose category
+// belongs to the plan, see isDynamicEntitlementCategory. The entitlements API doesn't answer whether they are
+// distributed, hence 'distribute' takes its default.
+func resourceSapBtpDirectoryDynamicEntitlementImport(plan string) func(ctx context.Context, d *schema.ResourceData,
+	meta interface{}) ([]*schema.ResourceData, error) {
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+		directoryId := d.Id()
+		services, err := importDirectoryAssignments(ctx, meta, directoryId)
+		if err != nil {
+			return nil, err
+		}
+
+		assignments := make([]interface{}, 0)
+		forEachEntityAssignment(services, directoryId, func(serviceName string,
+			assignedPlan *btpentitlements.AssignedServicePlan, info *btpentitlements.AssignedServicePlanSubAccount) {
+
+			if !assignedPlan.Unlimited && !info.UnlimitedAmountAssigned {
+				return
+			}
+			if !isDynamicEntitlementCategory(plan, assignedPlan.Category) {
+				return
+			}
+			assignments = append(assignments, map[string]interface{}{
+				"service_name": serviceName,
+				"plan_name":    assignedPlan.Name,
+				"distribute":   true,
+			})
+		})
+		if len(assignments) == 0 {
+			return nil, fmt.Errorf("BTP Directory %s has no %s entitlements without a quota", directoryId, plan)
+		}
+
+		d.Set("directory_id", directoryId)
+		if err := d.Set("assignment", assignments); err != nil {
+			return nil, err
+		}
+		return []*schema.ResourceData{d}, nil
+	}
+}
+
+// isDynamicEntitlementCategory tells whether service plans of the category are managed by the saas, elastic or
+// unlimited entitlements: applications by the saas ones, elastic services by the elastic ones and the rest by the
+// unlimited ones.
+func isDynamicEntitlementCategory(plan, category string) bool {
+	switch category {
+	case "APPLICATION":
+		return plan == "saas"
+	case "ELASTIC_SERVICE", "ELASTIC_LIMITED":
+		return plan == "elastic"
+	default:
+		return plan == "unlimited"
 	}
 }
 
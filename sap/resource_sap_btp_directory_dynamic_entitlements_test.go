@@ -0,0 +1,71 @@
+package sap
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"testing"
+)
+
+func TestAccSapBtpDirectorySaasEntitlements_basic(t *testing.T) {
+	testAccSapBtpDirectoryDynamicEntitlements(t, "saas")
+}
+
+func TestAccSapBtpDirectoryElasticEntitlements_basic(t *testing.T) {
+	testAccSapBtpDirectoryDynamicEntitlements(t, "elastic")
+}
+
+func TestAccSapBtpDirectoryUnlimitedEntitlements_basic(t *testing.T) {
+	testAccSapBtpDirectoryDynamicEntitlements(t, "unlimited")
+}
+
+func testAccSapBtpDirectoryDynamicEntitlements(t *testing.T, plan string) {
+	btp := newMockBtp(t)
+	btp.planCategories["destination/lite"] = map[string]string{
+		"saas":      "APPLICATION",
+		"elastic":   "ELASTIC_SERVICE",
+		"unlimited": "SERVICE",
+	}[plan]
+	resourceName := fmt.Sprintf("sap_btp_directory_%s_entitlements.test", plan)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		CheckDestroy:      testAccCheckDestroyed(btp, "sap_btp_directory", btp.directories),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpDirectoryDynamicEntitlementsConfig(plan),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "assignment.0.distribute", "true"),
+					testAccCheckDirectoryAssignment(btp, "destination", "lite", "unlimited", true),
+				),
+			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateIdFunc: testAccImportStateIdFunc("sap_btp_directory.test", ""),
+				ImportStateCheck: testAccCheckImportedAttributes(map[string]string{
+					"assignment.#":              "1",
+					"assignment.0.service_name": "destination",
+					"assignment.0.plan_name":    "lite",
+					"assignment.0.distribute":   "true",
+				}),
+			},
+		},
+	})
+}
+
+func testAccSapBtpDirectoryDynamicEntitlementsConfig(plan string) string {
+	return testAccProviderConfig + fmt.Sprintf(`
+resource "sap_btp_directory" "test" {
+  display_name = "Test Directory"
+}
+
+resource "sap_btp_directory_%s_entitlements" "test" {
+  directory_id = sap_btp_directory.test.id
+
+  assignment {
+    service_name = "destination"
+    plan_name    = "lite"
+  }
+}
+`, plan)
+}
@@ -2,12 +2,14 @@ package sap
 
 import (
 	"context"
+	"fmt"
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/nnicora/sap-sdk-go/sap"
 	"github.com/nnicora/sap-sdk-go/service/btpentitlements"
+	"log"
 	"time"
 )
 
@@ -18,7 +20,7 @@ func resourceSapBtpDirectoryEntitlements() *schema.Resource {
 		UpdateContext: resourceSapBtpDirectoryFixedEntitlementsUpdate,
 		DeleteContext: resourceSapBtpDirectoryFixedEntitlementsDelete,
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: resourceSapBtpDirectoryFixedEntitlementsImport,
 		},
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(3 * time.Minute),
@@ -93,7 +95,52 @@ func resourceSapBtpDirectoryFixedEntitlementsCreate(ctx context.Context,
 func resourceSapBtpDirectoryFixedEntitlementsRead(ctx context.Context,
 	d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 
-	return nil
+	return refreshDirectoryAssignments(ctx, d, meta, func(assignment map[string]interface{},
+		plan *btpentitlements.AssignedServicePlan, info *btpentitlements.AssignedServicePlanSubAccount) bool {
+
+		if plan.Unlimited || info.UnlimitedAmountAssigned || info.Amount < 1 {
+			return false
+		}
+		assignment["amount"] = int(info.Amount)
+		assignment["auto_distribute_amount"] = int(info.AutoDistributeAmount)
+		return true
+	})
+}
+
+// Imported by '<directory_id>', the entitlements with a numeric quota assigned to the directory. The entitlements API
+// doesn't answer whether quotas are distributed, hence 'distribute' is left to the configuration.
+func resourceSapBtpDirectoryFixedEntitlementsImport(ctx context.Context, d *schema.ResourceData,
+	meta interface{}) ([]*schema.ResourceData, error) {
+
+	directoryId := d.Id()
+	services, err := importDirectoryAssignments(ctx, meta, directoryId)
+	if err != nil {
+		return nil, err
+	}
+
+	assignments := make([]interface{}, 0)
+	forEachEntityAssignment(services, directoryId, func(serviceName string,
+		plan *btpentitlements.AssignedServicePlan, info *btpentitlements.AssignedServicePlanSubAccount) {
+
+		if plan.Unlimited || info.UnlimitedAmountAssigned || info.Amount < 1 {
+			return
+		}
+		assignments = append(assignments, map[string]interface{}{
+			"service_name":           serviceName,
+			"plan_name":              plan.Name,
+			"amount":                 int(info.Amount),
+			"auto_distribute_amount": int(info.AutoDistributeAmount),
+		})
+	})
+	if len(assignments) == 0 {
+		return nil, fmt.Errorf("BTP Directory %s has no entitlements with a quota", directoryId)
+	}
+
+	d.Set("directory_id", directoryId)
+	if err := d.Set("assignment", assignments); err != nil {
+		return nil, err
+	}
+	return []*schema.ResourceData{d}, nil
 }
 
 func resourceSapBtpDirectoryFixedEntitlementsUpdate(ctx context.Context,
@@ -179,6 +226,106 @@ func updateDirectoryEntitlements(ctx context.Context, operation string,
 	return nil
 }
 
+// importDirectoryAssignments returns the services entitled to the directory, failing when there's none to import.
+func importDirectoryAssignments(ctx context.Context, meta interface{},
+	directoryId string) ([]btpentitlements.AssignedService, error) {
+	btpEntitlementsV1Client := meta.(*SAPClient).btpEntitlementsV1Client
+
+	input := &btpentitlements.GetAssignmentsInput{
+		DirectoryGuid: directoryId,
+	}
+	output, err := btpEntitlementsV1Client.GetAssignments(ctx, input)
+	if err != nil {
+		if output != nil && output.Error != nil {
+			return nil, fmt.Errorf("BTP Directory Entitlements can't be imported; Operation code %v; %s",
+				output.StatusCode, sap.StringValue(output.Error.Message))
+		}
+		return nil, fmt.Errorf("BTP Directory Entitlements can't be imported;  %v", err)
+	}
+	return output.AssignedServices, nil
+}
+
+// refreshDirectoryAssignments reads back the assignments of the directory, calling refresh for each one still
+// entitled. The assignments gone, or refresh answers false for, are dropped; the resource is removed from state once
+// the directory, or all its assignments, are gone.
+func refreshDirectoryAssignments(ctx context.Context, d *schema.ResourceData, meta interface{},
+	refresh func(assignment map[string]interface{}, plan *btpentitlements.AssignedServicePlan,
+		info *btpentitlements.AssignedServicePlanSubAccount) bool) diag.Diagnostics {
+	btpEntitlementsV1Client := meta.(*SAPClient).btpEntitlementsV1Client
+
+	directoryId := d.Get("directory_id").(string)
+	input := &btpentitlements.GetAssignmentsInput{
+		DirectoryGuid: directoryId,
+	}
+	output, err := btpEntitlementsV1Client.GetAssignments(ctx, input)
+	if err != nil {
+		if output != nil && isNotFound(output.StatusAndBodyFromResponse, output.Error) {
+			log.Printf("[WARN] BTP Directory %s not found, removing its entitlements %s from state", directoryId, d.Id())
+			d.SetId("")
+			return nil
+		} else if output != nil && output.Error != nil {
+			return diag.Errorf("BTP Directory Entitlements can't be read; Operation code %v; %s",
+				output.StatusCode, sap.StringValue(output.Error.Message))
+		}
+		return diag.Errorf("BTP Directory Entitlements can't be read;  %v", err)
+	}
+
+	type assigned struct {
+		plan *btpentitlements.AssignedServicePlan
+		info *btpentitlements.AssignedServicePlanSubAccount
+	}
+	entitled := make(map[string]assigned)
+	forEachEntityAssignment(output.AssignedServices, directoryId, func(serviceName string,
+		plan *btpentitlements.AssignedServicePlan, info *btpentitlements.AssignedServicePlanSubAccount) {
+
+		entitled[serviceName+"/"+plan.Name] = assigned{plan: plan, info: info}
+	})
+
+	assignments, _ := d.Get("assignment").([]interface{})
+	current := make([]interface{}, 0, len(assignments))
+	for _, a := range assignments {
+		assignment, ok := a.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		key := assignment["service_name"].(string) + "/" + assignment["plan_name"].(string)
+		if e, ok := entitled[key]; !ok || !refresh(assignment, e.plan, e.info) {
+			log.Printf("[WARN] BTP Directory %s isn't entitled to %s anymore, removing from state", directoryId, key)
+			continue
+		}
+		current = append(current, assignment)
+	}
+
+	if len(current) == 0 {
+		log.Printf("[WARN] BTP Directory Entitlements %s not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err := d.Set("assignment", current); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// forEachEntityAssignment calls fn for every service plan assigned to the entity itself, a sub account or a
+// directory, skipping the assignments of the sub accounts within a directory.
+func forEachEntityAssignment(services []btpentitlements.AssignedService, entityId string,
+	fn func(serviceName string, plan *btpentitlements.AssignedServicePlan,
+		info *btpentitlements.AssignedServicePlanSubAccount)) {
+
+	for sIdx := range services {
+		plans := services[sIdx].ServicePlans
+		for pIdx := range plans {
+			infos := plans[pIdx].AssignmentInfo
+			for iIdx := range infos {
+				if infos[iIdx].EntityId == entityId {
+					fn(services[sIdx].Name, &plans[pIdx], &infos[iIdx])
+				}
+			}
+		}
+	}
+}
+
 func buildDirectoryEntitlements(data interface{}) []btpentitlements.DirectoryEntitlement {
 	if data == nil {
 		return nil
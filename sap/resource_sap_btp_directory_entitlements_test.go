@@ -0,0 +1,314 @@
+package sap
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/nnicora/sap-sdk-go/service/btpentitlements"
+	"testing"
+)
+
+func TestAccSapBtpDirectoryEntitlements_basic(t *testing.T) {
+	btp := newMockBtp(t)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		CheckDestroy:      testAccCheckDestroyed(btp, "sap_btp_directory", btp.directories),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpDirectoryEntitlementsConfig(2),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sap_btp_directory_entitlements.test", "assignment.0.amount", "2"),
+					testAccCheckDirectoryAssignment(btp, "xsuaa", "application", "amount", 2),
+				),
+			},
+			{
+				Config: testAccSapBtpDirectoryEntitlementsConfig(3),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sap_btp_directory_entitlements.test", "assignment.0.amount", "3"),
+					testAccCheckDirectoryAssignment(btp, "xsuaa", "application", "amount", 3),
+				),
+			},
+			{
+				// Entitlements revoked outside of Terraform are assigned again
+				PreConfig: func() {
+					btp.revokeAssignments("xsuaa", "application")
+				},
+				Config: testAccSapBtpDirectoryEntitlementsConfig(3),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sap_btp_directory_entitlements.test", "assignment.0.amount", "3"),
+					testAccCheckDirectoryAssignment(btp, "xsuaa", "application", "amount", 3),
+				),
+			},
+			{
+				ResourceName:      "sap_btp_directory_entitlements.test",
+				ImportState:       true,
+				ImportStateIdFunc: testAccImportStateIdFunc("sap_btp_directory.test", ""),
+				ImportStateCheck: testAccCheckImportedAttributes(map[string]string{
+					"assignment.#":              "1",
+					"assignment.0.service_name": "xsuaa",
+					"assignment.0.plan_name":    "application",
+					"assignment.0.amount":       "3",
+				}),
+			},
+		},
+	})
+}
+
+// testAccCheckDirectoryAssignment verifies the fake holds an entitlement of sap_btp_directory.test to the plan.
+func testAccCheckDirectoryAssignment(btp *mockBtp, serviceName, planName string,
+	key string, value interface{}) resource.TestCheckFunc {
+
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources["sap_btp_directory.test"]
+		if !ok {
+			return fmt.Errorf("sap_btp_directory.test not found in state")
+		}
+		assignment := btp.directoryAssignment(rs.Primary.ID, serviceName, planName)
+		if assignment == nil {
+			return fmt.Errorf("directory %s isn't entitled to %s/%s", rs.Primary.ID, serviceName, planName)
+		}
+		if fmt.Sprint(assignment[key]) != fmt.Sprint(value) {
+			return fmt.Errorf("directory %s entitlement to %s/%s has %s %v, expected %v",
+				rs.Primary.ID, serviceName, planName, key, assignment[key], value)
+		}
+		return nil
+	}
+}
+
+func testAccSapBtpDirectoryEntitlementsConfig(amount int) string {
+	return testAccProviderConfig + fmt.Sprintf(`
+resource "sap_btp_directory" "test" {
+  display_name = "Test Directory"
+}
+
+resource "sap_btp_directory_entitlements" "test" {
+  directory_id = sap_btp_directory.test.id
+
+  assignment {
+    service_name = "xsuaa"
+    plan_name    = "application"
+    amount       = %d
+  }
+}
+`, amount)
+}
+
+// newFakeDirectoryEntitlements returns a directory entitled to a quota of xsuaa and to destination without one,
+// along with the assignment of a sub account within it, which imports must skip.
+func newFakeDirectoryEntitlements() *fakeEntitlements {
+	return &fakeEntitlements{
+		assignedServices: map[string][]btpentitlements.AssignedService{
+			"directory-1": {
+				{
+					Name: "destination",
+					ServicePlans: []btpentitlements.AssignedServicePlan{
+						{
+							Name:     "lite",
+							Category: "ELASTIC_SERVICE",
+							AssignmentInfo: []btpentitlements.AssignedServicePlanSubAccount{
+								{EntityId: "directory-1", EntityType: "DIRECTORY", UnlimitedAmountAssigned: true},
+							},
+						},
+					},
+				},
+				{
+					Name: "xsuaa",
+					ServicePlans: []btpentitlements.AssignedServicePlan{
+						{
+							Name: "application",
+							AssignmentInfo: []btpentitlements.AssignedServicePlanSubAccount{
+								{EntityId: "directory-1", EntityType: "DIRECTORY", Amount: 3, AutoDistributeAmount: 1},
+								{EntityId: "sub-account-1", EntityType: "SUBACCOUNT", Amount: 1},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestSapBtpDirectoryFixedEntitlementsImport(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: newFakeDirectoryEntitlements()})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpDirectoryEntitlements().Schema, map[string]interface{}{})
+	d.SetId("directory-1")
+	if _, err := resourceSapBtpDirectoryFixedEntitlementsImport(context.Background(), d, meta); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"directory_id":                        "directory-1",
+		"assignment.#":                        1,
+		"assignment.0.service_name":           "xsuaa",
+		"assignment.0.plan_name":              "application",
+		"assignment.0.amount":                 3,
+		"assignment.0.auto_distribute_amount": 1,
+	}
+	for key, value := range expected {
+		if got := d.Get(key); got != value {
+			t.Errorf("%s is %v, expected %v", key, got, value)
+		}
+	}
+}
+
+func TestSapBtpDirectoryDynamicEntitlementImport(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: newFakeDirectoryEntitlements()})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpDirectoryDynamicEntitlements("elastic").Schema,
+		map[string]interface{}{})
+	d.SetId("directory-1")
+	if _, err := resourceSapBtpDirectoryDynamicEntitlementImport("elastic")(context.Background(), d, meta); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"directory_id":              "directory-1",
+		"assignment.#":              1,
+		"assignment.0.service_name": "destination",
+		"assignment.0.plan_name":    "lite",
+	}
+	for key, value := range expected {
+		if got := d.Get(key); got != value {
+			t.Errorf("%s is %v, expected %v", key, got, value)
+		}
+	}
+}
+
+func TestSapBtpDirectoryDynamicEntitlementImport_otherCategory(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: newFakeDirectoryEntitlements()})
+
+	// destination/lite is an elastic service, hence it's left to sap_btp_directory_elastic_entitlements
+	for _, plan := range []string{"saas", "unlimited"} {
+		d := schema.TestResourceDataRaw(t, resourceSapBtpDirectoryDynamicEntitlements(plan).Schema,
+			map[string]interface{}{})
+		d.SetId("directory-1")
+		if _, err := resourceSapBtpDirectoryDynamicEntitlementImport(plan)(context.Background(), d, meta); err == nil {
+			t.Errorf("expected an error importing the elastic entitlements as %s ones", plan)
+		}
+	}
+}
+
+func TestSapBtpDirectoryFixedEntitlementsImport_noQuota(t *testing.T) {
+	entitlements := newFakeDirectoryEntitlements()
+	entitlements.assignedServices["directory-1"][1].ServicePlans[0].AssignmentInfo[0].Amount = 0
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: entitlements})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpDirectoryEntitlements().Schema, map[string]interface{}{})
+	d.SetId("directory-1")
+	if _, err := resourceSapBtpDirectoryFixedEntitlementsImport(context.Background(), d, meta); err == nil {
+		t.Fatalf("expected an error for a directory without any quota assigned")
+	}
+}
+
+func TestSapBtpDirectoryFixedEntitlementsImport_notFound(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: newFakeDirectoryEntitlements()})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpDirectoryEntitlements().Schema, map[string]interface{}{})
+	d.SetId("directory-missing")
+	if _, err := resourceSapBtpDirectoryFixedEntitlementsImport(context.Background(), d, meta); err == nil {
+		t.Fatalf("expected an error for a directory which doesn't exist")
+	}
+}
+
+func testSapBtpDirectoryEntitlementsData(t *testing.T, r *schema.Resource, directoryId string,
+	assignments ...interface{}) *schema.ResourceData {
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"directory_id": directoryId,
+		"assignment":   assignments,
+	})
+	d.SetId("entitlements-0")
+	return d
+}
+
+func TestSapBtpDirectoryFixedEntitlementsRead(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: newFakeDirectoryEntitlements()})
+
+	// destination/lite has no quota and xsuaa/broker isn't entitled, hence both are dropped
+	d := testSapBtpDirectoryEntitlementsData(t, resourceSapBtpDirectoryEntitlements(), "directory-1",
+		map[string]interface{}{"service_name": "xsuaa", "plan_name": "application", "amount": 1, "distribute": true},
+		map[string]interface{}{"service_name": "destination", "plan_name": "lite", "amount": 1},
+		map[string]interface{}{"service_name": "xsuaa", "plan_name": "broker", "amount": 1})
+	if diags := resourceSapBtpDirectoryFixedEntitlementsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	expected := map[string]interface{}{
+		"assignment.#":                        1,
+		"assignment.0.service_name":           "xsuaa",
+		"assignment.0.amount":                 3,
+		"assignment.0.auto_distribute_amount": 1,
+		"assignment.0.distribute":             true,
+	}
+	for key, value := range expected {
+		if got := d.Get(key); got != value {
+			t.Errorf("%s is %v, expected %v", key, got, value)
+		}
+	}
+}
+
+func TestSapBtpDirectoryFixedEntitlementsRead_gone(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: newFakeDirectoryEntitlements()})
+
+	d := testSapBtpDirectoryEntitlementsData(t, resourceSapBtpDirectoryEntitlements(), "directory-1",
+		map[string]interface{}{"service_name": "xsuaa", "plan_name": "broker", "amount": 1})
+	if diags := resourceSapBtpDirectoryFixedEntitlementsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "" {
+		t.Errorf("entitlements weren't removed from state, while none of their assignments is left")
+	}
+}
+
+func TestSapBtpDirectoryFixedEntitlementsRead_notFound(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: newFakeDirectoryEntitlements()})
+
+	d := testSapBtpDirectoryEntitlementsData(t, resourceSapBtpDirectoryEntitlements(), "directory-missing",
+		map[string]interface{}{"service_name": "xsuaa", "plan_name": "application", "amount": 1})
+	if diags := resourceSapBtpDirectoryFixedEntitlementsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "" {
+		t.Errorf("entitlements weren't removed from state, while their directory is gone")
+	}
+}
+
+func TestSapBtpDirectoryDynamicEntitlementRead(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: newFakeDirectoryEntitlements()})
+
+	// xsuaa/application has a quota, hence it isn't managed by this resource
+	d := testSapBtpDirectoryEntitlementsData(t, resourceSapBtpDirectoryDynamicEntitlements("elastic"), "directory-1",
+		map[string]interface{}{"service_name": "destination", "plan_name": "lite"},
+		map[string]interface{}{"service_name": "xsuaa", "plan_name": "application"})
+	if diags := resourceSapBtpDirectoryDynamicEntitlementRead("elastic")(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	expected := map[string]interface{}{
+		"assignment.#":              1,
+		"assignment.0.service_name": "destination",
+		"assignment.0.plan_name":    "lite",
+	}
+	for key, value := range expected {
+		if got := d.Get(key); got != value {
+			t.Errorf("%s is %v, expected %v", key, got, value)
+		}
+	}
+}
+
+func TestSapBtpDirectoryDynamicEntitlementRead_notFound(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: newFakeDirectoryEntitlements()})
+
+	d := testSapBtpDirectoryEntitlementsData(t, resourceSapBtpDirectoryDynamicEntitlements("elastic"),
+		"directory-missing", map[string]interface{}{"service_name": "destination", "plan_name": "lite"})
+	if diags := resourceSapBtpDirectoryDynamicEntitlementRead("elastic")(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "" {
+		t.Errorf("entitlements weren't removed from state, while their directory is gone")
+	}
+}
@@ -2,15 +2,40 @@ package sap
 
 import (
 	"context"
-	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/nnicora/sap-sdk-go/sap"
 	"github.com/nnicora/sap-sdk-go/service/btpaccounts"
+	"log"
 	"time"
 )
 
+// The features a directory can have; DEFAULT is the one every directory starts with
+var directoryFeatureValues = []string{"DEFAULT", "ENTITLEMENTS", "AUTHORIZATIONS"}
+
+// directoryFeaturesSchema returns the set of features of a directory, validated at plan time. DEFAULT may be left
+// out, see flattenDirectoryFeatures; removing them all reverts the directory to DEFAULT.
+func directoryFeaturesSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeSet,
+		Optional: true,
+		Elem: &schema.Schema{
+			Type:         schema.TypeString,
+			ValidateFunc: validation.StringInSlice(directoryFeatureValues, false),
+		},
+		Set: schema.HashString,
+	}
+}
+
+// directoryFeaturesSchemaComputed returns the features of sap_btp_directory, which applies them on creation only and
+// reads back whatever sap_btp_directory_features sets later on.
+func directoryFeaturesSchemaComputed() *schema.Schema {
+	features := directoryFeaturesSchema()
+	features.Computed = true
+	return features
+}
+
 func resourceSapBtpDirectoryFeatures() *schema.Resource {
 	return &schema.Resource{
 		CreateContext: resourceSapBtpDirectoryFeaturesCreate,
@@ -22,20 +47,17 @@ func resourceSapBtpDirectoryFeatures() *schema.Resource {
 		},
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(3 * time.Minute),
+			Update: schema.DefaultTimeout(3 * time.Minute),
 			Delete: schema.DefaultTimeout(3 * time.Minute),
 		},
 		Schema: map[string]*schema.Schema{
 			"directory_id": {
 				Type:         schema.TypeString,
 				Required:     true,
+				ForceNew:     true,
 				ValidateFunc: validation.StringIsNotWhiteSpace,
 			},
-			"features": {
-				Type:     schema.TypeSet,
-				Optional: true,
-				Elem:     &schema.Schema{Type: schema.TypeString},
-				Set:      schema.HashString,
-			},
+			"features": directoryFeaturesSchema(),
 			"admins": {
 				Type:     schema.TypeSet,
 				Optional: true,
@@ -51,58 +73,110 @@ func resourceSapBtpDirectoryFeatures() *schema.Resource {
 func resourceSapBtpDirectoryFeaturesCreate(ctx context.Context,
 	d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 
-	if uuidString, err := uuid.GenerateUUID(); err != nil {
-		return diag.FromErr(err)
-	} else {
-		d.SetId(uuidString)
-	}
 	directoryId := d.Get("directory_id").(string)
-	input := &btpaccounts.UpdateDirectoryFeaturesInput{
-		DirectoryGuid: directoryId,
+	input := buildDirectoryFeaturesInput(directoryId, d)
+	if diags := updateDirectoryFeatures(ctx, "created", input, meta); diags.HasError() {
+		return diags
 	}
-	if val, ok := d.GetOk("admins"); ok {
-		input.DirectoryAdmins = expandStringSet(val.(*schema.Set))
-	}
-	if val, ok := d.GetOk("features"); ok {
-		input.DirectoryFeatures = expandStringSet(val.(*schema.Set))
-	}
-	return updateDirectoryFeatures(ctx, "created", input, meta)
+	d.SetId(directoryId)
+	return resourceSapBtpDirectoryFeaturesRead(ctx, d, meta)
 }
 
 func resourceSapBtpDirectoryFeaturesRead(ctx context.Context,
 	d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	btpAccountsV1Client := meta.(*SAPClient).btpAccountsV1Client
 
+	directoryId := directoryFeaturesId(d)
+	input := &btpaccounts.GetDirectoryInput{
+		DirectoryGuid: directoryId,
+	}
+	if output, err := btpAccountsV1Client.GetDirectory(ctx, input); err != nil {
+		if output != nil && isNotFound(output.StatusAndBodyFromResponse, output.Error) {
+			log.Printf("[WARN] BTP Directory %s not found, removing its features from state", directoryId)
+			d.SetId("")
+			return nil
+		}
+		if output != nil && output.Error != nil {
+			return diag.Errorf("BTP Directory Features can't be read; Operation code %v; %s",
+				output.StatusCode, sap.StringValue(output.Error.Message))
+		}
+		return diag.Errorf("BTP Directory Features can't be read;  %v", err)
+	} else {
+		// The admins aren't part of the directory, so they're kept as configured
+		d.SetId(directoryId)
+		d.Set("directory_id", directoryId)
+		d.Set("features", flattenDirectoryFeatures(d, output.DirectoryFeatures))
+	}
 	return nil
 }
 
 func resourceSapBtpDirectoryFeaturesUpdate(ctx context.Context,
 	d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 
-	//directoryId := d.Get("directory_id").(string)
-	//input := &btpaccounts.UpdateDirectoryFeaturesInput{
-	//	DirectoryGuid: directoryId,
-	//}
-	//if val, ok := d.GetOk("admins"); ok {
-	//	input.DirectoryAdmins = expandStringSet(val.(*schema.Set))
-	//}
-	//if val, ok := d.GetOk("features"); ok {
-	//	input.DirectoryFeatures = expandStringSet(val.(*schema.Set))
-	//}
-	//return updateDirectoryFeatures(ctx, "updated", input, meta)
-	return nil
+	if d.HasChanges("features", "admins") {
+		input := buildDirectoryFeaturesInput(directoryFeaturesId(d), d)
+		if diags := updateDirectoryFeatures(ctx, "updated", input, meta); diags.HasError() {
+			return diags
+		}
+	}
+	return resourceSapBtpDirectoryFeaturesRead(ctx, d, meta)
 }
 
+// Features can't be taken away from a directory one by one; it is reverted to the default ones instead
 func resourceSapBtpDirectoryFeaturesDelete(ctx context.Context,
 	d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 
-	//directoryId := d.Get("directory_id").(string)
-	//input := &btpaccounts.UpdateDirectoryFeaturesInput{
-	//	DirectoryGuid:     directoryId,
-	//	DirectoryAdmins:   []string{},
-	//	DirectoryFeatures: []string{},
-	//}
-	//return updateDirectoryFeatures(ctx, "deleted", input, meta)
-	return nil
+	input := &btpaccounts.UpdateDirectoryFeaturesInput{
+		DirectoryGuid:     directoryFeaturesId(d),
+		DirectoryFeatures: []string{"DEFAULT"},
+	}
+	return updateDirectoryFeatures(ctx, "deleted", input, meta)
+}
+
+// directoryFeaturesId returns the directory the features belong to, which is the id of the resource. Resources
+// created before were given a random id, their directory is the one in state.
+func directoryFeaturesId(d *schema.ResourceData) string {
+	if directoryId, ok := d.GetOk("directory_id"); ok {
+		return directoryId.(string)
+	}
+	return d.Id()
+}
+
+func buildDirectoryFeaturesInput(directoryId string, d *schema.ResourceData) *btpaccounts.UpdateDirectoryFeaturesInput {
+	input := &btpaccounts.UpdateDirectoryFeaturesInput{
+		DirectoryGuid: directoryId,
+	}
+	if val, ok := d.GetOk("admins"); ok {
+		input.DirectoryAdmins = expandStringSet(val.(*schema.Set))
+	}
+	// No features configured means DEFAULT only, which is what removing them reverts to
+	input.DirectoryFeatures = expandDirectoryFeatures(d.Get("features").(*schema.Set))
+	return input
+}
+
+// expandDirectoryFeatures returns the configured features along with DEFAULT, which every directory has.
+func expandDirectoryFeatures(features *schema.Set) []string {
+	expanded := expandStringSet(features)
+	if !features.Contains("DEFAULT") {
+		expanded = append([]string{"DEFAULT"}, expanded...)
+	}
+	return expanded
+}
+
+// flattenDirectoryFeatures returns the features of the directory to keep in state. BTP always answers with DEFAULT,
+// hence it's left out unless the features in state hold it; a directory with DEFAULT only has no features then.
+func flattenDirectoryFeatures(d *schema.ResourceData, features []string) []string {
+	if current, ok := d.Get("features").(*schema.Set); ok && current.Contains("DEFAULT") {
+		return features
+	}
+
+	flattened := make([]string, 0, len(features))
+	for _, feature := range features {
+		if feature != "DEFAULT" {
+			flattened = append(flattened, feature)
+		}
+	}
+	return flattened
 }
 
 func updateDirectoryFeatures(ctx context.Context, operation string,
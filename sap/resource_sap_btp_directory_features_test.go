@@ -0,0 +1,316 @@
+package sap
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/nnicora/sap-sdk-go/service/btpaccounts"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestAccSapBtpDirectoryFeatures_basic(t *testing.T) {
+	btp := newMockBtp(t)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		CheckDestroy:      testAccCheckDestroyed(btp, "sap_btp_directory", btp.directories),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpDirectoryFeaturesConfig(`["DEFAULT", "ENTITLEMENTS"]`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("sap_btp_directory_features.test", "directory_id",
+						"sap_btp_directory.test", "id"),
+					resource.TestCheckResourceAttrPair("sap_btp_directory_features.test", "id",
+						"sap_btp_directory.test", "id"),
+					resource.TestCheckResourceAttr("sap_btp_directory_features.test", "features.#", "2"),
+					testAccCheckDirectoryFeatures(btp, "DEFAULT", "ENTITLEMENTS"),
+				),
+			},
+			{
+				Config: testAccSapBtpDirectoryFeaturesConfig(`["DEFAULT", "ENTITLEMENTS", "AUTHORIZATIONS"]`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sap_btp_directory_features.test", "features.#", "3"),
+					testAccCheckDirectoryFeatures(btp, "DEFAULT", "ENTITLEMENTS", "AUTHORIZATIONS"),
+				),
+			},
+			{
+				// BTP keeps DEFAULT whether configured or not, which mustn't show up as a change
+				Config: testAccSapBtpDirectoryFeaturesConfig(`["ENTITLEMENTS"]`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sap_btp_directory_features.test", "features.#", "1"),
+					testAccCheckDirectoryFeatures(btp, "DEFAULT", "ENTITLEMENTS"),
+				),
+			},
+			{
+				ResourceName:      "sap_btp_directory_features.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				// Removing the features reverts the directory to DEFAULT, as destroying them does
+				Config: testAccSapBtpDirectoryFeaturesConfig(`[]`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sap_btp_directory_features.test", "features.#", "0"),
+					testAccCheckDirectoryFeatures(btp, "DEFAULT"),
+				),
+			},
+			{
+				Config:      testAccSapBtpDirectoryFeaturesConfig(`["DEFAULT", "UNKNOWN"]`),
+				ExpectError: regexp.MustCompile(`expected features\.\d+ to be one of`),
+			},
+		},
+	})
+}
+
+// testAccCheckDirectoryFeatures verifies the features the fake holds for sap_btp_directory.test, in any order.
+func testAccCheckDirectoryFeatures(btp *mockBtp, features ...string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources["sap_btp_directory.test"]
+		if !ok {
+			return fmt.Errorf("sap_btp_directory.test not found in state")
+		}
+		directory := btp.object(btp.directories, rs.Primary.ID)
+		if directory == nil {
+			return fmt.Errorf("directory %s doesn't exist", rs.Primary.ID)
+		}
+
+		actual := make([]string, 0)
+		values, _ := directory["directoryFeatures"].([]interface{})
+		for _, v := range values {
+			actual = append(actual, mockString(v))
+		}
+		sort.Strings(actual)
+		sort.Strings(features)
+		if strings.Join(actual, ",") != strings.Join(features, ",") {
+			return fmt.Errorf("directory %s has features %v, expected %v", rs.Primary.ID, actual, features)
+		}
+		return nil
+	}
+}
+
+// The directory declares the features too, as it reads them back
+func testAccSapBtpDirectoryFeaturesConfig(features string) string {
+	return testAccProviderConfig + fmt.Sprintf(`
+resource "sap_btp_directory" "test" {
+  display_name = "Test Directory"
+  features     = %[1]s
+}
+
+resource "sap_btp_directory_features" "test" {
+  directory_id = sap_btp_directory.test.id
+  features     = %[1]s
+}
+`, features)
+}
+
+func newFakeDirectoryFeatures() *fakeAccounts {
+	return &fakeAccounts{
+		directories: map[string]btpaccounts.Directory{
+			"directory-1": {Guid: "directory-1", DirectoryFeatures: []string{"DEFAULT"}},
+		},
+	}
+}
+
+func TestSapBtpDirectoryFeaturesCreate(t *testing.T) {
+	accounts := newFakeDirectoryFeatures()
+	meta := newFakeSAPClient(&fakeClientFactory{accountsClient: accounts})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpDirectoryFeatures().Schema, map[string]interface{}{
+		"directory_id": "directory-1",
+		"features":     []interface{}{"DEFAULT", "ENTITLEMENTS"},
+		"admins":       []interface{}{"admin@example.com"},
+	})
+	if diags := resourceSapBtpDirectoryFeaturesCreate(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "directory-1" {
+		t.Errorf("id is %q, expected the directory %q", d.Id(), "directory-1")
+	}
+	if got := accounts.updatedDirectoryFeatures.DirectoryAdmins; len(got) != 1 || got[0] != "admin@example.com" {
+		t.Errorf("directory was updated with admins %v", got)
+	}
+	if got := d.Get("features").(*schema.Set).Len(); got != 2 {
+		t.Errorf("%d features read back, expected 2", got)
+	}
+}
+
+func TestSapBtpDirectoryFeaturesRead(t *testing.T) {
+	accounts := newFakeDirectoryFeatures()
+	meta := newFakeSAPClient(&fakeClientFactory{accountsClient: accounts})
+
+	// Imported by the directory, nothing but the id in state
+	d := schema.TestResourceDataRaw(t, resourceSapBtpDirectoryFeatures().Schema, map[string]interface{}{})
+	d.SetId("directory-1")
+	if diags := resourceSapBtpDirectoryFeaturesRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if got := d.Get("directory_id").(string); got != "directory-1" {
+		t.Errorf("directory_id is %q, expected %q", got, "directory-1")
+	}
+	if features := d.Get("features").(*schema.Set); features.Len() != 0 {
+		t.Errorf("features %v read back, expected none for a directory with DEFAULT only", features.List())
+	}
+}
+
+func TestSapBtpDirectoryFeaturesCreate_withoutDefault(t *testing.T) {
+	accounts := newFakeDirectoryFeatures()
+	meta := newFakeSAPClient(&fakeClientFactory{accountsClient: accounts})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpDirectoryFeatures().Schema, map[string]interface{}{
+		"directory_id": "directory-1",
+		"features":     []interface{}{"ENTITLEMENTS"},
+	})
+	if diags := resourceSapBtpDirectoryFeaturesCreate(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	sent := accounts.updatedDirectoryFeatures.DirectoryFeatures
+	sort.Strings(sent)
+	if strings.Join(sent, ",") != "DEFAULT,ENTITLEMENTS" {
+		t.Errorf("directory was updated with features %v, expected DEFAULT along with ENTITLEMENTS", sent)
+	}
+	if features := d.Get("features").(*schema.Set); features.Len() != 1 || !features.Contains("ENTITLEMENTS") {
+		t.Errorf("features %v read back, expected ENTITLEMENTS only as configured", features.List())
+	}
+}
+
+func TestSapBtpDirectoryFeaturesRead_withoutDefault(t *testing.T) {
+	accounts := newFakeDirectoryFeatures()
+	accounts.directories["directory-1"] = btpaccounts.Directory{
+		Guid:              "directory-1",
+		DirectoryFeatures: []string{"DEFAULT", "ENTITLEMENTS"},
+	}
+	meta := newFakeSAPClient(&fakeClientFactory{accountsClient: accounts})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpDirectoryFeatures().Schema, map[string]interface{}{
+		"directory_id": "directory-1",
+		"features":     []interface{}{"ENTITLEMENTS"},
+	})
+	d.SetId("directory-1")
+	if diags := resourceSapBtpDirectoryFeaturesRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if features := d.Get("features").(*schema.Set); features.Len() != 1 || !features.Contains("ENTITLEMENTS") {
+		t.Errorf("features %v read back, expected ENTITLEMENTS only as configured", features.List())
+	}
+}
+
+func TestSapBtpDirectoryFeaturesUpdate_removed(t *testing.T) {
+	accounts := newFakeDirectoryFeatures()
+	accounts.directories["directory-1"] = btpaccounts.Directory{
+		Guid:              "directory-1",
+		DirectoryFeatures: []string{"DEFAULT", "ENTITLEMENTS"},
+	}
+	meta := newFakeSAPClient(&fakeClientFactory{accountsClient: accounts})
+
+	r := resourceSapBtpDirectoryFeatures()
+	state := &terraform.InstanceState{
+		ID: "directory-1",
+		Attributes: map[string]string{
+			"id":           "directory-1",
+			"directory_id": "directory-1",
+			"features.#":   "1",
+			"features.0":   "ENTITLEMENTS",
+		},
+	}
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"directory_id": "directory-1",
+	})
+	diff, err := r.Diff(context.Background(), state, config, meta)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff == nil || diff.Attributes["features.#"] == nil {
+		t.Fatalf("removing the features plans no change")
+	}
+
+	d, err := schema.InternalMap(r.Schema).Data(state, diff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diags := resourceSapBtpDirectoryFeaturesUpdate(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	features := accounts.directories["directory-1"].DirectoryFeatures
+	if len(features) != 1 || features[0] != "DEFAULT" {
+		t.Errorf("directory has features %v, expected it reverted to DEFAULT", features)
+	}
+	if features := d.Get("features").(*schema.Set); features.Len() != 0 {
+		t.Errorf("features %v read back, expected none as configured", features.List())
+	}
+}
+
+func TestSapBtpDirectoryFeaturesRead_notFound(t *testing.T) {
+	accounts := newFakeDirectoryFeatures()
+	meta := newFakeSAPClient(&fakeClientFactory{accountsClient: accounts})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpDirectoryFeatures().Schema, map[string]interface{}{
+		"directory_id": "directory-missing",
+	})
+	d.SetId("directory-missing")
+	if diags := resourceSapBtpDirectoryFeaturesRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "" {
+		t.Errorf("features of directory %s are still in state", d.Id())
+	}
+}
+
+func TestSapBtpDirectoryFeaturesDelete(t *testing.T) {
+	accounts := newFakeDirectoryFeatures()
+	accounts.directories["directory-1"] = btpaccounts.Directory{
+		Guid:              "directory-1",
+		DirectoryFeatures: []string{"DEFAULT", "ENTITLEMENTS"},
+	}
+	meta := newFakeSAPClient(&fakeClientFactory{accountsClient: accounts})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpDirectoryFeatures().Schema, map[string]interface{}{
+		"directory_id": "directory-1",
+		"features":     []interface{}{"DEFAULT", "ENTITLEMENTS"},
+	})
+	d.SetId("directory-1")
+	if diags := resourceSapBtpDirectoryFeaturesDelete(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	features := accounts.directories["directory-1"].DirectoryFeatures
+	if len(features) != 1 || features[0] != "DEFAULT" {
+		t.Errorf("directory has features %v, expected it reverted to DEFAULT", features)
+	}
+}
+
+func TestSapBtpDirectoryFeaturesDelete_error(t *testing.T) {
+	accounts := newFakeDirectoryFeatures()
+	meta := newFakeSAPClient(&fakeClientFactory{accountsClient: accounts})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpDirectoryFeatures().Schema, map[string]interface{}{
+		"directory_id": "directory-missing",
+	})
+	d.SetId("directory-missing")
+	if diags := resourceSapBtpDirectoryFeaturesDelete(context.Background(), d, meta); !diags.HasError() {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestSapBtpDirectoryFeatures_validation(t *testing.T) {
+	r := resourceSapBtpDirectoryFeatures()
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"directory_id": "directory-1",
+		"features":     []interface{}{"DEFAULT", "UNKNOWN"},
+	})
+	if diags := r.Validate(config); !diags.HasError() {
+		t.Fatalf("expected an error for an unknown feature")
+	}
+
+	config = terraform.NewResourceConfigRaw(map[string]interface{}{
+		"directory_id": "directory-1",
+		"features":     []interface{}{"DEFAULT", "ENTITLEMENTS", "AUTHORIZATIONS"},
+	})
+	if diags := r.Validate(config); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+}
@@ -0,0 +1,164 @@
+package sap
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestAccSapBtpDirectory_basic(t *testing.T) {
+	btp := newMockBtp(t)
+	resourceName := "sap_btp_directory.test"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		CheckDestroy:      testAccCheckDestroyed(btp, "sap_btp_directory", btp.directories),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpDirectoryConfig("Test Directory"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "display_name", "Test Directory"),
+					resource.TestCheckResourceAttr(resourceName, "parent_id", mockBtpGlobalAccountId),
+					resource.TestCheckResourceAttr(resourceName, "entity_state", "OK"),
+					testAccCheckMockObject(btp, resourceName, btp.directories, "displayName", "Test Directory"),
+				),
+			},
+			{
+				Config: testAccSapBtpDirectoryConfig("Renamed Directory"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "display_name", "Renamed Directory"),
+					testAccCheckMockObject(btp, resourceName, btp.directories, "displayName", "Renamed Directory"),
+				),
+			},
+		},
+	})
+}
+
+func testAccSapBtpDirectoryConfig(displayName string) string {
+	return testAccProviderConfig + fmt.Sprintf(`
+resource "sap_btp_directory" "test" {
+  display_name = %q
+  description  = "Directory of the acceptance tests"
+}
+`, displayName)
+}
+
+func TestAccSapBtpDirectory_customProperties(t *testing.T) {
+	btp := newMockBtp(t)
+	resourceName := "sap_btp_directory.test"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		CheckDestroy:      testAccCheckDestroyed(btp, "sap_btp_directory", btp.directories),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpDirectoryCustomPropertiesConfig(`{
+    cost_center = "1000"
+    owner       = "team-a"
+  }`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "custom_properties.%", "2"),
+					resource.TestCheckResourceAttr(resourceName, "custom_properties.owner", "team-a"),
+					testAccCheckMockCustomProperties(btp, resourceName, btp.directories, map[string]string{
+						"cost_center": "1000",
+						"owner":       "team-a",
+					}),
+				),
+			},
+			{
+				Config: testAccSapBtpDirectoryCustomPropertiesConfig(`{
+    owner = "team-b"
+  }`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "custom_properties.%", "1"),
+					resource.TestCheckResourceAttr(resourceName, "custom_properties.owner", "team-b"),
+					testAccCheckMockCustomProperties(btp, resourceName, btp.directories, map[string]string{
+						"owner": "team-b",
+					}),
+				),
+			},
+			{
+				Config: testAccSapBtpDirectoryCustomPropertiesConfig(`{
+    owner = ""
+  }`),
+				ExpectError: regexp.MustCompile(`custom_properties\.owner can't be empty`),
+			},
+		},
+	})
+}
+
+func testAccSapBtpDirectoryCustomPropertiesConfig(customProperties string) string {
+	return testAccProviderConfig + fmt.Sprintf(`
+resource "sap_btp_directory" "test" {
+  display_name      = "Test Directory"
+  custom_properties = %s
+}
+`, customProperties)
+}
+
+func TestAccSapBtpDirectory_parent(t *testing.T) {
+	btp := newMockBtp(t)
+	resourceName := "sap_btp_directory.test"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		CheckDestroy:      testAccCheckDestroyed(btp, "sap_btp_directory", btp.directories),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpDirectoryParentConfig("first"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(resourceName, "parent_id", "sap_btp_directory.first", "id"),
+					testAccCheckMockObjectId(btp, resourceName, btp.directories, "parentGuid", "sap_btp_directory.first"),
+				),
+			},
+			{
+				Config:      testAccSapBtpDirectoryParentConfig("second"),
+				ExpectError: regexp.MustCompile("directories can't change their parent"),
+			},
+		},
+	})
+}
+
+func testAccSapBtpDirectoryParentConfig(parent string) string {
+	return testAccProviderConfig + fmt.Sprintf(`
+resource "sap_btp_directory" "first" {
+  display_name = "First Directory"
+}
+
+resource "sap_btp_directory" "second" {
+  display_name = "Second Directory"
+}
+
+resource "sap_btp_directory" "test" {
+  display_name = "Nested Directory"
+  parent_id    = sap_btp_directory.%s.id
+}
+`, parent)
+}
+
+func TestSapBtpDirectoryCustomizeDiff_move(t *testing.T) {
+	r := resourceSapBtpDirectory()
+	state := &terraform.InstanceState{
+		ID: "directory-1",
+		Attributes: map[string]string{
+			"id":           "directory-1",
+			"display_name": "Nested Directory",
+			"parent_id":    "directory-2",
+		},
+	}
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"display_name": "Nested Directory",
+		"parent_id":    "directory-3",
+	})
+	_, err := r.Diff(context.Background(), state, config, nil)
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(err.Error(), "directories can't change their parent") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -2,11 +2,14 @@ package sap
 
 import (
 	"context"
+	"fmt"
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/nnicora/sap-sdk-go/sap"
+	"github.com/nnicora/sap-sdk-go/service/btpentitlements"
+	"log"
 	"time"
 )
 
@@ -17,7 +20,7 @@ func resourceSapBtpDynamicEntitlements(plan string) *schema.Resource {
 		UpdateContext: resourceSapBtpDynamicEntitlementsUpdate(plan),
 		DeleteContext: resourceSapBtpDynamicEntitlementsDelete(plan),
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: resourceSapBtpDynamicEntitlementsImport(plan),
 		},
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(3 * time.Minute),
@@ -113,12 +116,61 @@ func resourceSapBtpDynamicEntitlementsCreate(plan string) func(ctx context.Conte
 
 func resourceSapBtpDynamicEntitlementsRead(plan string) func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-		//btpAccountsV1Client := meta.(*SAPClient).btpAccountsV1Client
+		services, _ := d.Get("service").([]interface{})
+		// The plan named after the resource is assigned, whatever 'plan_name' says
+		current, diags := refreshSubAccountAssignments(ctx, meta, services, plan, func(assignment map[string]interface{},
+			plan *btpentitlements.AssignedServicePlan, info *btpentitlements.AssignedServicePlanSubAccount) bool {
 
+			return true
+		})
+		if diags != nil {
+			return diags
+		}
+
+		if len(services) > 0 && len(current) == 0 {
+			log.Printf("[WARN] BTP Sub Account %s Entitlements %s not found, removing from state", plan, d.Id())
+			d.SetId("")
+			return nil
+		}
+		if err := d.Set("service", current); err != nil {
+			return diag.FromErr(err)
+		}
 		return nil
 	}
 }
 
+// Imported by '<sub_account_id>/<service>/<plan>', the assignment of the service plan to the sub account. The plan
+// must be the one the resource assigns, which is named after it.
+func resourceSapBtpDynamicEntitlementsImport(plan string) func(ctx context.Context, d *schema.ResourceData,
+	meta interface{}) ([]*schema.ResourceData, error) {
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+		subAccountId, serviceName, planName, err := parseSubAccountEntitlementsId(d.Id())
+		if err != nil {
+			return nil, err
+		}
+		if planName != plan {
+			return nil, fmt.Errorf("BTP Sub Account Entitlements can't be imported; sap_btp_%s_entitlements "+
+				"assigns the '%s' plan only, not '%s'", plan, plan, planName)
+		}
+		if _, _, err := importSubAccountAssignment(ctx, meta, subAccountId, serviceName, planName); err != nil {
+			return nil, err
+		}
+
+		assignment := map[string]interface{}{
+			"sub_account_id": subAccountId,
+		}
+		service := map[string]interface{}{
+			"name":       serviceName,
+			"plan_name":  planName,
+			"assignment": []interface{}{assignment},
+		}
+		if err := d.Set("service", []interface{}{service}); err != nil {
+			return nil, err
+		}
+		return []*schema.ResourceData{d}, nil
+	}
+}
+
 func resourceSapBtpDynamicEntitlementsUpdate(plan string) func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 		servicePlans := buildEntitlementsSubAccountServicePlan(d.Get("service"))
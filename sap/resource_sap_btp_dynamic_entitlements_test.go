@@ -0,0 +1,165 @@
+package sap
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"strings"
+	"testing"
+)
+
+func TestAccSapBtpSaasEntitlements_basic(t *testing.T) {
+	testAccSapBtpDynamicEntitlements(t, "saas")
+}
+
+func TestAccSapBtpElasticEntitlements_basic(t *testing.T) {
+	testAccSapBtpDynamicEntitlements(t, "elastic")
+}
+
+func TestAccSapBtpUnlimitedEntitlements_basic(t *testing.T) {
+	testAccSapBtpDynamicEntitlements(t, "unlimited")
+}
+
+func testAccSapBtpDynamicEntitlements(t *testing.T, plan string) {
+	btp := newMockBtp(t)
+	resourceName := fmt.Sprintf("sap_btp_%s_entitlements.test", plan)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		CheckDestroy:      testAccCheckDestroyed(btp, "sap_btp_sub_account", btp.subAccounts),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpDynamicEntitlementsConfig(plan),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "service.0.name", "destination"),
+					testAccCheckSubAccountAssignment(btp, "destination", plan, "unlimited", true),
+				),
+			},
+			{
+				// Entitlements revoked outside of Terraform are assigned again
+				PreConfig: func() {
+					btp.revokeAssignments("destination", plan)
+				},
+				Config: testAccSapBtpDynamicEntitlementsConfig(plan),
+				Check:  testAccCheckSubAccountAssignment(btp, "destination", plan, "unlimited", true),
+			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateIdFunc: testAccImportStateIdFunc("sap_btp_sub_account.test", "/destination/"+plan),
+				ImportStateCheck: testAccCheckImportedAttributes(map[string]string{
+					"service.0.name":         "destination",
+					"service.0.plan_name":    plan,
+					"service.0.assignment.#": "1",
+				}),
+			},
+		},
+	})
+}
+
+func testAccSapBtpDynamicEntitlementsConfig(plan string) string {
+	return testAccSapBtpSubAccountConfig("Test Sub Account") + fmt.Sprintf(`
+resource "sap_btp_%s_entitlements" "test" {
+  service {
+    name      = "destination"
+    plan_name = %[1]q
+
+    assignment {
+      sub_account_id = sap_btp_sub_account.test.id
+    }
+  }
+}
+`, plan)
+}
+
+func testSapBtpDynamicEntitlementsData(t *testing.T, plan string, services ...interface{}) *schema.ResourceData {
+	d := schema.TestResourceDataRaw(t, resourceSapBtpDynamicEntitlements(plan).Schema, map[string]interface{}{
+		"service": services,
+	})
+	d.SetId("entitlements-0")
+	return d
+}
+
+func testSapBtpDynamicEntitlementsService(serviceName, planName string, subAccountIds ...string) map[string]interface{} {
+	assignments := make([]interface{}, 0, len(subAccountIds))
+	for _, subAccountId := range subAccountIds {
+		assignments = append(assignments, map[string]interface{}{"sub_account_id": subAccountId})
+	}
+	return map[string]interface{}{
+		"name":       serviceName,
+		"plan_name":  planName,
+		"assignment": assignments,
+	}
+}
+
+func TestSapBtpDynamicEntitlementsRead_partiallyGone(t *testing.T) {
+	entitlements := newFakeSubAccountEntitlements()
+	entitlements.assignedServices["sub-account-1"][0].ServicePlans[0].Name = "elastic"
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: entitlements})
+
+	// The elastic plan is assigned, whatever the configured plan name
+	d := testSapBtpDynamicEntitlementsData(t, "elastic",
+		testSapBtpDynamicEntitlementsService("xsuaa", "application", "sub-account-1", "sub-account-missing"))
+	if diags := resourceSapBtpDynamicEntitlementsRead("elastic")(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	expected := map[string]interface{}{
+		"service.#":                             1,
+		"service.0.assignment.#":                1,
+		"service.0.assignment.0.sub_account_id": "sub-account-1",
+	}
+	for key, value := range expected {
+		if got := d.Get(key); got != value {
+			t.Errorf("%s is %v, expected %v", key, got, value)
+		}
+	}
+}
+
+func TestSapBtpDynamicEntitlementsRead_notFound(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: newFakeSubAccountEntitlements()})
+
+	d := testSapBtpDynamicEntitlementsData(t, "elastic",
+		testSapBtpDynamicEntitlementsService("xsuaa", "application", "sub-account-missing"))
+	if diags := resourceSapBtpDynamicEntitlementsRead("elastic")(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "" {
+		t.Errorf("entitlements weren't removed from state, while their sub account is gone")
+	}
+}
+
+func TestSapBtpDynamicEntitlementsImport(t *testing.T) {
+	entitlements := newFakeSubAccountEntitlements()
+	entitlements.assignedServices["sub-account-1"][0].ServicePlans[0].Name = "elastic"
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: entitlements})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpDynamicEntitlements("elastic").Schema, map[string]interface{}{})
+	d.SetId("sub-account-1/xsuaa/elastic")
+	if _, err := resourceSapBtpDynamicEntitlementsImport("elastic")(context.Background(), d, meta); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"service.0.name":                        "xsuaa",
+		"service.0.plan_name":                   "elastic",
+		"service.0.assignment.0.sub_account_id": "sub-account-1",
+	}
+	for key, value := range expected {
+		if got := d.Get(key); got != value {
+			t.Errorf("%s is %v, expected %v", key, got, value)
+		}
+	}
+}
+
+func TestSapBtpDynamicEntitlementsImport_otherPlan(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: newFakeSubAccountEntitlements()})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpDynamicEntitlements("saas").Schema, map[string]interface{}{})
+	d.SetId("sub-account-1/xsuaa/application")
+	_, err := resourceSapBtpDynamicEntitlementsImport("saas")(context.Background(), d, meta)
+	if err == nil || !strings.Contains(err.Error(), "assigns the 'saas' plan only") {
+		t.Fatalf("expected an error for a plan the resource doesn't assign, got %v", err)
+	}
+}
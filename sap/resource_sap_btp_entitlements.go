@@ -10,6 +10,8 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/nnicora/sap-sdk-go/sap"
 	"github.com/nnicora/sap-sdk-go/service/btpentitlements"
+	"log"
+	"strings"
 	"time"
 )
 
@@ -20,7 +22,7 @@ func resourceSapBtpEntitlements() *schema.Resource {
 		UpdateContext: resourceSapBtpEntitlementFixedAssignmentsUpdate,
 		DeleteContext: resourceSapBtpEntitlementFixedAssignmentsDelete,
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: resourceSapBtpEntitlementFixedAssignmentsImport,
 		},
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(3 * time.Minute),
@@ -118,9 +120,138 @@ func resourceSapBtpEntitlementFixedAssignmentsCreate(ctx context.Context,
 func resourceSapBtpEntitlementFixedAssignmentsRead(ctx context.Context,
 	d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 
+	services, _ := d.Get("service").([]interface{})
+	current, diags := refreshSubAccountAssignments(ctx, meta, services, "", func(assignment map[string]interface{},
+		plan *btpentitlements.AssignedServicePlan, info *btpentitlements.AssignedServicePlanSubAccount) bool {
+
+		assignment["enable"] = info.UnlimitedAmountAssigned
+		if !info.UnlimitedAmountAssigned {
+			// The amount is meaningless for unlimited assignments, hence the configured one is kept
+			assignment["amount"] = int(info.Amount)
+		}
+		return true
+	})
+	if diags != nil {
+		return diags
+	}
+
+	if len(services) > 0 && len(current) == 0 {
+		log.Printf("[WARN] BTP Sub Account Entitlements %s not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err := d.Set("service", current); err != nil {
+		return diag.FromErr(err)
+	}
 	return nil
 }
 
+// refreshSubAccountAssignments reads back the assignments of the service blocks, calling refresh for each one still
+// assigned to its sub account. The assignments gone, or refresh answers false for, are dropped, and so are the
+// service blocks left without assignments; the next apply then assigns them again. The assignments are looked up
+// by the configured 'plan_name', unless assignedPlan names the plan assigned in its place.
+func refreshSubAccountAssignments(ctx context.Context, meta interface{}, services []interface{}, assignedPlan string,
+	refresh func(assignment map[string]interface{}, plan *btpentitlements.AssignedServicePlan,
+		info *btpentitlements.AssignedServicePlanSubAccount) bool) ([]interface{}, diag.Diagnostics) {
+	btpEntitlementsV1Client := meta.(*SAPClient).btpEntitlementsV1Client
+
+	// Assignments are fetched once per sub account, no matter how many service blocks refer to it
+	assignedServices := make(map[string][]btpentitlements.AssignedService)
+	current := make([]interface{}, 0, len(services))
+	for _, s := range services {
+		service, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		serviceName := service["name"].(string)
+		planName := service["plan_name"].(string)
+		if assignedPlan != "" {
+			planName = assignedPlan
+		}
+
+		assignments, _ := service["assignment"].([]interface{})
+		currentAssignments := make([]interface{}, 0, len(assignments))
+		for _, a := range assignments {
+			assignment, ok := a.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			subAccountId := assignment["sub_account_id"].(string)
+
+			assigned, ok := assignedServices[subAccountId]
+			if !ok {
+				input := &btpentitlements.GetAssignmentsInput{
+					SubAccountGuid: subAccountId,
+				}
+				output, err := btpEntitlementsV1Client.GetAssignments(ctx, input)
+				if err != nil {
+					if output != nil && isNotFound(output.StatusAndBodyFromResponse, output.Error) {
+						// The sub account is gone, and so are its assignments
+						log.Printf("[WARN] BTP Sub Account %s not found, its entitlements are gone", subAccountId)
+					} else if output != nil && output.Error != nil {
+						return nil, diag.Errorf("BTP Sub Account Entitlements can't be read; Operation code %v; %s",
+							output.StatusCode, sap.StringValue(output.Error.Message))
+					} else {
+						return nil, diag.Errorf("BTP Sub Account Entitlements can't be read;  %v", err)
+					}
+				} else {
+					assigned = output.AssignedServices
+				}
+				assignedServices[subAccountId] = assigned
+			}
+
+			plan, info := findSubAccountAssignment(assigned, serviceName, planName, subAccountId)
+			if info == nil || !refresh(assignment, plan, info) {
+				log.Printf("[WARN] BTP Sub Account %s isn't entitled to %s/%s anymore, removing from state",
+					subAccountId, serviceName, planName)
+				continue
+			}
+			currentAssignments = append(currentAssignments, assignment)
+		}
+		if len(currentAssignments) == 0 {
+			continue
+		}
+		service["assignment"] = currentAssignments
+		current = append(current, service)
+	}
+	return current, nil
+}
+
+// Imported by '<sub_account_id>/<service>/<plan>', the assignment of the service plan to the sub account
+func resourceSapBtpEntitlementFixedAssignmentsImport(ctx context.Context, d *schema.ResourceData,
+	meta interface{}) ([]*schema.ResourceData, error) {
+
+	subAccountId, serviceName, planName, err := parseSubAccountEntitlementsId(d.Id())
+	if err != nil {
+		return nil, err
+	}
+	_, info, err := importSubAccountAssignment(ctx, meta, subAccountId, serviceName, planName)
+	if err != nil {
+		return nil, err
+	}
+
+	assignment := map[string]interface{}{
+		"sub_account_id": subAccountId,
+		"amount":         int(info.Amount),
+		"enable":         info.UnlimitedAmountAssigned,
+	}
+	if info.UnlimitedAmountAssigned {
+		// The amount is meaningless for unlimited assignments, yet required; hence the least one allowed
+		assignment["amount"] = 1
+	} else if info.Amount < 1 {
+		return nil, fmt.Errorf("BTP Sub Account %s has no quota of %s/%s assigned", subAccountId, serviceName, planName)
+	}
+	service := map[string]interface{}{
+		"name":       serviceName,
+		"plan_name":  planName,
+		"assignment": []interface{}{assignment},
+	}
+	if err := d.Set("service", []interface{}{service}); err != nil {
+		return nil, err
+	}
+	return []*schema.ResourceData{d}, nil
+}
+
 func resourceSapBtpEntitlementFixedAssignmentsUpdate(ctx context.Context,
 	d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 
@@ -197,6 +328,63 @@ func entitlementsUpdateSubAccountServicePlan(ctx context.Context, operation stri
 	return nil
 }
 
+// parseSubAccountEntitlementsId splits the import id of sub account entitlements, '<sub_account_id>/<service>/<plan>'
+func parseSubAccountEntitlementsId(id string) (string, string, string, error) {
+	parts := strings.Split(id, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", fmt.Errorf("unexpected format of ID (%s), expected <sub_account_id>/<service>/<plan>", id)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+// importSubAccountAssignment returns the assignment of the service plan to the sub account, failing when there's
+// none to import.
+func importSubAccountAssignment(ctx context.Context, meta interface{}, subAccountId, serviceName,
+	planName string) (*btpentitlements.AssignedServicePlan, *btpentitlements.AssignedServicePlanSubAccount, error) {
+	btpEntitlementsV1Client := meta.(*SAPClient).btpEntitlementsV1Client
+
+	input := &btpentitlements.GetAssignmentsInput{
+		SubAccountGuid: subAccountId,
+	}
+	output, err := btpEntitlementsV1Client.GetAssignments(ctx, input)
+	if err != nil {
+		if output != nil && output.Error != nil {
+			return nil, nil, fmt.Errorf("BTP Sub Account Entitlements can't be imported; Operation code %v; %s",
+				output.StatusCode, sap.StringValue(output.Error.Message))
+		}
+		return nil, nil, fmt.Errorf("BTP Sub Account Entitlements can't be imported;  %v", err)
+	}
+
+	plan, info := findSubAccountAssignment(output.AssignedServices, serviceName, planName, subAccountId)
+	if info == nil {
+		return nil, nil, fmt.Errorf("BTP Sub Account %s isn't entitled to %s/%s", subAccountId, serviceName, planName)
+	}
+	return plan, info, nil
+}
+
+func findSubAccountAssignment(services []btpentitlements.AssignedService, serviceName, planName,
+	subAccountId string) (*btpentitlements.AssignedServicePlan, *btpentitlements.AssignedServicePlanSubAccount) {
+
+	for sIdx := range services {
+		if services[sIdx].Name != serviceName {
+			continue
+		}
+		plans := services[sIdx].ServicePlans
+		for pIdx := range plans {
+			if plans[pIdx].Name != planName {
+				continue
+			}
+			infos := plans[pIdx].AssignmentInfo
+			for iIdx := range infos {
+				if infos[iIdx].EntityId == subAccountId {
+					return &plans[pIdx], &infos[iIdx]
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
 func buildEntitlementsSubAccountServicePlan(data interface{}) []btpentitlements.SubAccountServicePlan {
 	if data == nil {
 		return nil
@@ -253,6 +441,11 @@ func buildEntitlementsAssignments(data interface{}) []btpentitlements.Assignment
 		if val, ok := m["sub_account_id"]; ok && val != nil {
 			elem.SubAccountGuid = val.(string)
 		}
+		if val, ok := m["enable"]; ok && val != nil && val.(bool) {
+			// Amount and enable are mutually exclusive for the entitlements service
+			elem.Enable = sap.Bool(true)
+			elem.Amount = nil
+		}
 
 		if val, ok := m["resource"]; ok && val != nil {
 			elem.Resources = buildEntitlementsResources(val)
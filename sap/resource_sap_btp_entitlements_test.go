@@ -0,0 +1,300 @@
+package sap
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/nnicora/sap-sdk-go/service/btpentitlements"
+	"strings"
+	"testing"
+)
+
+func TestAccSapBtpEntitlements_basic(t *testing.T) {
+	btp := newMockBtp(t)
+	resourceName := "sap_btp_entitlements.test"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		CheckDestroy:      testAccCheckDestroyed(btp, "sap_btp_sub_account", btp.subAccounts),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpEntitlementsConfig(2),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "service.0.assignment.0.amount", "2"),
+					testAccCheckSubAccountAssignment(btp, "xsuaa", "application", "amount", 2),
+				),
+			},
+			{
+				Config: testAccSapBtpEntitlementsConfig(5),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "service.0.assignment.0.amount", "5"),
+					testAccCheckSubAccountAssignment(btp, "xsuaa", "application", "amount", 5),
+				),
+			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateIdFunc: testAccImportStateIdFunc("sap_btp_sub_account.test", "/xsuaa/application"),
+				ImportStateCheck: testAccCheckImportedAttributes(map[string]string{
+					"service.0.name":                "xsuaa",
+					"service.0.plan_name":           "application",
+					"service.0.assignment.0.amount": "5",
+					"service.0.assignment.0.enable": "false",
+				}),
+			},
+		},
+	})
+}
+
+// testAccCheckSubAccountAssignment verifies the fake holds an entitlement of sap_btp_sub_account.test to the plan.
+func testAccCheckSubAccountAssignment(btp *mockBtp, serviceName, planName string,
+	key string, value interface{}) resource.TestCheckFunc {
+
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources["sap_btp_sub_account.test"]
+		if !ok {
+			return fmt.Errorf("sap_btp_sub_account.test not found in state")
+		}
+		assignment := btp.assignment(rs.Primary.ID, serviceName, planName)
+		if assignment == nil {
+			return fmt.Errorf("sub account %s isn't entitled to %s/%s", rs.Primary.ID, serviceName, planName)
+		}
+		if fmt.Sprint(assignment[key]) != fmt.Sprint(value) {
+			return fmt.Errorf("sub account %s entitlement to %s/%s has %s %v, expected %v",
+				rs.Primary.ID, serviceName, planName, key, assignment[key], value)
+		}
+		return nil
+	}
+}
+
+func testAccSapBtpEntitlementsConfig(amount int) string {
+	return testAccSapBtpSubAccountConfig("Test Sub Account") + fmt.Sprintf(`
+resource "sap_btp_entitlements" "test" {
+  service {
+    name      = "xsuaa"
+    plan_name = "application"
+
+    assignment {
+      sub_account_id = sap_btp_sub_account.test.id
+      amount         = %d
+    }
+  }
+}
+`, amount)
+}
+
+func newFakeSubAccountEntitlements() *fakeEntitlements {
+	return &fakeEntitlements{
+		assignedServices: map[string][]btpentitlements.AssignedService{
+			"sub-account-1": {
+				{
+					Name: "xsuaa",
+					ServicePlans: []btpentitlements.AssignedServicePlan{
+						{
+							Name: "application",
+							AssignmentInfo: []btpentitlements.AssignedServicePlanSubAccount{
+								{EntityId: "sub-account-1", EntityType: "SUBACCOUNT", Amount: 4},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func testSapBtpEntitlementFixedAssignmentsData(t *testing.T, services ...interface{}) *schema.ResourceData {
+	d := schema.TestResourceDataRaw(t, resourceSapBtpEntitlements().Schema, map[string]interface{}{
+		"service": services,
+	})
+	d.SetId("entitlements-0")
+	return d
+}
+
+func testSapBtpEntitlementFixedAssignmentsService(serviceName, planName, subAccountId string,
+	amount int) map[string]interface{} {
+
+	return map[string]interface{}{
+		"name":      serviceName,
+		"plan_name": planName,
+		"assignment": []interface{}{
+			map[string]interface{}{"sub_account_id": subAccountId, "amount": amount},
+		},
+	}
+}
+
+func TestSapBtpEntitlementFixedAssignmentsRead(t *testing.T) {
+	entitlements := newFakeSubAccountEntitlements()
+	entitlements.assignedServices["sub-account-1"][0].ServicePlans = append(
+		entitlements.assignedServices["sub-account-1"][0].ServicePlans, btpentitlements.AssignedServicePlan{
+			Name:      "broker",
+			Unlimited: true,
+			AssignmentInfo: []btpentitlements.AssignedServicePlanSubAccount{
+				{EntityId: "sub-account-1", EntityType: "SUBACCOUNT", Amount: 2},
+			},
+		})
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: entitlements})
+
+	d := testSapBtpEntitlementFixedAssignmentsData(t,
+		testSapBtpEntitlementFixedAssignmentsService("xsuaa", "application", "sub-account-1", 1),
+		testSapBtpEntitlementFixedAssignmentsService("xsuaa", "broker", "sub-account-1", 1))
+	if diags := resourceSapBtpEntitlementFixedAssignmentsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	// The broker plan is unlimited on the global account, yet assigned with a numeric quota
+	expected := map[string]interface{}{
+		"service.#":                     2,
+		"service.0.assignment.0.amount": 4,
+		"service.0.assignment.0.enable": false,
+		"service.1.assignment.0.amount": 2,
+		"service.1.assignment.0.enable": false,
+	}
+	for key, value := range expected {
+		if got := d.Get(key); got != value {
+			t.Errorf("%s is %v, expected %v", key, got, value)
+		}
+	}
+}
+
+func TestSapBtpEntitlementFixedAssignmentsRead_unlimited(t *testing.T) {
+	entitlements := newFakeSubAccountEntitlements()
+	info := &entitlements.assignedServices["sub-account-1"][0].ServicePlans[0].AssignmentInfo[0]
+	info.UnlimitedAmountAssigned = true
+	info.Amount = 0
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: entitlements})
+
+	d := testSapBtpEntitlementFixedAssignmentsData(t,
+		testSapBtpEntitlementFixedAssignmentsService("xsuaa", "application", "sub-account-1", 3))
+	if diags := resourceSapBtpEntitlementFixedAssignmentsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	if got := d.Get("service.0.assignment.0.enable"); got != true {
+		t.Errorf("enable is %v, expected true", got)
+	}
+	if got := d.Get("service.0.assignment.0.amount"); got != 3 {
+		t.Errorf("amount is %v, expected the configured 3", got)
+	}
+}
+
+func TestSapBtpEntitlementFixedAssignmentsRead_partiallyGone(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: newFakeSubAccountEntitlements()})
+
+	d := testSapBtpEntitlementFixedAssignmentsData(t,
+		testSapBtpEntitlementFixedAssignmentsService("xsuaa", "application", "sub-account-1", 4),
+		testSapBtpEntitlementFixedAssignmentsService("xsuaa", "broker", "sub-account-1", 1),
+		testSapBtpEntitlementFixedAssignmentsService("xsuaa", "application", "sub-account-gone", 1))
+	if diags := resourceSapBtpEntitlementFixedAssignmentsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	if d.Id() == "" {
+		t.Fatalf("entitlements were removed from state, while one assignment is still there")
+	}
+	if got := d.Get("service.#"); got != 1 {
+		t.Fatalf("service.# is %v, expected the missing assignments to be dropped", got)
+	}
+	if got := d.Get("service.0.plan_name"); got != "application" {
+		t.Errorf("service.0.plan_name is %v, expected application", got)
+	}
+}
+
+func TestSapBtpEntitlementFixedAssignmentsRead_gone(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: newFakeSubAccountEntitlements()})
+
+	d := testSapBtpEntitlementFixedAssignmentsData(t,
+		testSapBtpEntitlementFixedAssignmentsService("xsuaa", "broker", "sub-account-1", 1))
+	if diags := resourceSapBtpEntitlementFixedAssignmentsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "" {
+		t.Errorf("entitlements weren't removed from state, while none of their assignments is left")
+	}
+}
+
+func TestSapBtpEntitlementFixedAssignmentsImport(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: newFakeSubAccountEntitlements()})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpEntitlements().Schema, map[string]interface{}{})
+	d.SetId("sub-account-1/xsuaa/application")
+	if _, err := resourceSapBtpEntitlementFixedAssignmentsImport(context.Background(), d, meta); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"service.0.name":                        "xsuaa",
+		"service.0.plan_name":                   "application",
+		"service.0.assignment.0.sub_account_id": "sub-account-1",
+		"service.0.assignment.0.amount":         4,
+		"service.0.assignment.0.enable":         false,
+	}
+	for key, value := range expected {
+		if got := d.Get(key); got != value {
+			t.Errorf("%s is %v, expected %v", key, got, value)
+		}
+	}
+}
+
+func TestSapBtpEntitlementFixedAssignmentsImport_unlimited(t *testing.T) {
+	entitlements := newFakeSubAccountEntitlements()
+	info := &entitlements.assignedServices["sub-account-1"][0].ServicePlans[0].AssignmentInfo[0]
+	info.Amount = 0
+	info.UnlimitedAmountAssigned = true
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: entitlements})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpEntitlements().Schema, map[string]interface{}{})
+	d.SetId("sub-account-1/xsuaa/application")
+	if _, err := resourceSapBtpEntitlementFixedAssignmentsImport(context.Background(), d, meta); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The amount must pass the validation of the schema, even though it's meaningless once enabled
+	expected := map[string]interface{}{
+		"service.0.assignment.0.amount": 1,
+		"service.0.assignment.0.enable": true,
+	}
+	for key, value := range expected {
+		if got := d.Get(key); got != value {
+			t.Errorf("%s is %v, expected %v", key, got, value)
+		}
+	}
+}
+
+func TestSapBtpEntitlementFixedAssignmentsImport_noQuota(t *testing.T) {
+	entitlements := newFakeSubAccountEntitlements()
+	entitlements.assignedServices["sub-account-1"][0].ServicePlans[0].AssignmentInfo[0].Amount = 0
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: entitlements})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpEntitlements().Schema, map[string]interface{}{})
+	d.SetId("sub-account-1/xsuaa/application")
+	_, err := resourceSapBtpEntitlementFixedAssignmentsImport(context.Background(), d, meta)
+	if err == nil || !strings.Contains(err.Error(), "has no quota of xsuaa/application") {
+		t.Fatalf("expected an error for an assignment without quota, got %v", err)
+	}
+}
+
+func TestSapBtpEntitlementFixedAssignmentsImport_notEntitled(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: newFakeSubAccountEntitlements()})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpEntitlements().Schema, map[string]interface{}{})
+	d.SetId("sub-account-1/xsuaa/broker")
+	_, err := resourceSapBtpEntitlementFixedAssignmentsImport(context.Background(), d, meta)
+	if err == nil || !strings.Contains(err.Error(), "isn't entitled to xsuaa/broker") {
+		t.Fatalf("expected an error for a plan the sub account isn't entitled to, got %v", err)
+	}
+}
+
+func TestSapBtpEntitlementFixedAssignmentsImport_invalidId(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{entitlementsClient: newFakeSubAccountEntitlements()})
+
+	for _, id := range []string{"sub-account-1", "sub-account-1/xsuaa", "sub-account-1//application"} {
+		d := schema.TestResourceDataRaw(t, resourceSapBtpEntitlements().Schema, map[string]interface{}{})
+		d.SetId(id)
+		if _, err := resourceSapBtpEntitlementFixedAssignmentsImport(context.Background(), d, meta); err == nil {
+			t.Errorf("expected an error for import id %q", id)
+		}
+	}
+}
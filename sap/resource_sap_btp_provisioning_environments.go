@@ -2,12 +2,16 @@ package sap
 
 import (
 	"context"
+	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/nnicora/sap-sdk-go/sap"
 	"github.com/nnicora/sap-sdk-go/service/btpprovisioning"
 	"github.com/pkg/errors"
+	"log"
+	"strings"
 	"time"
 )
 
@@ -15,97 +19,24 @@ func resourceSapBtpProvisioningEnvironments() *schema.Resource {
 	return &schema.Resource{
 		CreateContext: resourceSapBtpProvisioningEnvironmentsCreate,
 		ReadContext:   resourceSapBtpProvisioningEnvironmentsRead,
-		//UpdateContext: resourceSapBtpProvisioningEnvironmentsUpdate,
-		UpdateContext: resourceSapBtpProvisioningEnvironmentsRead,
+		UpdateContext: resourceSapBtpProvisioningEnvironmentsUpdate,
 		DeleteContext: resourceSapBtpProvisioningEnvironmentsDelete,
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(3 * time.Minute),
+			Update: schema.DefaultTimeout(3 * time.Minute),
 			Delete: schema.DefaultTimeout(3 * time.Minute),
 		},
 		Schema: map[string]*schema.Schema{
-			"provisioning_service": {
-				Type:     schema.TypeList,
-				Required: true,
-				MaxItems: 1,
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-
-						"host": {
-							Type:     schema.TypeString,
-							Required: true,
-						},
-						"oauth2": {
-							Type:     schema.TypeList,
-							Required: true,
-							MaxItems: 1,
-							Elem: &schema.Resource{
-								Schema: map[string]*schema.Schema{
-									"grant_type": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "client_credentials",
-										Description: "SAP OAuth2 Grant Type.",
-									},
-									"client_id": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Client Id.",
-									},
-									"client_secret": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Client Secret.",
-									},
-									"token_url": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Token Url.",
-									},
-									"authorization_url": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Authorization Url.",
-									},
-									"redirect_url": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Redirect Url.",
-									},
-
-									"username": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Username. Used in case if 'grant_type=password'.",
-									},
-									"password": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Password. Used in case if 'grant_type=password'.",
-									},
-
-									"timeout_seconds": {
-										Type:        schema.TypeInt,
-										Optional:    true,
-										Default:     60,
-										Description: "SAP OAuth2 HTTP Client timeout.",
-									},
-								},
-							},
-						},
-					},
-				},
-			},
+			"endpoint_id":          endpointIdSchema("provisioning_service"),
+			"provisioning_service": endpointSchema("provisioning_service"),
 
 			"environment_type": {
 				Type:         schema.TypeString,
 				Required:     true,
+				ForceNew:     true,
 				ValidateFunc: validation.StringIsNotWhiteSpace,
 			},
 			"plan_name": {
@@ -116,32 +47,39 @@ func resourceSapBtpProvisioningEnvironments() *schema.Resource {
 			"technical_key": {
 				Type:         schema.TypeString,
 				Optional:     true,
+				ForceNew:     true,
 				ValidateFunc: validation.StringIsNotWhiteSpace,
 			},
 
 			"description": {
 				Type:     schema.TypeString,
 				Optional: true,
+				ForceNew: true,
 			},
 			"landscape_label": {
 				Type:     schema.TypeString,
 				Optional: true,
+				ForceNew: true,
 			},
 			"name": {
 				Type:     schema.TypeString,
 				Optional: true,
+				ForceNew: true,
 			},
 			"origin": {
 				Type:     schema.TypeString,
 				Optional: true,
+				ForceNew: true,
 			},
 			"service_name": {
 				Type:     schema.TypeString,
 				Optional: true,
+				ForceNew: true,
 			},
 			"user": {
 				Type:     schema.TypeString,
 				Optional: true,
+				ForceNew: true,
 			},
 			"parameters": {
 				Type:     schema.TypeMap,
@@ -240,20 +178,12 @@ func resourceSapBtpProvisioningEnvironments() *schema.Resource {
 func resourceSapBtpProvisioningEnvironmentsCreate(ctx context.Context,
 	d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	//btpProvisioningV1Client := meta.(*SAPClient).btpProvisioningV1Client
-	session := meta.(*SAPClient).session
-
-	serviceList := d.Get("provisioning_service").([]interface{})
-	if len(serviceList) < 1 {
-		return diag.Errorf("SaaS manager service is required")
-	}
 
-	err := session.AddEndpointWithReplace(btpprovisioning.EndpointsID, extractEndpointConfig(serviceList))
+	btpProvisioningV1Client, err := meta.(*SAPClient).provisioningV1Client(d, "provisioning_service")
 	if err != nil {
 		return diag.FromErr(errors.Errorf("BTP Provisioning Service OAuth2;  %v", err))
 	}
 
-	btpProvisioningV1Client := btpprovisioning.New(session)
-
 	input := &btpprovisioning.CreateEnvironmentInstanceInput{
 		EnvironmentType: d.Get("environment_type").(string),
 		PlanName:        d.Get("plan_name").(string),
@@ -294,29 +224,32 @@ func resourceSapBtpProvisioningEnvironmentsCreate(ctx context.Context,
 		d.SetId(output.Id)
 	}
 
-	return nil
+	if err := waitForEnvironmentInstance(ctx, btpProvisioningV1Client, d.Id(), []string{"CREATING"},
+		[]string{"OK"}, d.Timeout(schema.TimeoutCreate)); err != nil {
+		return diag.Errorf("BTP Provisioning Environment can't be created; %v", err)
+	}
+
+	return resourceSapBtpProvisioningEnvironmentsRead(ctx, d, meta)
 }
 
 func resourceSapBtpProvisioningEnvironmentsRead(ctx context.Context,
 	d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	//btpProvisioningV1Client := meta.(*SAPClient).btpProvisioningV1Client
-	session := meta.(*SAPClient).session
-	serviceList := d.Get("provisioning_service").([]interface{})
-	if len(serviceList) < 1 {
-		return diag.Errorf("Provisioning service is required")
-	}
-
-	err := session.AddEndpointWithReplace(btpprovisioning.EndpointsID, extractEndpointConfig(serviceList))
+	btpProvisioningV1Client, err := meta.(*SAPClient).provisioningV1Client(d, "provisioning_service")
 	if err != nil {
 		return diag.FromErr(errors.Errorf("BTP Provisioning Service OAuth2;  %v", err))
 	}
-	btpProvisioningV1Client := btpprovisioning.New(session)
 
 	input := &btpprovisioning.GetEnvironmentInstanceInput{
 		EnvironmentInstanceId: d.Id(),
 	}
 
 	if output, err := btpProvisioningV1Client.GetEnvironmentInstance(ctx, input); err != nil {
+		if output != nil && isNotFound(output.StatusAndBodyFromResponse, output.Error) {
+			log.Printf("[WARN] BTP Provisioning Environment %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
 		if output != nil && output.Error != nil {
 			return diag.Errorf("BTP Provisioning Environment can't be read; Operation code %v; %s",
 				output.StatusCode, sap.StringValue(output.Error.Message))
@@ -357,85 +290,60 @@ func resourceSapBtpProvisioningEnvironmentsRead(ctx context.Context,
 
 func resourceSapBtpProvisioningEnvironmentsUpdate(ctx context.Context,
 	d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	//btpProvisioningV1Client := meta.(*SAPClient).btpProvisioningV1Client
-	//session := meta.(*SAPClient).session
-	//serviceList := d.Get("provisioning_service").([]interface{})
-	//if len(serviceList) < 1 {
-	//	return diag.Errorf("Provisioning service is required")
-	//}
-	//
-	//	err := session.AddEndpointWithReplace(btpprovisioning.EndpointsID, extractEndpointConfig(serviceList))
-	//	if err != nil {
-	//		return diag.FromErr(errors.Errorf("BTP Provisioning Service OAuth2;  %v", err))
-	//	}
-	//btpProvisioningV1Client := btpprovisioning.New(session)
-	//
-	//input := &btpprovisioning.UpdateEnvironmentInstanceInput{
-	//	EnvironmentInstanceId: d.Id(),
-	//	PlanName:              d.Get("plan_name").(string),
-	//}
-	//
-	//if val, ok := d.GetOk("parameters"); ok {
-	//	if m, isMap := val.(map[string]interface{}); isMap {
-	//		input.Parameters = m
-	//	}
-	//}
-	//if output, err := btpProvisioningV1Client.UpdateEnvironmentInstance(ctx, input); err != nil {
-	//	if output != nil && output.Error != nil {
-	//		return diag.Errorf("BTP Provisioning Environment can't be updated; Operation code %v; %s",
-	//			output.StatusCode, sap.StringValue(output.Error.Message))
-	//	} else {
-	//		return diag.Errorf("BTP Provisioning Environment can't be updated;  %v", err)
-	//	}
-	//}
-	//else {
-	//	logDebug(output, "Directory Entitlements Output")
-	//	if len(sap.StringValue(output.JobStatusId)) > 0 {
-	//		jobInput := &btpentitlements.GetJobStatusInput{
-	//			JobId: sap.StringValue(output.JobStatusId),
-	//		}
-	//		logDebug(jobInput, "Directory Entitlements GetJobStatus")
-	//		retryErr := resource.RetryContext(ctx, 2*time.Minute, func() *resource.RetryError {
-	//			if jobOut, err := btpEntitlementsV1Client.GetJobStatus(ctx, jobInput); err != nil {
-	//				return resource.RetryableError(err)
-	//			} else {
-	//				// IN_PROGRESS, COMPLETED, FAILED
-	//				if jobOut.Status == "IN_PROGRESS" {
-	//					return resource.RetryableError(
-	//						fmt.Errorf("BTP Directory Entitlements in progress; %s", jobOut.Description))
-	//				} else if jobOut.Status == "FAILED" {
-	//					return resource.NonRetryableError(
-	//						fmt.Errorf("BTP Directory Entitlements failed; %s", jobOut.Description))
-	//				} else {
-	//					return nil
-	//				}
-	//			}
-	//		})
-	//
-	//		if retryErr != nil && isResourceTimeoutError(retryErr) {
-	//			return diag.FromErr(retryErr)
-	//		}
-	//	}
-	//}
-	return nil
+	btpProvisioningV1Client, err := meta.(*SAPClient).provisioningV1Client(d, "provisioning_service")
+	if err != nil {
+		return diag.FromErr(errors.Errorf("BTP Provisioning Service OAuth2;  %v", err))
+	}
+
+	if d.HasChanges("plan_name", "parameters") {
+		// The environment instance as before the update, to tell once it took the update up
+		before, err := btpProvisioningV1Client.GetEnvironmentInstance(ctx, &btpprovisioning.GetEnvironmentInstanceInput{
+			EnvironmentInstanceId: d.Id(),
+		})
+		if err != nil {
+			if before != nil && before.Error != nil {
+				return diag.Errorf("BTP Provisioning Environment can't be updated; Operation code %v; %s",
+					before.StatusCode, sap.StringValue(before.Error.Message))
+			}
+			return diag.Errorf("BTP Provisioning Environment can't be updated;  %v", err)
+		}
+
+		input := &btpprovisioning.UpdateEnvironmentInstanceInput{
+			EnvironmentInstanceId: d.Id(),
+			PlanName:              d.Get("plan_name").(string),
+		}
+		if val, ok := d.GetOk("parameters"); ok {
+			if m, isMap := val.(map[string]interface{}); isMap {
+				input.Parameters = m
+			}
+		}
+
+		if output, err := btpProvisioningV1Client.UpdateEnvironmentInstance(ctx, input); err != nil {
+			if output != nil && output.Error != nil {
+				return diag.Errorf("BTP Provisioning Environment can't be updated; Operation code %v; %s",
+					output.StatusCode, sap.StringValue(output.Error.Message))
+			} else {
+				return diag.Errorf("BTP Provisioning Environment can't be updated;  %v", err)
+			}
+		}
+
+		if err := waitForEnvironmentInstanceUpdate(ctx, btpProvisioningV1Client, d.Id(), &before.EnvironmentInstance,
+			input.PlanName, d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return diag.Errorf("BTP Provisioning Environment can't be updated; %v", err)
+		}
+	}
+
+	return resourceSapBtpProvisioningEnvironmentsRead(ctx, d, meta)
 }
 
 func resourceSapBtpProvisioningEnvironmentsDelete(ctx context.Context,
 	d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	//btpProvisioningV1Client := meta.(*SAPClient).btpProvisioningV1Client
-	session := meta.(*SAPClient).session
-	serviceList := d.Get("provisioning_service").([]interface{})
-	if len(serviceList) < 1 {
-		return diag.Errorf("Provisioning service is required")
-	}
-
-	err := session.AddEndpointWithReplace(btpprovisioning.EndpointsID, extractEndpointConfig(serviceList))
+	btpProvisioningV1Client, err := meta.(*SAPClient).provisioningV1Client(d, "provisioning_service")
 	if err != nil {
 		return diag.FromErr(errors.Errorf("BTP Provisioning Service OAuth2;  %v", err))
 	}
 
-	btpProvisioningV1Client := btpprovisioning.New(session)
-
 	input := &btpprovisioning.DeleteEnvironmentInstanceInput{
 		EnvironmentInstanceId: d.Id(),
 	}
@@ -447,5 +355,110 @@ func resourceSapBtpProvisioningEnvironmentsDelete(ctx context.Context,
 			return diag.Errorf("BTP Provisioning Environment can't be deleted;  %v", err)
 		}
 	}
+
+	// Once deleted, the environment instance isn't found anymore
+	if err := waitForEnvironmentInstance(ctx, btpProvisioningV1Client, d.Id(), []string{"DELETING", "OK"},
+		[]string{}, d.Timeout(schema.TimeoutDelete)); err != nil {
+		return diag.Errorf("BTP Provisioning Environment can't be deleted; %v", err)
+	}
 	return nil
 }
+
+// waitForEnvironmentInstance polls the environment instance until its state leaves the pending ones; a state
+// ending in _FAILED (CREATION_FAILED, UPDATE_FAILED, DELETION_FAILED) stops the waiting, having the state message
+// as error. Without target states, the waiting ends once the environment instance isn't found anymore.
+func waitForEnvironmentInstance(ctx context.Context, client provisioningClient, id string,
+	pending, target []string, timeout time.Duration) error {
+
+	return waitForEnvironmentInstanceState(ctx, environmentInstanceStateRefresh(ctx, client, id), pending, target,
+		timeout)
+}
+
+// waitForEnvironmentInstanceUpdate waits for an update of the environment instance, as it was before, to finish.
+// Right after the update is requested, the environment instance may still answer OK as before; it has taken the
+// update up once seen UPDATING, modified later or with another operation, see environmentInstanceUpdateStarted.
+// The update is done once the environment instance is OK again, having the requested plan.
+func waitForEnvironmentInstanceUpdate(ctx context.Context, client provisioningClient, id string,
+	before *btpprovisioning.EnvironmentInstance, planName string, timeout time.Duration) error {
+
+	started := false
+	return resource.RetryContext(ctx, timeout, func() *resource.RetryError {
+		output, err := client.GetEnvironmentInstance(ctx, &btpprovisioning.GetEnvironmentInstanceInput{
+			EnvironmentInstanceId: id,
+		})
+		if err != nil {
+			if output != nil && isNotFound(output.StatusAndBodyFromResponse, output.Error) {
+				return resource.NonRetryableError(fmt.Errorf("environment instance %s not found", id))
+			}
+			if output != nil && output.Error != nil {
+				return resource.NonRetryableError(fmt.Errorf("Operation code %v; %s",
+					output.StatusCode, sap.StringValue(output.Error.Message)))
+			}
+			return resource.NonRetryableError(err)
+		}
+
+		environment := &output.EnvironmentInstance
+		started = started || environmentInstanceUpdateStarted(before, environment)
+		switch {
+		case !started:
+			return resource.RetryableError(fmt.Errorf("update not taken up yet, state %s", environment.State))
+		case strings.HasSuffix(environment.State, "_FAILED"):
+			return resource.NonRetryableError(
+				fmt.Errorf("state %s; %s", environment.State, environment.StateMessage))
+		case environment.State != "OK":
+			return resource.RetryableError(fmt.Errorf("state %s", environment.State))
+		case planName != "" && environment.PlanName != planName:
+			return resource.RetryableError(fmt.Errorf("plan %s, expected %s", environment.PlanName, planName))
+		}
+		return nil
+	})
+}
+
+// environmentInstanceUpdateStarted tells whether the environment instance has taken up an update requested while
+// it was as before; a state left over from an earlier update, such as UPDATE_FAILED, doesn't count.
+func environmentInstanceUpdateStarted(before, current *btpprovisioning.EnvironmentInstance) bool {
+	return current.State == "UPDATING" ||
+		current.ModifiedDate > before.ModifiedDate ||
+		current.Operation != before.Operation
+}
+
+func waitForEnvironmentInstanceState(ctx context.Context, refresh resource.StateRefreshFunc,
+	pending, target []string, timeout time.Duration) error {
+
+	stateConf := &resource.StateChangeConf{
+		Pending:    pending,
+		Target:     target,
+		Refresh:    refresh,
+		Timeout:    timeout,
+		Delay:      5 * time.Second,
+		MinTimeout: 5 * time.Second,
+	}
+	_, err := stateConf.WaitForStateContext(ctx)
+	return err
+}
+
+func environmentInstanceStateRefresh(ctx context.Context, client provisioningClient,
+	id string) resource.StateRefreshFunc {
+
+	return func() (interface{}, string, error) {
+		input := &btpprovisioning.GetEnvironmentInstanceInput{
+			EnvironmentInstanceId: id,
+		}
+		output, err := client.GetEnvironmentInstance(ctx, input)
+		if err != nil {
+			if output != nil && isNotFound(output.StatusAndBodyFromResponse, output.Error) {
+				return nil, "", nil
+			}
+			if output != nil && output.Error != nil {
+				return nil, "", fmt.Errorf("Operation code %v; %s",
+					output.StatusCode, sap.StringValue(output.Error.Message))
+			}
+			return nil, "", err
+		}
+
+		if strings.HasSuffix(output.State, "_FAILED") {
+			return output, output.State, fmt.Errorf("state %s; %s", output.State, output.StateMessage)
+		}
+		return output, output.State, nil
+	}
+}
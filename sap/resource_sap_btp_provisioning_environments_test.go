@@ -0,0 +1,199 @@
+package sap
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nnicora/sap-sdk-go/service/btpprovisioning"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccSapBtpProvisioningEnvironments_basic(t *testing.T) {
+	btp := newMockBtp(t)
+	resourceName := "sap_btp_provisioning_environments.test"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		CheckDestroy:      testAccCheckDestroyed(btp, "sap_btp_provisioning_environments", btp.environments),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpProvisioningEnvironmentsConfig("standard"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "state", "OK"),
+					resource.TestCheckResourceAttr(resourceName, "plan_name", "standard"),
+					resource.TestCheckResourceAttr(resourceName, "global_account_id", mockBtpGlobalAccountId),
+				),
+			},
+			{
+				Config: testAccSapBtpProvisioningEnvironmentsConfig("trial"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "state", "OK"),
+					resource.TestCheckResourceAttr(resourceName, "operation", "update"),
+					testAccCheckMockObject(btp, resourceName, btp.environments, "planName", "trial"),
+				),
+			},
+		},
+	})
+}
+
+func testAccSapBtpProvisioningEnvironmentsConfig(planName string) string {
+	return testAccProviderConfig + fmt.Sprintf(`
+resource "sap_btp_provisioning_environments" "test" {
+  endpoint_id      = "provisioning"
+  environment_type = "cloudfoundry"
+  service_name     = "cloudfoundry"
+  plan_name        = %q
+  name             = "test-environment"
+}
+`, planName)
+}
+
+func TestSapBtpProvisioningEnvironmentsRead(t *testing.T) {
+	provisioning := &fakeProvisioning{
+		environments: map[string]btpprovisioning.EnvironmentInstance{
+			"environment-1": {
+				Id:              "environment-1",
+				EnvironmentType: "cloudfoundry",
+				PlanName:        "standard",
+				State:           "OK",
+			},
+		},
+	}
+	meta := newFakeSAPClient(&fakeClientFactory{provisioningClient: provisioning})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpProvisioningEnvironments().Schema, map[string]interface{}{
+		"endpoint_id": fakeEndpointId,
+	})
+	d.SetId("environment-1")
+	if diags := resourceSapBtpProvisioningEnvironmentsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if got := d.Get("plan_name").(string); got != "standard" {
+		t.Errorf("plan_name is %q, expected %q", got, "standard")
+	}
+	if got := d.Get("state").(string); got != "OK" {
+		t.Errorf("state is %q, expected %q", got, "OK")
+	}
+}
+
+func TestSapBtpProvisioningEnvironmentsRead_notFound(t *testing.T) {
+	provisioning := &fakeProvisioning{environments: map[string]btpprovisioning.EnvironmentInstance{}}
+	meta := newFakeSAPClient(&fakeClientFactory{provisioningClient: provisioning})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpProvisioningEnvironments().Schema, map[string]interface{}{
+		"endpoint_id": fakeEndpointId,
+	})
+	d.SetId("environment-1")
+	if diags := resourceSapBtpProvisioningEnvironmentsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "" {
+		t.Errorf("environment %s is still in state", d.Id())
+	}
+}
+
+func TestSapBtpProvisioningEnvironmentsRead_error(t *testing.T) {
+	provisioning := &fakeProvisioning{failStatus: 403, failMessage: "Access denied"}
+	meta := newFakeSAPClient(&fakeClientFactory{provisioningClient: provisioning})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpProvisioningEnvironments().Schema, map[string]interface{}{
+		"endpoint_id": fakeEndpointId,
+	})
+	d.SetId("environment-1")
+	diags := resourceSapBtpProvisioningEnvironmentsRead(context.Background(), d, meta)
+	if !diags.HasError() {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(diags[0].Summary, "Operation code 403; Access denied") {
+		t.Errorf("error %q doesn't hold the API answer", diags[0].Summary)
+	}
+}
+
+func TestSapBtpProvisioningEnvironmentsRead_noEndpoint(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{provisioningClient: &fakeProvisioning{}})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpProvisioningEnvironments().Schema, map[string]interface{}{})
+	d.SetId("environment-1")
+	if diags := resourceSapBtpProvisioningEnvironmentsRead(context.Background(), d, meta); !diags.HasError() {
+		t.Fatalf("expected an error for a resource referring to no endpoint")
+	}
+}
+
+func TestWaitForEnvironmentInstanceUpdate(t *testing.T) {
+	before := btpprovisioning.EnvironmentInstance{
+		State: "OK", PlanName: "standard", Operation: "provision", ModifiedDate: 1000,
+	}
+	updated := func(state, planName string) btpprovisioning.EnvironmentInstance {
+		return btpprovisioning.EnvironmentInstance{
+			State: state, PlanName: planName, Operation: "update", ModifiedDate: 2000,
+		}
+	}
+	cases := []struct {
+		name    string
+		polls   []btpprovisioning.EnvironmentInstance
+		failure string
+	}{
+		{
+			name:  "OK before the update is taken up",
+			polls: []btpprovisioning.EnvironmentInstance{before, before, updated("UPDATING", "free"), updated("OK", "free")},
+		},
+		{
+			name:  "applied without being seen UPDATING",
+			polls: []btpprovisioning.EnvironmentInstance{updated("OK", "free")},
+		},
+		{
+			name:  "modified before the plan shows",
+			polls: []btpprovisioning.EnvironmentInstance{updated("OK", "standard"), updated("OK", "free")},
+		},
+		{
+			name: "failed",
+			polls: []btpprovisioning.EnvironmentInstance{before, updated("UPDATING", "free"),
+				{State: "UPDATE_FAILED", StateMessage: "quota exceeded", Operation: "update", ModifiedDate: 3000}},
+			failure: "quota exceeded",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := &fakeProvisioning{
+				polls: map[string][]btpprovisioning.EnvironmentInstance{"environment-1": c.polls},
+			}
+			err := waitForEnvironmentInstanceUpdate(context.Background(), client, "environment-1", &before, "free",
+				time.Minute)
+			if c.failure == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if c.failure != "" && (err == nil || !strings.Contains(err.Error(), c.failure)) {
+				t.Fatalf("error is %v, expected one about %q", err, c.failure)
+			}
+			if remaining := client.polls["environment-1"]; c.failure == "" && len(remaining) != 1 {
+				t.Errorf("update done with %d polls left", len(remaining)-1)
+			}
+		})
+	}
+}
+
+func TestEnvironmentInstanceUpdateStarted(t *testing.T) {
+	before := &btpprovisioning.EnvironmentInstance{State: "UPDATE_FAILED", Operation: "update", ModifiedDate: 1000}
+	if environmentInstanceUpdateStarted(before, before) {
+		t.Errorf("the failure of an earlier update is taken as the update requested")
+	}
+	if !environmentInstanceUpdateStarted(before, &btpprovisioning.EnvironmentInstance{
+		State: "UPDATING", Operation: "update", ModifiedDate: 1000,
+	}) {
+		t.Errorf("UPDATING isn't taken as the update started")
+	}
+}
+
+func TestSapBtpProvisioningEnvironments_forceNew(t *testing.T) {
+	s := resourceSapBtpProvisioningEnvironments().Schema
+	// Updates carry the plan and the parameters only
+	for _, name := range []string{"name", "description", "origin", "user"} {
+		if !s[name].ForceNew {
+			t.Errorf("%s isn't ForceNew, while updates can't change it", name)
+		}
+	}
+}
@@ -9,11 +9,13 @@ import (
 	"github.com/nnicora/sap-sdk-go/sap"
 	"github.com/nnicora/sap-sdk-go/service/btpaccounts"
 	"github.com/pkg/errors"
+	"log"
 	"time"
 )
 
 func resourceSapBtpSubAccount() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
+		SchemaVersion: 1,
 		CreateContext: resourceSapBtpSubAccountCreate,
 		ReadContext:   resourceSapBtpSubAccountRead,
 		UpdateContext: resourceSapBtpSubAccountUpdate,
@@ -61,33 +63,12 @@ func resourceSapBtpSubAccount() *schema.Resource {
 				Optional: true,
 				Default:  "",
 			},
-			"custom_properties": {
-				Type:     schema.TypeList,
-				Optional: true,
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-						"key": {
-							Type:     schema.TypeString,
-							Optional: true,
-							Default:  "",
-						},
-						"value": {
-							Type:     schema.TypeString,
-							Optional: true,
-							Default:  "",
-						},
-						"delete": {
-							Type:     schema.TypeBool,
-							Optional: true,
-							Default:  false,
-						},
-					},
-				},
-			},
+			"custom_properties": customPropertiesSchema(),
 			"sub_account_admins": {
-				Type:     schema.TypeList,
-				Optional: true,
-				Elem:     &schema.Schema{Type: schema.TypeString},
+				Type:       schema.TypeList,
+				Optional:   true,
+				Elem:       &schema.Schema{Type: schema.TypeString},
+				Deprecated: "Applied on creation only; manage the admins through 'sap_btp_sub_account_admins'.",
 			},
 
 			"zone_id": {
@@ -119,7 +100,8 @@ func resourceSapBtpSubAccount() *schema.Resource {
 			"parent_id": {
 				Type:     schema.TypeString,
 				Optional: true,
-				Computed: true,
+				Description: "The directory to create the sub account in, the global account when not set; a change " +
+					"moves the sub account, and removing it moves the sub account back to the global account.",
 			},
 			"state": {
 				Type:     schema.TypeString,
@@ -135,15 +117,43 @@ func resourceSapBtpSubAccount() *schema.Resource {
 			"tags": tagsSchema(),
 		},
 	}
+	r.StateUpgraders = []schema.StateUpgrader{
+		customPropertiesStateUpgraderV0(r, resourceSapBtpSubAccountCustomPropertiesV0()),
+	}
+	return r
+}
+
+// resourceSapBtpSubAccountCustomPropertiesV0 is the schema custom properties had up to version 0
+func resourceSapBtpSubAccountCustomPropertiesV0() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"key": {
+					Type:     schema.TypeString,
+					Optional: true,
+					Default:  "",
+				},
+				"value": {
+					Type:     schema.TypeString,
+					Optional: true,
+					Default:  "",
+				},
+				"delete": {
+					Type:     schema.TypeBool,
+					Optional: true,
+					Default:  false,
+				},
+			},
+		},
+	}
 }
 
 func resourceSapBtpSubAccountCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	btpAccountsClient := meta.(*SAPClient).btpAccountsV1Client
 
-	customProperties := make([]btpaccounts.KeyValue, 0)
-	if v, ok := d.GetOk("custom_properties"); ok {
-		customProperties = expandSapBtpAccountCustomPropertiesParameters(v.([]interface{}))
-	}
+	customProperties := expandCustomProperties(d.Get("custom_properties"))
 
 	subAccountAdmins := make([]string, 0)
 	if v, ok := d.GetOk("sub_account_admins"); ok {
@@ -160,8 +170,12 @@ func resourceSapBtpSubAccountCreate(ctx context.Context, d *schema.ResourceData,
 	displayName := d.Get("display_name")
 	subdomain := d.Get("subdomain")
 	origin := d.Get("origin")
+	parentId := gaId.(string)
+	if val, ok := d.GetOk("parent_id"); ok {
+		parentId = val.(string)
+	}
 	input := &btpaccounts.CreateSubAccountInput{
-		ParentGuid:  gaId.(string),
+		ParentGuid:  parentId,
 		Region:      region.(string),
 		DisplayName: displayName.(string),
 
@@ -206,8 +220,6 @@ func resourceSapBtpSubAccountCreate(ctx context.Context, d *schema.ResourceData,
 					return resource.RetryableError(fmt.Errorf("BTP Sub Account not yet started"))
 				}
 			}
-
-			return nil
 		})
 
 		if retryErr != nil && isResourceTimeoutError(retryErr) {
@@ -231,7 +243,7 @@ func resourceSapBtpSubAccountCreate(ctx context.Context, d *schema.ResourceData,
 		d.Set("created_date", respSubAccount.CreatedDate.Format(time.RFC3339))
 		d.Set("modified_date", respSubAccount.ModifiedDate.Format(time.RFC3339))
 		d.Set("parent_features", respSubAccount.ParentFeatures)
-		d.Set("parent_id", respSubAccount.ParentGuid)
+		d.Set("parent_id", flattenSubAccountParentId(d, respSubAccount.ParentGuid, respSubAccount.GlobalAccountGuid))
 		d.Set("state", respSubAccount.State)
 		d.Set("state_message", respSubAccount.StateMessage)
 		d.Set("description", respSubAccount.Description)
@@ -242,65 +254,22 @@ func resourceSapBtpSubAccountCreate(ctx context.Context, d *schema.ResourceData,
 		d.Set("used_for_production", respSubAccount.UsedForProduction)
 		d.Set("subdomain", respSubAccount.Subdomain)
 
-		cp := make([]map[string]interface{}, 0)
-		{
-			for _, gaCP := range respSubAccount.CustomProperties {
-				m := make(map[string]interface{})
-				m["key"] = gaCP.Key
-				m["value"] = gaCP.Value
-				cp = append(cp, m)
-			}
-		}
-		d.Set("custom_properties", cp)
+		d.Set("custom_properties", flattenCustomProperties(respSubAccount.CustomProperties))
 	}
 	return nil
 }
 
-func expandSapBtpAccountCustomPropertiesParameters(config []interface{}) []btpaccounts.KeyValue {
-	parameters := make([]btpaccounts.KeyValue, 0)
-
-	for _, c := range config {
-		param := c.(map[string]interface{})
-		key := param["key"].(string)
-		value := param["value"].(string)
-
-		parameters = append(parameters, btpaccounts.KeyValue{
-			Key:   key,
-			Value: value,
-		})
-	}
-
-	return parameters
-}
-
-func expandSapBtpAccountUpdateCustomPropertiesParameters(config []interface{}) []btpaccounts.UpdateSubAccountProperties {
-	parameters := make([]btpaccounts.UpdateSubAccountProperties, 0)
-
-	for _, c := range config {
-		param := c.(map[string]interface{})
-		key := param["key"].(string)
-		value := param["value"].(string)
-		deleteFlag := param["delete"].(bool)
-
-		prop := btpaccounts.UpdateSubAccountProperties{
-			KeyValue: btpaccounts.KeyValue{
-				Key:   key,
-				Value: value,
-			},
-			Delete: deleteFlag,
-		}
-		parameters = append(parameters, prop)
-	}
-
-	return parameters
-}
-
 func resourceSapBtpSubAccountRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	btpAccountsClient := meta.(*SAPClient).btpAccountsV1Client
 
 	if output, err := btpAccountsClient.GetSubAccount(ctx, &btpaccounts.GetSubAccountInput{
 		SubAccountGuid: d.Id(),
 	}); err != nil {
+		if output != nil && isNotFound(output.StatusAndBodyFromResponse, output.Error) {
+			log.Printf("[WARN] BTP Sub Account %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
 		if output != nil && output.Error != nil {
 			return diag.FromErr(
 				errors.Errorf("BTP Sub Account can't be read; %s", sap.StringValue(output.Error.Message)))
@@ -313,7 +282,7 @@ func resourceSapBtpSubAccountRead(ctx context.Context, d *schema.ResourceData, m
 		d.Set("created_date", output.CreatedDate.Format(time.RFC3339))
 		d.Set("modified_date", output.ModifiedDate.Format(time.RFC3339))
 		d.Set("parent_features", output.ParentFeatures)
-		d.Set("parent_id", output.ParentGuid)
+		d.Set("parent_id", flattenSubAccountParentId(d, output.ParentGuid, output.GlobalAccountGuid))
 		d.Set("state", output.State)
 		d.Set("state_message", output.StateMessage)
 		d.Set("description", output.Description)
@@ -324,16 +293,7 @@ func resourceSapBtpSubAccountRead(ctx context.Context, d *schema.ResourceData, m
 		d.Set("used_for_production", output.UsedForProduction)
 		d.Set("subdomain", output.Subdomain)
 
-		cp := make([]map[string]interface{}, 0)
-		{
-			for _, gaCP := range output.CustomProperties {
-				m := make(map[string]interface{})
-				m["key"] = gaCP.Key
-				m["value"] = gaCP.Value
-				cp = append(cp, m)
-			}
-		}
-		d.Set("custom_properties", cp)
+		d.Set("custom_properties", flattenCustomProperties(output.CustomProperties))
 	}
 	return nil
 }
@@ -341,11 +301,14 @@ func resourceSapBtpSubAccountRead(ctx context.Context, d *schema.ResourceData, m
 func resourceSapBtpSubAccountUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	btpAccountsClient := meta.(*SAPClient).btpAccountsV1Client
 
-	customProperties := make([]btpaccounts.UpdateSubAccountProperties, 0)
-	if v, ok := d.GetOk("custom_properties"); ok {
-		customProperties = expandSapBtpAccountUpdateCustomPropertiesParameters(v.([]interface{}))
+	if d.HasChange("parent_id") {
+		if diags := moveSubAccount(ctx, d, meta); diags.HasError() {
+			return diags
+		}
 	}
 
+	customProperties := customPropertiesUpdates(d.GetChange("custom_properties"))
+
 	usedForProduction := d.Get("used_for_production")
 	displayName := d.Get("display_name")
 	description := d.Get("description")
@@ -404,7 +367,7 @@ func resourceSapBtpSubAccountUpdate(ctx context.Context, d *schema.ResourceData,
 			d.Set("created_date", output.CreatedDate.Format(time.RFC3339))
 			d.Set("modified_date", output.ModifiedDate.Format(time.RFC3339))
 			d.Set("parent_features", output.ParentFeatures)
-			d.Set("parent_id", output.ParentGuid)
+			d.Set("parent_id", flattenSubAccountParentId(d, output.ParentGuid, output.GlobalAccountGuid))
 			d.Set("state", output.State)
 			d.Set("state_message", output.StateMessage)
 			d.Set("description", output.Description)
@@ -415,21 +378,58 @@ func resourceSapBtpSubAccountUpdate(ctx context.Context, d *schema.ResourceData,
 			d.Set("used_for_production", output.UsedForProduction)
 			d.Set("subdomain", output.Subdomain)
 
-			cp := make([]map[string]interface{}, 0)
-			{
-				for _, gaCP := range output.CustomProperties {
-					m := make(map[string]interface{})
-					m["key"] = gaCP.Key
-					m["value"] = gaCP.Value
-					cp = append(cp, m)
-				}
-			}
-			d.Set("custom_properties", cp)
+			d.Set("custom_properties", flattenCustomProperties(output.CustomProperties))
+		}
+	}
+	return nil
+}
+
+// moveSubAccount moves the sub account to its new parent, a directory or the global account, waiting for it to settle
+func moveSubAccount(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	btpAccountsClient := meta.(*SAPClient).btpAccountsV1Client
+
+	targetId := d.Get("parent_id").(string)
+	if targetId == "" {
+		targetId = d.Get("global_account_id").(string)
+	}
+	input := &btpaccounts.MoveSubAccountInput{
+		SubAccountGuid:    d.Id(),
+		TargetAccountGuid: targetId,
+	}
+	if output, err := btpAccountsClient.MoveSubAccount(ctx, input); err != nil {
+		if output != nil && output.Error != nil {
+			return diag.FromErr(
+				errors.Errorf("BTP Sub Account can't be moved; %s", sap.StringValue(output.Error.Message)))
 		}
+		return diag.FromErr(fmt.Errorf("BTP Sub Account can't be moved; %#v", err))
+	}
+
+	retryErr := resource.RetryContext(ctx, 2*time.Minute, func() *resource.RetryError {
+		if c, gAcErr := btpAccountsClient.GetSubAccount(ctx, &btpaccounts.GetSubAccountInput{
+			SubAccountGuid: d.Id(),
+		}); gAcErr != nil {
+			return resource.NonRetryableError(gAcErr)
+		} else if c.State != "OK" {
+			return resource.RetryableError(fmt.Errorf("BTP Sub Account moving in progress, having status %s", c.State))
+		}
+		return nil
+	})
+	if retryErr != nil {
+		return diag.FromErr(errors.Errorf("BTP Sub Account can't be moved; %v", retryErr))
 	}
 	return nil
 }
 
+// flattenSubAccountParentId returns the parent to keep in state. BTP answers with the global account for the sub
+// accounts outside of any directory, which is kept empty unless configured, so removing 'parent_id' shows up as a
+// move back to the global account.
+func flattenSubAccountParentId(d *schema.ResourceData, parentId, globalAccountId string) string {
+	if parentId == globalAccountId && d.Get("parent_id").(string) != globalAccountId {
+		return ""
+	}
+	return parentId
+}
+
 func resourceSapBtpSubAccountDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	btpAccountsClient := meta.(*SAPClient).btpAccountsV1Client
 	aId := d.Id()
@@ -457,7 +457,6 @@ func resourceSapBtpSubAccountDelete(ctx context.Context, d *schema.ResourceData,
 		} else {
 			return resource.NonRetryableError(gAcErr)
 		}
-		return nil
 	})
 	if retryErr != nil && isResourceTimeoutError(retryErr) {
 		return diag.FromErr(retryErr)
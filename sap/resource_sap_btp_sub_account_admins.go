@@ -0,0 +1,63 @@
+package sap
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nnicora/sap-sdk-go/sap"
+	"github.com/nnicora/sap-sdk-go/service/btpaccounts"
+	"github.com/pkg/errors"
+)
+
+var subAccountAdministrators = &accountAdmins{
+	kind:           "Sub Account",
+	idKey:          "sub_account_id",
+	roleCollection: "Subaccount Administrator",
+	subdomain:      subAccountSubdomain,
+}
+
+func resourceSapBtpSubAccountAdmins() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceSapBtpSubAccountAdminsCreate,
+		ReadContext:   resourceSapBtpSubAccountAdminsRead,
+		UpdateContext: resourceSapBtpSubAccountAdminsUpdate,
+		DeleteContext: resourceSapBtpSubAccountAdminsDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: subAccountAdministrators.importState,
+		},
+		Schema: subAccountAdministrators.schema("The sub account, whose Authorization Service the endpoint " +
+			"refers to; the host of the endpoint must start with the subdomain of the sub account."),
+	}
+}
+
+func resourceSapBtpSubAccountAdminsCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return subAccountAdministrators.create(ctx, d, meta)
+}
+
+func resourceSapBtpSubAccountAdminsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return subAccountAdministrators.read(ctx, d, meta)
+}
+
+func resourceSapBtpSubAccountAdminsUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return subAccountAdministrators.update(ctx, d, meta)
+}
+
+func resourceSapBtpSubAccountAdminsDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return subAccountAdministrators.delete(ctx, d, meta)
+}
+
+func subAccountSubdomain(ctx context.Context, client accountsClient, id string) (string, bool, error) {
+	output, err := client.GetSubAccount(ctx, &btpaccounts.GetSubAccountInput{
+		SubAccountGuid: id,
+	})
+	if err != nil {
+		if output != nil && isNotFound(output.StatusAndBodyFromResponse, output.Error) {
+			return "", false, nil
+		}
+		if output != nil && output.Error != nil {
+			return "", false, errors.Errorf("BTP Sub Account can't be read; %s", sap.StringValue(output.Error.Message))
+		}
+		return "", false, errors.Errorf("BTP Sub Account can't be read; %v", err)
+	}
+	return output.Subdomain, true, nil
+}
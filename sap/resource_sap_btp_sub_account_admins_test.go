@@ -0,0 +1,431 @@
+package sap
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/nnicora/sap-sdk-go/sap"
+	"github.com/nnicora/sap-sdk-go/service/btpaccounts"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestAccSapBtpSubAccountAdmins_additive(t *testing.T) {
+	btp := newMockBtp(t)
+	btp.addSubAccount("mock-sub-account", "mock-sub-account")
+	btp.addScimMember("Subaccount Administrator", "outsider@example.com", "ldap")
+	resourceName := "sap_btp_sub_account_admins.test"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		CheckDestroy: testAccCheckMockScimMembers(btp, "Subaccount Administrator",
+			"outsider@example.com"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpSubAccountAdminsConfig(false, "alice@example.com", "bob@example.com"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "id", "mock-sub-account"),
+					resource.TestCheckResourceAttr(resourceName, "admins.#", "2"),
+					testAccCheckMockScimMembers(btp, "Subaccount Administrator",
+						"alice@example.com", "bob@example.com", "outsider@example.com"),
+				),
+			},
+			{
+				Config: testAccSapBtpSubAccountAdminsConfig(false, "bob@example.com", "carol@example.com"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "admins.#", "2"),
+					testAccCheckMockScimMembers(btp, "Subaccount Administrator",
+						"bob@example.com", "carol@example.com", "outsider@example.com"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccSapBtpSubAccountAdmins_authoritative(t *testing.T) {
+	btp := newMockBtp(t)
+	btp.addSubAccount("mock-sub-account", "mock-sub-account")
+	btp.addScimMember("Subaccount Administrator", "outsider@example.com", "ldap")
+	resourceName := "sap_btp_sub_account_admins.test"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		// The only admin left is kept, rather than locking everyone out of the sub account
+		CheckDestroy: testAccCheckMockScimMembers(btp, "Subaccount Administrator", "alice@example.com"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpSubAccountAdminsConfig(true, "alice@example.com"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "admins.#", "1"),
+					testAccCheckMockScimMembers(btp, "Subaccount Administrator", "alice@example.com"),
+				),
+			},
+			{
+				// An admin added outside of Terraform shows up as a change, and is removed again
+				PreConfig: func() {
+					btp.addScimMember("Subaccount Administrator", "intruder@example.com", "ldap")
+				},
+				Config: testAccSapBtpSubAccountAdminsConfig(true, "alice@example.com"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "admins.#", "1"),
+					testAccCheckMockScimMembers(btp, "Subaccount Administrator", "alice@example.com"),
+				),
+			},
+			{
+				// Imported admins are additive, the endpoint is resolved from the sub account's subdomain
+				ResourceName:            resourceName,
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"authoritative"},
+			},
+		},
+	})
+}
+
+func testAccSapBtpSubAccountAdminsConfig(authoritative bool, admins ...string) string {
+	return testAccProviderConfig + fmt.Sprintf(`
+resource "sap_btp_sub_account_admins" "test" {
+  endpoint_id    = "sub-account-authorization"
+  sub_account_id = "mock-sub-account"
+  admins         = ["%s"]
+  authoritative  = %t
+}
+`, strings.Join(admins, `", "`), authoritative)
+}
+
+// testAccCheckMockScimMembers verifies the members of the named role collection, as the fake holds them.
+func testAccCheckMockScimMembers(btp *mockBtp, roleCollection string, userNames ...string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if got := btp.scimMembers(roleCollection); !reflect.DeepEqual(got, append([]string{}, userNames...)) {
+			return fmt.Errorf("%s has members %v, expected %v", roleCollection, got, userNames)
+		}
+		return nil
+	}
+}
+
+// newFakeAdminsAuthorization returns an XSUAA whose 'Subaccount Administrator' role collection holds the given
+// users of SAP ID Service; "bob@example.com" exists without being an admin.
+func newFakeAdminsAuthorization(admins ...string) *fakeAuthorization {
+	authorization := &fakeAuthorization{
+		groups: map[string]*scimGroup{
+			"Subaccount Administrator": {Id: "group-1", DisplayName: "Subaccount Administrator"},
+		},
+		users: map[string]scimUser{
+			"user-bob@example.com": {Id: "user-bob@example.com", UserName: "bob@example.com", Origin: "ldap"},
+		},
+	}
+	group := authorization.groups["Subaccount Administrator"]
+	for _, userName := range admins {
+		id := "user-" + userName
+		authorization.users[id] = scimUser{Id: id, UserName: userName, Origin: "ldap"}
+		group.Members = append(group.Members, scimGroupMember{Origin: "ldap", Type: "USER", Value: id})
+	}
+	return authorization
+}
+
+// newFakeAdminsSAPClient returns a client knowing "sub-account-1", whose subdomain the host of fakeEndpointId
+// starts with.
+func newFakeAdminsSAPClient(authorization authorizationClient) *SAPClient {
+	return newFakeSAPClient(&fakeClientFactory{
+		accountsClient: &fakeAccounts{
+			subAccounts: map[string]btpaccounts.SubAccount{
+				"sub-account-1": {Guid: "sub-account-1", Subdomain: "fake", State: "OK"},
+			},
+		},
+		authorizationClient: authorization,
+	})
+}
+
+func TestSapBtpSubAccountAdminsCreate(t *testing.T) {
+	authorization := newFakeAdminsAuthorization("outsider@example.com")
+	meta := newFakeAdminsSAPClient(authorization)
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccountAdmins().Schema, map[string]interface{}{
+		"endpoint_id":    fakeEndpointId,
+		"sub_account_id": "sub-account-1",
+		"admins":         []interface{}{"alice@example.com", "bob@example.com"},
+	})
+	if diags := resourceSapBtpSubAccountAdminsCreate(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "sub-account-1" {
+		t.Errorf("id is %q, expected %q", d.Id(), "sub-account-1")
+	}
+	expected := []string{"alice@example.com", "bob@example.com", "outsider@example.com"}
+	if got := authorization.memberNames("Subaccount Administrator"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("admins are %v, expected %v", got, expected)
+	}
+	if !reflect.DeepEqual(authorization.createdUsers, []string{"alice@example.com"}) {
+		t.Errorf("created users are %v, expected only alice@example.com", authorization.createdUsers)
+	}
+	if emails := authorization.users["user-alice@example.com"].Emails; len(emails) != 1 ||
+		emails[0].Value != "alice@example.com" {
+		t.Errorf("alice@example.com was created with emails %v", emails)
+	}
+}
+
+func TestSapBtpSubAccountAdmins_validateUserName(t *testing.T) {
+	r := resourceSapBtpSubAccountAdmins()
+	diags := r.Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+		"endpoint_id":    fakeEndpointId,
+		"sub_account_id": "sub-account-1",
+		"admins":         []interface{}{"alice@example.com", "bob"},
+	}))
+	if !diags.HasError() {
+		t.Fatalf("expected an error for the user name without email address")
+	}
+}
+
+func TestSapBtpSubAccountAdminsCreate_unknownOrigin(t *testing.T) {
+	authorization := newFakeAdminsAuthorization("outsider@example.com")
+	meta := newFakeAdminsSAPClient(authorization)
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccountAdmins().Schema, map[string]interface{}{
+		"endpoint_id":    fakeEndpointId,
+		"sub_account_id": "sub-account-1",
+		"admins":         []interface{}{"alice@example.com"},
+		"origin":         "sap.custm",
+	})
+	diags := resourceSapBtpSubAccountAdminsCreate(context.Background(), d, meta)
+	if !diags.HasError() {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(diags[0].Summary, "sap.custm") {
+		t.Errorf("unexpected error: %s", diags[0].Summary)
+	}
+	if len(authorization.createdUsers) > 0 {
+		t.Errorf("users %v were created for an unknown identity provider", authorization.createdUsers)
+	}
+}
+
+func TestSapBtpSubAccountAdminsUpdate_addFails(t *testing.T) {
+	authorization := newFakeAdminsAuthorization("outsider@example.com")
+	authorization.failAddMember = "user-bob@example.com"
+	meta := newFakeAdminsSAPClient(authorization)
+
+	// Replacing the admins as a whole, where adding the second one fails
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccountAdmins().Schema, map[string]interface{}{
+		"endpoint_id":    fakeEndpointId,
+		"sub_account_id": "sub-account-1",
+		"admins":         []interface{}{"alice@example.com", "bob@example.com"},
+		"authoritative":  true,
+	})
+	if diags := resourceSapBtpSubAccountAdminsCreate(context.Background(), d, meta); !diags.HasError() {
+		t.Fatalf("expected an error")
+	}
+	got := authorization.memberNames("Subaccount Administrator")
+	if i := sort.SearchStrings(got, "outsider@example.com"); i == len(got) || got[i] != "outsider@example.com" {
+		t.Errorf("admins are %v, outsider@example.com was removed before all admins were added", got)
+	}
+}
+
+func TestSapBtpSubAccountAdminsCreate_authoritative(t *testing.T) {
+	authorization := newFakeAdminsAuthorization("outsider@example.com")
+	meta := newFakeAdminsSAPClient(authorization)
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccountAdmins().Schema, map[string]interface{}{
+		"endpoint_id":    fakeEndpointId,
+		"sub_account_id": "sub-account-1",
+		"admins":         []interface{}{"bob@example.com"},
+		"authoritative":  true,
+	})
+	if diags := resourceSapBtpSubAccountAdminsCreate(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	expected := []string{"bob@example.com"}
+	if got := authorization.memberNames("Subaccount Administrator"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("admins are %v, expected %v", got, expected)
+	}
+}
+
+func TestSapBtpSubAccountAdminsCreate_error(t *testing.T) {
+	authorization := &fakeAuthorization{failStatus: 403, failMessage: "insufficient_scope"}
+	meta := newFakeAdminsSAPClient(authorization)
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccountAdmins().Schema, map[string]interface{}{
+		"endpoint_id":    fakeEndpointId,
+		"sub_account_id": "sub-account-1",
+		"admins":         []interface{}{"alice@example.com"},
+	})
+	diags := resourceSapBtpSubAccountAdminsCreate(context.Background(), d, meta)
+	if !diags.HasError() {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(diags[0].Summary, "insufficient_scope") {
+		t.Errorf("error %q doesn't hold the API message", diags[0].Summary)
+	}
+}
+
+func TestSapBtpSubAccountAdminsRead(t *testing.T) {
+	for _, tc := range []struct {
+		authoritative bool
+		expected      []string
+	}{
+		// An admin removed outside of Terraform is missing, one added is ignored
+		{authoritative: false, expected: []string{"alice@example.com"}},
+		{authoritative: true, expected: []string{"alice@example.com", "outsider@example.com"}},
+	} {
+		authorization := newFakeAdminsAuthorization("alice@example.com", "outsider@example.com")
+		meta := newFakeAdminsSAPClient(authorization)
+
+		d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccountAdmins().Schema, map[string]interface{}{
+			"endpoint_id":    fakeEndpointId,
+			"sub_account_id": "sub-account-1",
+			"admins":         []interface{}{"alice@example.com", "bob@example.com"},
+			"authoritative":  tc.authoritative,
+		})
+		d.SetId("sub-account-1")
+		if diags := resourceSapBtpSubAccountAdminsRead(context.Background(), d, meta); diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+		got := expandStringSet(d.Get("admins").(*schema.Set))
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("authoritative %t: admins are %v, expected %v", tc.authoritative, got, tc.expected)
+		}
+	}
+}
+
+func TestSapBtpSubAccountAdminsDelete(t *testing.T) {
+	authorization := newFakeAdminsAuthorization("alice@example.com", "outsider@example.com")
+	meta := newFakeAdminsSAPClient(authorization)
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccountAdmins().Schema, map[string]interface{}{
+		"endpoint_id":    fakeEndpointId,
+		"sub_account_id": "sub-account-1",
+		"admins":         []interface{}{"alice@example.com"},
+	})
+	d.SetId("sub-account-1")
+	if diags := resourceSapBtpSubAccountAdminsDelete(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	expected := []string{"outsider@example.com"}
+	if got := authorization.memberNames("Subaccount Administrator"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("admins are %v, expected %v", got, expected)
+	}
+}
+
+func TestSapBtpSubAccountAdminsCreate_foreignEndpoint(t *testing.T) {
+	authorization := newFakeAdminsAuthorization("outsider@example.com")
+	meta := newFakeSAPClient(&fakeClientFactory{
+		accountsClient: &fakeAccounts{
+			subAccounts: map[string]btpaccounts.SubAccount{
+				"sub-account-1": {Guid: "sub-account-1", Subdomain: "other", State: "OK"},
+			},
+		},
+		authorizationClient: authorization,
+	})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccountAdmins().Schema, map[string]interface{}{
+		"endpoint_id":    fakeEndpointId,
+		"sub_account_id": "sub-account-1",
+		"admins":         []interface{}{"alice@example.com"},
+	})
+	diags := resourceSapBtpSubAccountAdminsCreate(context.Background(), d, meta)
+	if !diags.HasError() {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(diags[0].Summary, "doesn't belong to BTP Sub Account sub-account-1") {
+		t.Errorf("unexpected error: %s", diags[0].Summary)
+	}
+	expected := []string{"outsider@example.com"}
+	if got := authorization.memberNames("Subaccount Administrator"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("admins are %v, expected them unchanged as %v", got, expected)
+	}
+}
+
+func TestSapBtpSubAccountAdminsRead_notFound(t *testing.T) {
+	meta := newFakeAdminsSAPClient(newFakeAdminsAuthorization("alice@example.com"))
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccountAdmins().Schema, map[string]interface{}{
+		"endpoint_id":    fakeEndpointId,
+		"sub_account_id": "sub-account-2",
+		"admins":         []interface{}{"alice@example.com"},
+	})
+	d.SetId("sub-account-2")
+	if diags := resourceSapBtpSubAccountAdminsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "" {
+		t.Errorf("the admins of a missing sub account are kept in state")
+	}
+}
+
+func TestSapBtpSubAccountAdminsImport(t *testing.T) {
+	authorization := newFakeAdminsAuthorization("alice@example.com", "outsider@example.com")
+	meta := newSAPClient(&fakeClientFactory{
+		accountsClient: &fakeAccounts{
+			subAccounts: map[string]btpaccounts.SubAccount{
+				"sub-account-1": {Guid: "sub-account-1", Subdomain: "fake", State: "OK"},
+			},
+		},
+		authorizationClient: authorization,
+	}, map[string]*sap.EndpointConfig{
+		"accounts":      {Host: "https://accounts.local"},
+		fakeEndpointId:  {Host: "https://fake.authentication.local"},
+		"other-account": {Host: "https://other.authentication.local"},
+	})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccountAdmins().Schema, map[string]interface{}{})
+	d.SetId("sub-account-1")
+	if _, err := subAccountAdministrators.importState(context.Background(), d, meta); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := d.Get("endpoint_id").(string); got != fakeEndpointId {
+		t.Errorf("endpoint_id is %q, expected %q", got, fakeEndpointId)
+	}
+	if got := d.Get("sub_account_id").(string); got != "sub-account-1" {
+		t.Errorf("sub_account_id is %q, expected %q", got, "sub-account-1")
+	}
+	got := expandStringSet(d.Get("admins").(*schema.Set))
+	sort.Strings(got)
+	if expected := []string{"alice@example.com", "outsider@example.com"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("admins are %v, expected %v", got, expected)
+	}
+}
+
+func TestSapBtpSubAccountAdminsImport_noEndpoint(t *testing.T) {
+	meta := newFakeSAPClient(&fakeClientFactory{
+		accountsClient: &fakeAccounts{
+			subAccounts: map[string]btpaccounts.SubAccount{
+				"sub-account-1": {Guid: "sub-account-1", Subdomain: "other", State: "OK"},
+			},
+		},
+		authorizationClient: newFakeAdminsAuthorization(),
+	})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccountAdmins().Schema, map[string]interface{}{})
+	d.SetId("sub-account-1")
+	if _, err := subAccountAdministrators.importState(context.Background(), d, meta); err == nil {
+		t.Fatalf("expected an error, as no endpoint refers to the Authorization Service of the sub account")
+	}
+}
+
+func TestSapBtpSubAccountAdminsDelete_lastAdmins(t *testing.T) {
+	authorization := newFakeAdminsAuthorization("alice@example.com")
+	meta := newFakeAdminsSAPClient(authorization)
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccountAdmins().Schema, map[string]interface{}{
+		"endpoint_id":    fakeEndpointId,
+		"sub_account_id": "sub-account-1",
+		"admins":         []interface{}{"alice@example.com"},
+		"authoritative":  true,
+	})
+	d.SetId("sub-account-1")
+	diags := resourceSapBtpSubAccountAdminsDelete(context.Background(), d, meta)
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if len(diags) != 1 || diags[0].Severity != diag.Warning {
+		t.Errorf("expected a warning, got %v", diags)
+	}
+	expected := []string{"alice@example.com"}
+	if got := authorization.memberNames("Subaccount Administrator"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("admins are %v, expected the last one kept", got)
+	}
+}
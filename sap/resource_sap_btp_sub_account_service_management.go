@@ -2,12 +2,12 @@ package sap
 
 import (
 	"context"
-	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/nnicora/sap-sdk-go/sap"
 	"github.com/nnicora/sap-sdk-go/service/btpaccounts"
 	"github.com/pkg/errors"
+	"log"
 	"time"
 )
 
@@ -28,6 +28,7 @@ func resourceSapBtpSubAccountServiceManagement() *schema.Resource {
 			"sub_account_id": {
 				Type:     schema.TypeString,
 				Required: true,
+				ForceNew: true,
 			},
 
 			// Computed
@@ -37,9 +38,10 @@ func resourceSapBtpSubAccountServiceManagement() *schema.Resource {
 				Computed: true,
 			},
 			"client_secret": {
-				Type:     schema.TypeString,
-				Optional: true,
-				Computed: true,
+				Type:      schema.TypeString,
+				Optional:  true,
+				Computed:  true,
+				Sensitive: true,
 			},
 			"service_management_url": {
 				Type:     schema.TypeString,
@@ -65,49 +67,62 @@ func resourceSapBtpSubAccountServiceManagement() *schema.Resource {
 func resourceSapBtpSubAccountServiceManagementCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	btpAccountsClient := meta.(*SAPClient).btpAccountsV1Client
 
-	if uuidString, err := uuid.GenerateUUID(); err != nil {
-		return diag.FromErr(err)
-	} else {
-		d.SetId(uuidString)
+	// A sub account has a single binding; one created outside of Terraform may be in use elsewhere, hence it's
+	// only ever managed once imported, so that destroying the resource doesn't delete it unnoticed
+	subAccountId := d.Get("sub_account_id").(string)
+	getInput := &btpaccounts.GetServiceManagementBindingInput{
+		SubAccountGuid: subAccountId,
+	}
+	if output, err := btpAccountsClient.GetSubAccountServiceManagementBinding(ctx, getInput); err == nil {
+		return diag.Errorf("BTP Sub Account ServiceManagementBinding can't be created; BTP Sub Account %s has "+
+			"already one, which 'terraform import' brings under management", subAccountId)
+	} else if output == nil || !isNotFound(output.StatusAndBodyFromResponse, output.Error) {
+		if output != nil && output.Error != nil {
+			return diag.FromErr(
+				errors.Errorf("BTP Sub Account ServiceManagementBinding can't be created; %s", sap.StringValue(output.Error.Message)))
+		}
+		return diag.FromErr(errors.Errorf("BTP Sub Account ServiceManagementBinding can't be created;  %v", err))
 	}
 
-	subAccountId := d.Get("sub_account_id")
 	input := &btpaccounts.CreateServiceManagementBindingInput{
-		SubAccountGuid: subAccountId.(string),
+		SubAccountGuid: subAccountId,
 	}
-	if output, err := btpAccountsClient.CreateSubAccountServiceManagementBinding(ctx, input); err != nil {
-		return resourceSapBtpSubAccountServiceManagementRead(ctx, d, meta)
-	} else {
-		d.Set("client_id", output.ClientId)
-		d.Set("client_secret", output.ClientSecret)
-		d.Set("service_management_url", output.SMUrl)
-		d.Set("authentication_server_url", output.Url)
-		d.Set("application_name", output.XsAppName)
+	output, err := btpAccountsClient.CreateSubAccountServiceManagementBinding(ctx, input)
+	if err != nil {
+		if output != nil && output.Error != nil {
+			return diag.FromErr(
+				errors.Errorf("BTP Sub Account ServiceManagementBinding can't be created; %s", sap.StringValue(output.Error.Message)))
+		}
+		return diag.FromErr(errors.Errorf("BTP Sub Account ServiceManagementBinding can't be created;  %v", err))
 	}
 
+	d.SetId(subAccountId)
+	setSubAccountServiceManagementBinding(d, &output.ServiceManagementBinding)
 	return nil
 }
 
 func resourceSapBtpSubAccountServiceManagementRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	btpAccountsClient := meta.(*SAPClient).btpAccountsV1Client
 
-	subAccountId := d.Get("sub_account_id")
+	subAccountId := subAccountServiceManagementId(d)
 	input := &btpaccounts.GetServiceManagementBindingInput{
-		SubAccountGuid: subAccountId.(string),
+		SubAccountGuid: subAccountId,
 	}
 	if output, err := btpAccountsClient.GetSubAccountServiceManagementBinding(ctx, input); err != nil {
+		if output != nil && isNotFound(output.StatusAndBodyFromResponse, output.Error) {
+			log.Printf("[WARN] BTP Sub Account ServiceManagementBinding %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
 		if output != nil && output.Error != nil {
 			return diag.FromErr(
 				errors.Errorf("BTP Sub Account ServiceManagementBinding can't be read; %s", sap.StringValue(output.Error.Message)))
 		}
 		return diag.FromErr(errors.Errorf("BTP Sub Account ServiceManagementBinding can't be read;  %v", err))
 	} else {
-		d.SetId(d.Id())
-		d.Set("client_id", output.ClientId)
-		d.Set("client_secret", output.ClientSecret)
-		d.Set("service_management_url", output.SMUrl)
-		d.Set("authentication_server_url", output.Url)
-		d.Set("application_name", output.XsAppName)
+		d.SetId(subAccountId)
+		d.Set("sub_account_id", subAccountId)
+		setSubAccountServiceManagementBinding(d, &output.ServiceManagementBinding)
 	}
 	return nil
 }
@@ -115,11 +130,13 @@ func resourceSapBtpSubAccountServiceManagementRead(ctx context.Context, d *schem
 func resourceSapBtpSubAccountServiceManagementDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	btpAccountsClient := meta.(*SAPClient).btpAccountsV1Client
 
-	subAccountId := d.Get("sub_account_id")
 	input := &btpaccounts.DeleteServiceManagementBindingInput{
-		SubAccountGuid: subAccountId.(string),
+		SubAccountGuid: subAccountServiceManagementId(d),
 	}
 	if output, err := btpAccountsClient.DeleteSubAccountServiceManagementBinding(ctx, input); err != nil {
+		if output != nil && isNotFound(output.StatusAndBodyFromResponse, output.Error) {
+			return nil
+		}
 		if output != nil && output.Error != nil {
 			return diag.FromErr(
 				errors.Errorf("BTP Sub Account ServiceManagementBinding can't be deleted; %s", sap.StringValue(output.Error.Message)))
@@ -128,3 +145,20 @@ func resourceSapBtpSubAccountServiceManagementDelete(ctx context.Context, d *sch
 	}
 	return nil
 }
+
+// subAccountServiceManagementId returns the sub account the binding belongs to, which is the id of the resource.
+// Resources created before were given a random id, their sub account is the one in state.
+func subAccountServiceManagementId(d *schema.ResourceData) string {
+	if subAccountId, ok := d.GetOk("sub_account_id"); ok {
+		return subAccountId.(string)
+	}
+	return d.Id()
+}
+
+func setSubAccountServiceManagementBinding(d *schema.ResourceData, binding *btpaccounts.ServiceManagementBinding) {
+	d.Set("client_id", binding.ClientId)
+	d.Set("client_secret", binding.ClientSecret)
+	d.Set("service_management_url", binding.SMUrl)
+	d.Set("authentication_server_url", binding.Url)
+	d.Set("application_name", binding.XsAppName)
+}
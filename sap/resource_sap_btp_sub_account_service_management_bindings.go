@@ -2,19 +2,22 @@ package sap
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/nnicora/sap-sdk-go/service/btpmanagment"
+	"github.com/nnicora/terraform-provider-sap/sap/internal/flatten"
 	"github.com/pkg/errors"
-	"strings"
+	"log"
 	"time"
 )
 
 func resourceSapBtpSubAccountServiceManagementBindings() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
+		SchemaVersion: 1,
 		CreateContext: resourceSapBtpSubAccountServiceManagementBindingsCreate,
 		ReadContext:   resourceSapBtpSubAccountServiceManagementBindingsRead,
-		//UpdateContext: resourceSapBtpSubAccountServiceManagementBindingsUpdate,
+		// Service Manager can't update bindings; only the attributes not sent to it change in place
 		UpdateContext: resourceSapBtpSubAccountServiceManagementBindingsRead,
 		DeleteContext: resourceSapBtpSubAccountServiceManagementBindingsDelete,
 		Importer: &schema.ResourceImporter{
@@ -25,106 +28,39 @@ func resourceSapBtpSubAccountServiceManagementBindings() *schema.Resource {
 			Delete: schema.DefaultTimeout(3 * time.Minute),
 		},
 		Schema: map[string]*schema.Schema{
-			"service_management": {
-				Type:     schema.TypeList,
-				Required: true,
-				MaxItems: 1,
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-
-						"host": {
-							Type:     schema.TypeString,
-							Required: true,
-						},
-						"oauth2": {
-							Type:     schema.TypeList,
-							Required: true,
-							MaxItems: 1,
-							Elem: &schema.Resource{
-								Schema: map[string]*schema.Schema{
-									"grant_type": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "client_credentials",
-										Description: "SAP OAuth2 Grant Type.",
-									},
-									"client_id": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Client Id.",
-									},
-									"client_secret": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Client Secret.",
-									},
-									"token_url": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Token Url.",
-									},
-									"authorization_url": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Authorization Url.",
-									},
-									"redirect_url": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Redirect Url.",
-									},
-
-									"username": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Username. Used in case if 'grant_type=password'.",
-									},
-									"password": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Password. Used in case if 'grant_type=password'.",
-									},
-
-									"timeout_seconds": {
-										Type:        schema.TypeInt,
-										Optional:    true,
-										Default:     60,
-										Description: "SAP OAuth2 HTTP Client timeout.",
-									},
-								},
-							},
-						},
-					},
-				},
+			"endpoint_id":        endpointIdSchema("service_management"),
+			"service_management": endpointSchema("service_management"),
+
+			"async": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether Service Manager runs the operations asynchronously, waiting for them to finish.",
 			},
 
 			"name": {
 				Type:     schema.TypeString,
 				Required: true,
+				ForceNew: true,
 			},
 			"service_instance_id": {
 				Type:     schema.TypeString,
 				Optional: true,
+				ForceNew: true,
 			},
 			"parameters": {
 				Type:     schema.TypeMap,
 				Optional: true,
+				ForceNew: true,
 				Elem:     &schema.Schema{Type: schema.TypeString},
 			},
 			"resources": {
 				Type:     schema.TypeMap,
 				Optional: true,
+				ForceNew: true,
 				Elem:     &schema.Schema{Type: schema.TypeString},
 			},
-			"labels": {
-				Type:     schema.TypeMap,
-				Optional: true,
-				Elem:     &schema.Schema{Type: schema.TypeList},
-			},
+			"labels": labelsSchemaForceNew(),
 
 			"ready": {
 				Type:     schema.TypeBool,
@@ -136,131 +72,135 @@ func resourceSapBtpSubAccountServiceManagementBindings() *schema.Resource {
 				Elem:     &schema.Schema{Type: schema.TypeString},
 			},
 			"credentials": {
-				Type:     schema.TypeMap,
-				Computed: true,
-				Elem:     &schema.Schema{Type: schema.TypeString},
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Sensitive:   true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The credentials flattened into dotted keys, e.g. \"uaa.clientid\" or \"certificates.0\".",
+			},
+			"credentials_json": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The credentials as JSON, for the nested values the flattened map can't represent faithfully.",
 			},
 
 			"tags": tagsSchema(),
 		},
 	}
+	r.StateUpgraders = []schema.StateUpgrader{
+		labelsStateUpgraderV0(r),
+	}
+	return r
 }
 
 func resourceSapBtpSubAccountServiceManagementBindingsCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	session := meta.(*SAPClient).session
-	serviceList := d.Get("service_management").([]interface{})
-	if len(serviceList) < 1 {
-		return diag.Errorf("Service management is required")
-	}
-
-	err := session.AddEndpointWithReplace(btpmanagment.EndpointsID, extractEndpointConfig(serviceList))
+	btpServiceManagementV1Client, err := meta.(*SAPClient).serviceManagementV1Client(d, "service_management")
 	if err != nil {
 		return diag.FromErr(errors.Errorf("BTP Service Management OAuth2;  %v", err))
 	}
 
-	btpServiceManagementV1Client := btpmanagment.New(session)
-
 	input := &btpmanagment.CreateServiceBindingInput{
-		Async:             false,
+		Async:             d.Get("async").(bool),
 		Name:              d.Get("name").(string),
 		ServiceInstanceId: d.Get("service_instance_id").(string),
 		Parameters:        expandMapString(d.Get("parameters")),
 		BindResource:      expandMapString(d.Get("resources")),
-		Labels:            expandMapListString(d.Get("labels")),
+		Labels:            expandLabels(d.Get("labels")),
 	}
 
-	if output, err := btpServiceManagementV1Client.CreateServiceBinding(ctx, input); err != nil {
-		if output != nil && output.ErrorMessage != "" {
+	output, err := btpServiceManagementV1Client.createServiceBinding(ctx, input)
+	if err != nil {
+		return diag.Errorf("BTP Sub Account ServiceManagement Bindings can't be created; %v", err)
+	}
+
+	if output.Location != "" {
+		operation, err := operationFromLocation(output.Location)
+		if err != nil {
 			return diag.FromErr(
-				errors.Errorf("BTP Sub Account ServiceManagement Bindings can't be created; %s", output.ErrorMessage))
+				errors.Errorf("BTP Sub Account ServiceManagement Bindings can't be created; %v", err))
+		}
+		d.SetId(operation.ResourceID)
+
+		if err := waitForServiceManagementOperation(ctx, btpServiceManagementV1Client, output.Location,
+			d.Timeout(schema.TimeoutCreate)); err != nil {
+			return diag.FromErr(
+				errors.Errorf("BTP Sub Account ServiceManagement Bindings can't be created; %v", err))
 		}
 	} else {
 		d.SetId(output.Id)
-		d.Set("ready", output.Ready)
-		d.Set("context", output.Context)
-
-		data := make(map[string]string)
-		flatMap("", output.Credentials, data)
-		d.Set("credentials", data)
 	}
 
-	return nil
+	return resourceSapBtpSubAccountServiceManagementBindingsRead(ctx, d, meta)
 }
 
 func resourceSapBtpSubAccountServiceManagementBindingsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	session := meta.(*SAPClient).session
-	serviceList := d.Get("service_management").([]interface{})
-	if len(serviceList) < 1 {
-		return diag.Errorf("Service management service is required")
-	}
-
-	err := session.AddEndpointWithReplace(btpmanagment.EndpointsID, extractEndpointConfig(serviceList))
+	btpServiceManagementV1Client, err := meta.(*SAPClient).serviceManagementV1Client(d, "service_management")
 	if err != nil {
 		return diag.FromErr(errors.Errorf("BTP Service Management OAuth2;  %v", err))
 	}
 
-	btpServiceManagementV1Client := btpmanagment.New(session)
-
 	input := &btpmanagment.GetServiceBindingInput{
 		ServiceBindingID: d.Id(),
 	}
-	if output, err := btpServiceManagementV1Client.GetServiceBinding(ctx, input); err != nil {
-		if output != nil && output.ErrorMessage != "" {
-			return diag.FromErr(
-				errors.Errorf("BTP Sub Account ServiceManagement Bindings can't be created; %s", output.ErrorMessage))
+	output, err := btpServiceManagementV1Client.GetServiceBinding(ctx, input)
+	if err != nil {
+		smErr := newServiceManagementError(err, output.Error, output.StatusAndBodyFromResponse)
+		if smErr.IsNotFound() {
+			log.Printf("[WARN] BTP Sub Account ServiceManagement Binding %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
 		}
-	} else {
-		d.Set("ready", output.Ready)
-		d.Set("context", output.Context)
-
-		data := make(map[string]string)
-		flatMap("", output.Credentials, data)
-		d.Set("credentials", data)
+		return diag.Errorf("BTP Sub Account ServiceManagement Bindings can't be read; %v", smErr)
 	}
-	return nil
-}
 
-func resourceSapBtpSubAccountServiceManagementBindingsUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.Set("ready", output.Ready)
+	d.Set("context", output.Context)
+
+	credentialsJson, err := serviceBindingCredentialsJson(output.Credentials)
+	if err != nil {
+		return diag.FromErr(errors.Errorf("BTP Sub Account ServiceManagement Binding credentials can't be read; %v", err))
+	}
+	d.Set("credentials", flatten.Flatten(output.Credentials))
+	d.Set("credentials_json", credentialsJson)
 	return nil
 }
 
 func resourceSapBtpSubAccountServiceManagementBindingsDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	session := meta.(*SAPClient).session
-	serviceList := d.Get("service_management").([]interface{})
-	if len(serviceList) < 1 {
-		return diag.Errorf("Service management service is required")
-	}
-
-	err := session.AddEndpointWithReplace(btpmanagment.EndpointsID, extractEndpointConfig(serviceList))
+	btpServiceManagementV1Client, err := meta.(*SAPClient).serviceManagementV1Client(d, "service_management")
 	if err != nil {
 		return diag.FromErr(errors.Errorf("BTP Service Management OAuth2;  %v", err))
 	}
 
-	btpServiceManagementV1Client := btpmanagment.New(session)
-
 	input := &btpmanagment.DeleteServiceBindingInput{
 		ServiceBindingID: d.Id(),
-		Async:            false,
+		Async:            d.Get("async").(bool),
 	}
-	if output, err := btpServiceManagementV1Client.DeleteServiceBinding(ctx, input); err != nil {
-		if output != nil && output.ErrorMessage != "" {
+	output, err := btpServiceManagementV1Client.deleteServiceBinding(ctx, input)
+	if err != nil {
+		if isServiceManagementNotFound(err) {
+			return nil
+		}
+		return diag.Errorf("BTP Sub Account ServiceManagement Bindings can't be deleted; %v", err)
+	}
+	if output.Location != "" {
+		if err := waitForServiceManagementOperation(ctx, btpServiceManagementV1Client, output.Location,
+			d.Timeout(schema.TimeoutDelete)); err != nil {
 			return diag.FromErr(
-				errors.Errorf("BTP Sub Account ServiceManagement Bindings can't be created; %s", output.ErrorMessage))
+				errors.Errorf("BTP Sub Account ServiceManagement Bindings can't be deleted; %v", err))
 		}
 	}
 	return nil
 }
 
-func flatMap(prefix string, src map[string]interface{}, dst map[string]string) {
-	for k, v := range src {
-		if nestedMap, ok := v.(map[string]interface{}); ok {
-			flatMap(k, nestedMap, dst)
-		} else {
-			if prefix != "" && !strings.HasSuffix(prefix, ".") {
-				prefix = prefix + "."
-			}
-			key := prefix + k
-			dst[key] = v.(string)
-		}
+// serviceBindingCredentialsJson encodes the credentials of a binding, keeping their nested values and types.
+func serviceBindingCredentialsJson(credentials map[string]interface{}) (string, error) {
+	if len(credentials) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(credentials)
+	if err != nil {
+		return "", err
 	}
+	return string(data), nil
 }
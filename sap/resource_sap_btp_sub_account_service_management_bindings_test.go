@@ -0,0 +1,160 @@
+package sap
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/nnicora/sap-sdk-go/service/btpmanagment"
+	"testing"
+)
+
+func TestAccSapBtpSubAccountServiceManagementBindings_basic(t *testing.T) {
+	btp := newMockBtp(t)
+	resourceName := "sap_btp_sub_account_service_management_bindings.test"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		CheckDestroy: resource.ComposeTestCheckFunc(
+			testAccCheckDestroyed(btp, "sap_btp_sub_account_service_management_bindings", btp.bindings),
+			testAccCheckDestroyed(btp, "sap_btp_sub_account_service_management_instances", btp.instances),
+		),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpSubAccountServiceManagementBindingsConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(resourceName, "service_instance_id",
+						"sap_btp_sub_account_service_management_instances.test", "id"),
+					resource.TestCheckResourceAttr(resourceName, "ready", "true"),
+					resource.TestCheckResourceAttr(resourceName, "credentials.url", mockBtpHost),
+					resource.TestCheckResourceAttrSet(resourceName, "credentials.clientsecret"),
+					resource.TestCheckResourceAttr(resourceName, "credentials.port", "30015"),
+					resource.TestCheckResourceAttrSet(resourceName, "credentials.uaa.tenantid"),
+					resource.TestCheckResourceAttrSet(resourceName, "credentials_json"),
+				),
+			},
+		},
+	})
+}
+
+func TestSapBtpSubAccountServiceManagementBindingsRead(t *testing.T) {
+	var credentials map[string]interface{}
+	if err := json.Unmarshal([]byte(`{
+  "host": "hana.example.com",
+  "port": 30015,
+  "encrypt": true,
+  "certificate": ["-----BEGIN CERTIFICATE-----root", "-----BEGIN CERTIFICATE-----leaf"],
+  "uaa": {"clientid": "sb-hana", "tenant": {"subdomain": "test"}},
+  "schema": null
+}`), &credentials); err != nil {
+		t.Fatal(err)
+	}
+	sm := &fakeServiceManagement{
+		bindings: map[string]btpmanagment.BindingItem{
+			"binding-0": {Id: "binding-0", Name: "hana", Ready: true, Credentials: credentials},
+		},
+	}
+	meta := newFakeSAPClient(&fakeClientFactory{smClient: sm})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccountServiceManagementBindings().Schema,
+		map[string]interface{}{
+			"endpoint_id": fakeEndpointId,
+			"name":        "hana",
+		})
+	d.SetId("binding-0")
+	if diags := resourceSapBtpSubAccountServiceManagementBindingsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	expected := map[string]string{
+		"host":                 "hana.example.com",
+		"port":                 "30015",
+		"encrypt":              "true",
+		"certificate.#":        "2",
+		"certificate.0":        "-----BEGIN CERTIFICATE-----root",
+		"certificate.1":        "-----BEGIN CERTIFICATE-----leaf",
+		"uaa.clientid":         "sb-hana",
+		"uaa.tenant.subdomain": "test",
+		"schema":               "",
+	}
+	actual := d.Get("credentials").(map[string]interface{})
+	if len(actual) != len(expected) {
+		t.Errorf("credentials are %v, expected %v", actual, expected)
+	}
+	for key, value := range expected {
+		if actual[key] != value {
+			t.Errorf("credentials.%s is %q, expected %q", key, actual[key], value)
+		}
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(d.Get("credentials_json").(string)), &decoded); err != nil {
+		t.Fatalf("credentials_json isn't JSON; %v", err)
+	}
+	if decoded["port"] != float64(30015) || decoded["encrypt"] != true {
+		t.Errorf("credentials_json lost the types of the credentials: %v", decoded)
+	}
+}
+
+const testAccSapBtpSubAccountServiceManagementBindingsConfig = testAccProviderConfig + `
+resource "sap_btp_sub_account_service_management_instances" "test" {
+  endpoint_id           = "service-manager"
+  name                  = "test-instance"
+  service_offering_name = "destination"
+  service_plan_name     = "lite"
+}
+
+resource "sap_btp_sub_account_service_management_bindings" "test" {
+  endpoint_id         = "service-manager"
+  name                = "test-binding"
+  service_instance_id = sap_btp_sub_account_service_management_instances.test.id
+  async               = true
+}
+`
+
+func TestSapBtpSubAccountServiceManagementBindingsDiff_forceNew(t *testing.T) {
+	r := resourceSapBtpSubAccountServiceManagementBindings()
+	state := &terraform.InstanceState{
+		ID: "binding-1",
+		Attributes: map[string]string{
+			"id":                  "binding-1",
+			"endpoint_id":         fakeEndpointId,
+			"async":               "false",
+			"name":                "binding",
+			"service_instance_id": "instance-1",
+			"parameters.%":        "1",
+			"parameters.role":     "viewer",
+		},
+	}
+	existing := map[string]interface{}{
+		"endpoint_id":         fakeEndpointId,
+		"name":                "binding",
+		"service_instance_id": "instance-1",
+		"parameters":          map[string]interface{}{"role": "viewer"},
+	}
+
+	for attribute, value := range map[string]interface{}{
+		"name":                "renamed",
+		"service_instance_id": "instance-2",
+		"parameters":          map[string]interface{}{"role": "admin"},
+		"resources":           map[string]interface{}{"app_guid": "app-1"},
+		"labels": []interface{}{
+			map[string]interface{}{"key": "team", "values": []interface{}{"core"}},
+		},
+	} {
+		raw := make(map[string]interface{}, len(existing)+1)
+		for k, v := range existing {
+			raw[k] = v
+		}
+		raw[attribute] = value
+
+		diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), nil)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", attribute, err)
+		}
+		if !diff.RequiresNew() {
+			t.Errorf("changing %s doesn't replace the binding, while Service Manager can't update it", attribute)
+		}
+	}
+}
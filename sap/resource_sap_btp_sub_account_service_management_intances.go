@@ -2,104 +2,34 @@ package sap
 
 import (
 	"context"
+	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/nnicora/sap-sdk-go/service/btpmanagment"
 	"github.com/pkg/errors"
+	"log"
 	"time"
 )
 
 func resourceSapBtpSubAccountServiceManagementInstances() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
+		SchemaVersion: 1,
 		CreateContext: resourceSapBtpSubAccountServiceManagementInstancesCreate,
 		ReadContext:   resourceSapBtpSubAccountServiceManagementInstancesRead,
-		//UpdateContext: resourceSapBtpSubAccountServiceManagementInstancesUpdate,
-		UpdateContext: resourceSapBtpSubAccountServiceManagementInstancesRead,
+		UpdateContext: resourceSapBtpSubAccountServiceManagementInstancesUpdate,
 		DeleteContext: resourceSapBtpSubAccountServiceManagementInstancesDelete,
+		CustomizeDiff: resourceSapBtpSubAccountServiceManagementInstancesCustomizeDiff,
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(3 * time.Minute),
+			Update: schema.DefaultTimeout(3 * time.Minute),
 			Delete: schema.DefaultTimeout(3 * time.Minute),
 		},
 		Schema: map[string]*schema.Schema{
-			"service_management": {
-				Type:     schema.TypeList,
-				Required: true,
-				MaxItems: 1,
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-
-						"host": {
-							Type:     schema.TypeString,
-							Required: true,
-						},
-						"oauth2": {
-							Type:     schema.TypeList,
-							Required: true,
-							MaxItems: 1,
-							Elem: &schema.Resource{
-								Schema: map[string]*schema.Schema{
-									"grant_type": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "client_credentials",
-										Description: "SAP OAuth2 Grant Type.",
-									},
-									"client_id": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Client Id.",
-									},
-									"client_secret": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Client Secret.",
-									},
-									"token_url": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Token Url.",
-									},
-									"authorization_url": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Authorization Url.",
-									},
-									"redirect_url": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Redirect Url.",
-									},
-
-									"username": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Username. Used in case if 'grant_type=password'.",
-									},
-									"password": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Password. Used in case if 'grant_type=password'.",
-									},
-
-									"timeout_seconds": {
-										Type:        schema.TypeInt,
-										Optional:    true,
-										Default:     60,
-										Description: "SAP OAuth2 HTTP Client timeout.",
-									},
-								},
-							},
-						},
-					},
-				},
-			},
+			"endpoint_id":        endpointIdSchema("service_management"),
+			"service_management": endpointSchema("service_management"),
 
 			"name": {
 				Type:     schema.TypeString,
@@ -108,25 +38,29 @@ func resourceSapBtpSubAccountServiceManagementInstances() *schema.Resource {
 			"service_plan_id": {
 				Type:     schema.TypeString,
 				Optional: true,
+				Computed: true,
 			},
 			"service_offering_name": {
 				Type:     schema.TypeString,
 				Optional: true,
+				ForceNew: true,
 			},
 			"service_plan_name": {
 				Type:     schema.TypeString,
 				Optional: true,
 			},
+			"async": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether Service Manager runs the operations asynchronously, waiting for them to finish.",
+			},
 			"parameters": {
 				Type:     schema.TypeMap,
 				Optional: true,
 				Elem:     &schema.Schema{Type: schema.TypeString},
 			},
-			"labels": {
-				Type:     schema.TypeMap,
-				Optional: true,
-				Elem:     &schema.Schema{Type: schema.TypeList},
-			},
+			"labels": labelsSchema(),
 
 			"ready": {
 				Type:     schema.TypeBool,
@@ -158,27 +92,23 @@ func resourceSapBtpSubAccountServiceManagementInstances() *schema.Resource {
 			"tags": tagsSchema(),
 		},
 	}
+	r.StateUpgraders = []schema.StateUpgrader{
+		labelsStateUpgraderV0(r),
+	}
+	return r
 }
 
 func resourceSapBtpSubAccountServiceManagementInstancesCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	session := meta.(*SAPClient).session
-	serviceList := d.Get("service_management").([]interface{})
-	if len(serviceList) < 1 {
-		return diag.Errorf("Service management is required")
-	}
-
-	err := session.AddEndpointWithReplace(btpmanagment.EndpointsID, extractEndpointConfig(serviceList))
+	btpServiceManagementV1Client, err := meta.(*SAPClient).serviceManagementV1Client(d, "service_management")
 	if err != nil {
 		return diag.FromErr(errors.Errorf("BTP Service Management OAuth2;  %v", err))
 	}
 
-	btpServiceManagementV1Client := btpmanagment.New(session)
-
 	input := &btpmanagment.CreateServiceInstanceInput{
-		Async:      false,
+		Async:      d.Get("async").(bool),
 		Name:       d.Get("name").(string),
 		Parameters: expandMapString(d.Get("parameters")),
-		Labels:     expandMapListString(d.Get("labels")),
+		Labels:     expandLabels(d.Get("labels")),
 	}
 	if val, ok := d.GetOk("service_plan_id"); ok {
 		input.ServicePlanId = val.(string)
@@ -189,123 +119,228 @@ func resourceSapBtpSubAccountServiceManagementInstancesCreate(ctx context.Contex
 	if val, ok := d.GetOk("service_plan_name"); ok {
 		input.ServicePlanName = val.(string)
 	}
-	if output, err := btpServiceManagementV1Client.CreateServiceInstance(ctx, input); err != nil {
-		if output != nil && output.ErrorMessage != "" {
+	output, err := btpServiceManagementV1Client.createServiceInstance(ctx, input)
+	if err != nil {
+		return diag.Errorf("BTP Sub Account ServiceManagement Instances can't be created; %v", err)
+	}
+
+	if output.Location != "" {
+		operation, err := operationFromLocation(output.Location)
+		if err != nil {
 			return diag.FromErr(
-				errors.Errorf("BTP Sub Account ServiceManagement Instances can't be created; %s", output.ErrorMessage))
+				errors.Errorf("BTP Sub Account ServiceManagement Instances can't be created; %v", err))
+		}
+		d.SetId(operation.ResourceID)
+
+		if err := waitForServiceManagementOperation(ctx, btpServiceManagementV1Client, output.Location,
+			d.Timeout(schema.TimeoutCreate)); err != nil {
+			return diag.FromErr(
+				errors.Errorf("BTP Sub Account ServiceManagement Instances can't be created; %v", err))
 		}
 	} else {
 		d.SetId(output.Id)
-		d.Set("service_plan_id", output.ServicePlanId)
-		d.Set("ready", output.Ready)
-		d.Set("platform_id", output.PlatformId)
-		d.Set("dashboard_url", output.DashboardUrl)
-		d.Set("context", output.Context)
-		d.Set("maintenance_info", output.MaintenanceInfo)
-		d.Set("usable", output.Usable)
 	}
 
-	return nil
+	return resourceSapBtpSubAccountServiceManagementInstancesRead(ctx, d, meta)
 }
 
 func resourceSapBtpSubAccountServiceManagementInstancesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	session := meta.(*SAPClient).session
-	serviceList := d.Get("service_management").([]interface{})
-	if len(serviceList) < 1 {
-		return diag.Errorf("Service management is required")
+	btpServiceManagementV1Client, err := meta.(*SAPClient).serviceManagementV1Client(d, "service_management")
+	if err != nil {
+		return diag.FromErr(errors.Errorf("BTP Service Management OAuth2;  %v", err))
 	}
 
-	err := session.AddEndpointWithReplace(btpmanagment.EndpointsID, extractEndpointConfig(serviceList))
+	input := &btpmanagment.GetServiceInstanceInput{
+		ServiceInstanceID: d.Id(),
+	}
+	output, err := btpServiceManagementV1Client.GetServiceInstance(ctx, input)
+	if err != nil {
+		smErr := newServiceManagementError(err, output.Error, output.StatusAndBodyFromResponse)
+		if smErr.IsNotFound() {
+			log.Printf("[WARN] BTP Sub Account ServiceManagement Instance %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return diag.Errorf("BTP Sub Account ServiceManagement Instances can't be read; %v", smErr)
+	}
+
+	d.Set("service_plan_id", output.ServicePlanId)
+	d.Set("ready", output.Ready)
+	d.Set("platform_id", output.PlatformId)
+	d.Set("dashboard_url", output.DashboardUrl)
+	d.Set("context", output.Context)
+	d.Set("maintenance_info", output.MaintenanceInfo)
+	d.Set("usable", output.Usable)
+	return nil
+}
+
+func resourceSapBtpSubAccountServiceManagementInstancesUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	btpServiceManagementV1Client, err := meta.(*SAPClient).serviceManagementV1Client(d, "service_management")
 	if err != nil {
 		return diag.FromErr(errors.Errorf("BTP Service Management OAuth2;  %v", err))
 	}
 
-	btpServiceManagementV1Client := btpmanagment.New(session)
+	// Only the way operations run changed, nothing to send
+	if !d.HasChanges("name", "service_plan_id", "service_plan_name", "parameters", "labels") {
+		return resourceSapBtpSubAccountServiceManagementInstancesRead(ctx, d, meta)
+	}
 
-	input := &btpmanagment.GetServiceInstanceInput{
+	input := &updateServiceInstanceInput{
 		ServiceInstanceID: d.Id(),
+		Async:             d.Get("async").(bool),
 	}
-	if output, err := btpServiceManagementV1Client.GetServiceInstance(ctx, input); err != nil {
-		if output != nil && output.ErrorMessage != "" {
+	if d.HasChange("name") {
+		input.Name = d.Get("name").(string)
+	}
+	if planId := d.Get("service_plan_id").(string); d.HasChange("service_plan_id") && planId != "" {
+		input.ServicePlanId = planId
+	} else if d.HasChange("service_plan_name") {
+		// The new plan is looked up by name, within the offering of the current plan; its id is only known
+		// after the update, when the plan was changed by name
+		oldPlanId, _ := d.GetChange("service_plan_id")
+		offering, err := serviceOfferingOfPlan(ctx, btpServiceManagementV1Client, oldPlanId.(string))
+		if err != nil {
 			return diag.FromErr(
-				errors.Errorf("BTP Sub Account ServiceManagement Instances can't be created; %s", output.ErrorMessage))
+				errors.Errorf("BTP Sub Account ServiceManagement Instances can't be updated; %v", err))
 		}
-	} else {
-		d.Set("service_plan_id", output.ServicePlanId)
-		d.Set("ready", output.Ready)
-		d.Set("platform_id", output.PlatformId)
-		d.Set("dashboard_url", output.DashboardUrl)
-		d.Set("context", output.Context)
-		d.Set("maintenance_info", output.MaintenanceInfo)
-		d.Set("usable", output.Usable)
+		planId, err := servicePlanIdByName(ctx, btpServiceManagementV1Client, offering.Id,
+			d.Get("service_plan_name").(string))
+		if err != nil {
+			return diag.FromErr(
+				errors.Errorf("BTP Sub Account ServiceManagement Instances can't be updated; %v", err))
+		}
+		input.ServicePlanId = planId
 	}
-	return nil
+	if d.HasChange("parameters") {
+		input.Parameters = expandMapString(d.Get("parameters"))
+	}
+	if d.HasChange("labels") {
+		oldLabels, newLabels := d.GetChange("labels")
+		input.Labels = labelOperations(expandLabels(oldLabels), expandLabels(newLabels))
+	}
+
+	output, err := btpServiceManagementV1Client.updateServiceInstance(ctx, input)
+	if err != nil {
+		return diag.Errorf("BTP Sub Account ServiceManagement Instances can't be updated; %v", err)
+	}
+	if output.Location != "" {
+		if err := waitForServiceManagementOperation(ctx, btpServiceManagementV1Client, output.Location,
+			d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return diag.FromErr(
+				errors.Errorf("BTP Sub Account ServiceManagement Instances can't be updated; %v", err))
+		}
+	}
+
+	return resourceSapBtpSubAccountServiceManagementInstancesRead(ctx, d, meta)
 }
 
-func resourceSapBtpSubAccountServiceManagementInstancesUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	//session := meta.(*SAPClient).session
-	//serviceList := d.Get("service_management").([]interface{})
-	//if len(serviceList) < 1 {
-	//	return diag.Errorf("Service management is required")
-	//}
-	//
-	//	err := session.AddEndpointWithReplace(btpmanagment.EndpointsID, extractEndpointConfig(serviceList))
-	//	if err != nil {
-	//		return diag.FromErr(errors.Errorf("BTP Service Management OAuth2;  %v", err))
-	//	}
-	//
-	//btpServiceManagementV1Client := btpmanagment.New(session)
-	//
-	//input := &btpmanagment.UpdateServiceInstanceInput {
-	//	ServiceInstanceID: d.Id(),
-	//	Async: false,
-	//	Name: d.Get("name").(string),
-	//	Parameters: expandMapString(d.Get("parameters")),
-	//	Labels: expandMapListString(d.Get("labels")),
-	//}
-	//if val, ok := d.GetOk("service_plan_id"); ok {
-	//	input.ServicePlanId = val.(string)
-	//}
-	//if output, err := btpServiceManagementV1Client.UpdateServiceInstance(ctx, input); err != nil {
-	//	if output != nil && output.ErrorMessage != "" {
-	//		return diag.FromErr(
-	//			errors.Errorf("BTP Sub Account ServiceManagement Instances can't be created; %s", output.ErrorMessage))
-	//	}
-	//} else {
-	//	d.Set("service_plan_id", output.ServicePlanId)
-	//	d.Set("ready", output.Ready)
-	//	d.Set("platform_id", output.PlatformId)
-	//	d.Set("dashboard_url", output.DashboardUrl)
-	//	d.Set("context", output.Context)
-	//	d.Set("maintenance_info", output.MaintenanceInfo)
-	//	d.Set("usable", output.Usable)
-	//}
+// Changing the plan of an instance is allowed only if the offering's broker supports it; otherwise the instance
+// is replaced.
+func resourceSapBtpSubAccountServiceManagementInstancesCustomizeDiff(ctx context.Context, d *schema.ResourceDiff,
+	meta interface{}) error {
+
+	if d.Id() == "" {
+		return nil
+	}
+	planIdChanged := d.HasChange("service_plan_id")
+	planNameChanged := d.HasChange("service_plan_name")
+	if !planIdChanged && !planNameChanged {
+		return nil
+	}
+
+	oldPlanId, _ := d.GetChange("service_plan_id")
+	if oldPlanId.(string) == "" {
+		return nil
+	}
+
+	btpServiceManagementV1Client, err := meta.(*SAPClient).serviceManagementV1Client(d, "service_management")
+	if err != nil {
+		return errors.Errorf("BTP Service Management OAuth2;  %v", err)
+	}
+	offering, err := serviceOfferingOfPlan(ctx, btpServiceManagementV1Client, oldPlanId.(string))
+	if err != nil {
+		return err
+	}
+
+	if !offering.PlanUpdateable {
+		if planIdChanged {
+			if err := d.ForceNew("service_plan_id"); err != nil {
+				return err
+			}
+		}
+		if planNameChanged {
+			if err := d.ForceNew("service_plan_name"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if planNameChanged && !planIdChanged {
+		return d.SetNewComputed("service_plan_id")
+	}
 	return nil
 }
 
 func resourceSapBtpSubAccountServiceManagementInstancesDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	session := meta.(*SAPClient).session
-	serviceList := d.Get("service_management").([]interface{})
-	if len(serviceList) < 1 {
-		return diag.Errorf("Service management is required")
-	}
-
-	err := session.AddEndpointWithReplace(btpmanagment.EndpointsID, extractEndpointConfig(serviceList))
+	btpServiceManagementV1Client, err := meta.(*SAPClient).serviceManagementV1Client(d, "service_management")
 	if err != nil {
 		return diag.FromErr(errors.Errorf("BTP Service Management OAuth2;  %v", err))
 	}
 
-	btpServiceManagementV1Client := btpmanagment.New(session)
-
 	input := &btpmanagment.DeleteServiceInstanceInput{
 		ServiceInstanceID: d.Id(),
-		Async:             false,
+		Async:             d.Get("async").(bool),
 	}
-	if output, err := btpServiceManagementV1Client.DeleteServiceInstance(ctx, input); err != nil {
-		if output != nil && output.ErrorMessage != "" {
+	output, err := btpServiceManagementV1Client.deleteServiceInstance(ctx, input)
+	if err != nil {
+		if isServiceManagementNotFound(err) {
+			return nil
+		}
+		return diag.Errorf("BTP Sub Account ServiceManagement Instances can't be deleted; %v", err)
+	}
+	if output.Location != "" {
+		if err := waitForServiceManagementOperation(ctx, btpServiceManagementV1Client, output.Location,
+			d.Timeout(schema.TimeoutDelete)); err != nil {
 			return diag.FromErr(
-				errors.Errorf("BTP Sub Account ServiceManagement Instances can't be created; %s", output.ErrorMessage))
+				errors.Errorf("BTP Sub Account ServiceManagement Instances can't be deleted; %v", err))
 		}
 	}
 	return nil
 }
+
+func serviceOfferingOfPlan(ctx context.Context, client serviceManagementClient,
+	planId string) (*btpmanagment.OfferingItem, error) {
+
+	plan, err := client.GetServicePlan(ctx, &btpmanagment.GetServicePlanInput{
+		ServicePlanID: planId,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(newServiceManagementError(err, plan.Error, plan.StatusAndBodyFromResponse),
+			"service plan %s can't be read", planId)
+	}
+
+	offering, err := client.GetServiceOffering(ctx, &btpmanagment.GetServiceOfferingInput{
+		ServiceOfferingID: plan.ServiceOfferingId,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(newServiceManagementError(err, offering.Error, offering.StatusAndBodyFromResponse),
+			"service offering %s can't be read", plan.ServiceOfferingId)
+	}
+	return &offering.OfferingItem, nil
+}
+
+func servicePlanIdByName(ctx context.Context, client serviceManagementClient,
+	offeringId, planName string) (string, error) {
+
+	plans, err := client.GetServicePlans(ctx, &btpmanagment.GetServicePlansInput{
+		FieldQuery: fmt.Sprintf("name eq '%s' and service_offering_id eq '%s'", planName, offeringId),
+	})
+	if err != nil {
+		return "", errors.Wrapf(newServiceManagementError(err, plans.Error, plans.StatusAndBodyFromResponse),
+			"service plan %s can't be read", planName)
+	}
+	if len(plans.Items) == 0 {
+		return "", errors.Errorf("service plan %s not found for service offering %s", planName, offeringId)
+	}
+	return plans.Items[0].Id, nil
+}
@@ -0,0 +1,278 @@
+package sap
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/nnicora/sap-sdk-go/service/btpmanagment"
+	"strings"
+	"testing"
+)
+
+func TestAccSapBtpSubAccountServiceManagementInstances_basic(t *testing.T) {
+	btp := newMockBtp(t)
+	resourceName := "sap_btp_sub_account_service_management_instances.test"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		CheckDestroy: testAccCheckDestroyed(btp, "sap_btp_sub_account_service_management_instances",
+			btp.instances),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpSubAccountServiceManagementInstancesConfig("test-instance", "application"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "service_plan_id", "plan-xsuaa-application"),
+					resource.TestCheckResourceAttr(resourceName, "ready", "true"),
+					testAccCheckMockObject(btp, resourceName, btp.instances, "name", "test-instance"),
+				),
+			},
+			{
+				// xsuaa plans are updateable, so the instance is changed in place
+				Config: testAccSapBtpSubAccountServiceManagementInstancesConfig("renamed-instance", "broker"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "service_plan_id", "plan-xsuaa-broker"),
+					testAccCheckMockObject(btp, resourceName, btp.instances, "name", "renamed-instance"),
+					testAccCheckMockObject(btp, resourceName, btp.instances, "service_plan_id", "plan-xsuaa-broker"),
+				),
+			},
+		},
+	})
+}
+
+func testAccSapBtpSubAccountServiceManagementInstancesConfig(name, planName string) string {
+	return testAccProviderConfig + fmt.Sprintf(`
+resource "sap_btp_sub_account_service_management_instances" "test" {
+  endpoint_id           = "service-manager"
+  name                  = %q
+  service_offering_name = "xsuaa"
+  service_plan_name     = %q
+  async                 = true
+
+  labels {
+    key    = "team"
+    values = ["instances"]
+  }
+}
+`, name, planName)
+}
+
+// newFakeServiceManagementInstances returns a Service Manager holding the xsuaa offering, whose plans can be
+// changed, along with an instance of its application plan.
+func newFakeServiceManagementInstances() *fakeServiceManagement {
+	return &fakeServiceManagement{
+		offerings: map[string]btpmanagment.OfferingItem{
+			"offering-xsuaa": {Id: "offering-xsuaa", Name: "xsuaa", PlanUpdateable: true},
+		},
+		plans: map[string]btpmanagment.PlanItem{
+			"plan-xsuaa-application": {
+				Id: "plan-xsuaa-application", Name: "application", ServiceOfferingId: "offering-xsuaa",
+			},
+			"plan-xsuaa-broker": {
+				Id: "plan-xsuaa-broker", Name: "broker", ServiceOfferingId: "offering-xsuaa",
+			},
+		},
+		instances: map[string]btpmanagment.InstanceItem{
+			"instance-0": {
+				Id: "instance-0", Name: "existing", ServicePlanId: "plan-xsuaa-application", Ready: true,
+			},
+		},
+	}
+}
+
+func testSapBtpSubAccountServiceManagementInstancesData(t *testing.T, id string) *schema.ResourceData {
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccountServiceManagementInstances().Schema,
+		map[string]interface{}{
+			"endpoint_id":     fakeEndpointId,
+			"name":            "test",
+			"service_plan_id": "plan-xsuaa-application",
+		})
+	d.SetId(id)
+	return d
+}
+
+func TestSapBtpSubAccountServiceManagementInstancesCreate(t *testing.T) {
+	sm := newFakeServiceManagementInstances()
+	meta := newFakeSAPClient(&fakeClientFactory{smClient: sm})
+
+	d := testSapBtpSubAccountServiceManagementInstancesData(t, "")
+	if diags := resourceSapBtpSubAccountServiceManagementInstancesCreate(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "instance-2" {
+		t.Errorf("id is %q, expected %q", d.Id(), "instance-2")
+	}
+	if sm.createdInstance.ServicePlanId != "plan-xsuaa-application" {
+		t.Errorf("instance was created with plan %q", sm.createdInstance.ServicePlanId)
+	}
+	if !d.Get("ready").(bool) {
+		t.Errorf("ready wasn't read back")
+	}
+}
+
+func TestSapBtpSubAccountServiceManagementInstancesCreate_async(t *testing.T) {
+	sm := newFakeServiceManagementInstances()
+	sm.asyncOperationState = "succeeded"
+	meta := newFakeSAPClient(&fakeClientFactory{smClient: sm})
+
+	d := testSapBtpSubAccountServiceManagementInstancesData(t, "")
+	d.Set("async", true)
+	if diags := resourceSapBtpSubAccountServiceManagementInstancesCreate(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if !sm.createdInstance.Async {
+		t.Errorf("instance wasn't created asynchronously")
+	}
+	if d.Id() != "instance-2" {
+		t.Errorf("id is %q, expected the one of the operation's resource %q", d.Id(), "instance-2")
+	}
+}
+
+func TestSapBtpSubAccountServiceManagementInstancesCreate_asyncFailed(t *testing.T) {
+	sm := newFakeServiceManagementInstances()
+	sm.asyncOperationState = "failed"
+	meta := newFakeSAPClient(&fakeClientFactory{smClient: sm})
+
+	d := testSapBtpSubAccountServiceManagementInstancesData(t, "")
+	diags := resourceSapBtpSubAccountServiceManagementInstancesCreate(context.Background(), d, meta)
+	if !diags.HasError() {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(diags[0].Summary, "BrokerError provisioning failed") {
+		t.Errorf("error %q doesn't hold the operation's errors", diags[0].Summary)
+	}
+	// Kept in state, so the failed instance gets replaced
+	if d.Id() != "instance-2" {
+		t.Errorf("id is %q, expected %q", d.Id(), "instance-2")
+	}
+}
+
+func TestSapBtpSubAccountServiceManagementInstancesCreate_error(t *testing.T) {
+	sm := newFakeServiceManagementInstances()
+	sm.failStatus = 400
+	sm.failMessage = "BadRequest"
+	meta := newFakeSAPClient(&fakeClientFactory{smClient: sm})
+
+	d := testSapBtpSubAccountServiceManagementInstancesData(t, "")
+	diags := resourceSapBtpSubAccountServiceManagementInstancesCreate(context.Background(), d, meta)
+	if !diags.HasError() {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(diags[0].Summary, "Operation code 400; BadRequest") {
+		t.Errorf("error %q doesn't hold the API answer", diags[0].Summary)
+	}
+	if d.Id() != "" {
+		t.Errorf("id %q was set for an instance which wasn't created", d.Id())
+	}
+}
+
+func TestSapBtpSubAccountServiceManagementInstancesRead_notFound(t *testing.T) {
+	sm := newFakeServiceManagementInstances()
+	meta := newFakeSAPClient(&fakeClientFactory{smClient: sm})
+
+	d := testSapBtpSubAccountServiceManagementInstancesData(t, "instance-missing")
+	if diags := resourceSapBtpSubAccountServiceManagementInstancesRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "" {
+		t.Errorf("instance %s is still in state", d.Id())
+	}
+}
+
+func TestSapBtpSubAccountServiceManagementInstancesRead_error(t *testing.T) {
+	sm := newFakeServiceManagementInstances()
+	sm.failStatus = 500
+	sm.failMessage = "InternalServerError"
+	meta := newFakeSAPClient(&fakeClientFactory{smClient: sm})
+
+	d := testSapBtpSubAccountServiceManagementInstancesData(t, "instance-0")
+	diags := resourceSapBtpSubAccountServiceManagementInstancesRead(context.Background(), d, meta)
+	if !diags.HasError() {
+		t.Fatalf("expected an error")
+	}
+	if d.Id() != "instance-0" {
+		t.Errorf("instance was removed from state")
+	}
+}
+
+func TestSapBtpSubAccountServiceManagementInstancesUpdate_planName(t *testing.T) {
+	sm := newFakeServiceManagementInstances()
+	meta := newFakeSAPClient(&fakeClientFactory{smClient: sm})
+	r := resourceSapBtpSubAccountServiceManagementInstances()
+
+	state := &terraform.InstanceState{
+		ID: "instance-0",
+		Attributes: map[string]string{
+			"id":                "instance-0",
+			"endpoint_id":       fakeEndpointId,
+			"name":              "existing",
+			"service_plan_id":   "plan-xsuaa-application",
+			"service_plan_name": "application",
+			"async":             "false",
+		},
+	}
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"endpoint_id":       fakeEndpointId,
+		"name":              "existing",
+		"service_plan_name": "broker",
+	})
+
+	diff, err := r.Diff(context.Background(), state, config, meta)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff.RequiresNew() {
+		t.Fatalf("instance is replaced, while its offering allows changing plans")
+	}
+	newState, diags := r.Apply(context.Background(), state, diff, meta)
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if sm.updatedInstance.ServicePlanId != "plan-xsuaa-broker" {
+		t.Errorf("instance was updated with plan %q, expected %q", sm.updatedInstance.ServicePlanId,
+			"plan-xsuaa-broker")
+	}
+	if got := newState.Attributes["service_plan_id"]; got != "plan-xsuaa-broker" {
+		t.Errorf("service_plan_id is %q, expected %q", got, "plan-xsuaa-broker")
+	}
+}
+
+func TestSapBtpSubAccountServiceManagementInstancesDelete(t *testing.T) {
+	sm := newFakeServiceManagementInstances()
+	meta := newFakeSAPClient(&fakeClientFactory{smClient: sm})
+
+	d := testSapBtpSubAccountServiceManagementInstancesData(t, "instance-0")
+	if diags := resourceSapBtpSubAccountServiceManagementInstancesDelete(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if _, ok := sm.instances["instance-0"]; ok {
+		t.Errorf("instance wasn't deleted")
+	}
+}
+
+func TestSapBtpSubAccountServiceManagementInstancesDelete_notFound(t *testing.T) {
+	sm := newFakeServiceManagementInstances()
+	meta := newFakeSAPClient(&fakeClientFactory{smClient: sm})
+
+	d := testSapBtpSubAccountServiceManagementInstancesData(t, "instance-missing")
+	if diags := resourceSapBtpSubAccountServiceManagementInstancesDelete(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("an instance already gone must not fail the delete: %v", diags)
+	}
+}
+
+func TestSapBtpSubAccountServiceManagementInstancesDelete_error(t *testing.T) {
+	sm := newFakeServiceManagementInstances()
+	sm.failStatus = 422
+	sm.failMessage = "UnprocessableEntity"
+	meta := newFakeSAPClient(&fakeClientFactory{smClient: sm})
+
+	d := testSapBtpSubAccountServiceManagementInstancesData(t, "instance-0")
+	diags := resourceSapBtpSubAccountServiceManagementInstancesDelete(context.Background(), d, meta)
+	if !diags.HasError() {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(diags[0].Summary, "Operation code 422; UnprocessableEntity") {
+		t.Errorf("error %q doesn't hold the API answer", diags[0].Summary)
+	}
+}
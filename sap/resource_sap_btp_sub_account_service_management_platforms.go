@@ -6,11 +6,13 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/nnicora/sap-sdk-go/service/btpmanagment"
 	"github.com/pkg/errors"
+	"log"
 	"time"
 )
 
 func resourceSapBtpSubAccountServiceManagementPlatforms() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
+		SchemaVersion: 1,
 		CreateContext: resourceSapBtpSubAccountServiceManagementPlatformsCreate,
 		ReadContext:   resourceSapBtpSubAccountServiceManagementPlatformsRead,
 		UpdateContext: resourceSapBtpSubAccountServiceManagementPlatformsUpdate,
@@ -23,82 +25,8 @@ func resourceSapBtpSubAccountServiceManagementPlatforms() *schema.Resource {
 			Delete: schema.DefaultTimeout(3 * time.Minute),
 		},
 		Schema: map[string]*schema.Schema{
-			"service_management": {
-				Type:     schema.TypeList,
-				Required: true,
-				MaxItems: 1,
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-
-						"host": {
-							Type:     schema.TypeString,
-							Required: true,
-						},
-						"oauth2": {
-							Type:     schema.TypeList,
-							Required: true,
-							MaxItems: 1,
-							Elem: &schema.Resource{
-								Schema: map[string]*schema.Schema{
-									"grant_type": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "client_credentials",
-										Description: "SAP OAuth2 Grant Type.",
-									},
-									"client_id": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Client Id.",
-									},
-									"client_secret": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Client Secret.",
-									},
-									"token_url": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Token Url.",
-									},
-									"authorization_url": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Authorization Url.",
-									},
-									"redirect_url": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Redirect Url.",
-									},
-
-									"username": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Username. Used in case if 'grant_type=password'.",
-									},
-									"password": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Password. Used in case if 'grant_type=password'.",
-									},
-
-									"timeout_seconds": {
-										Type:        schema.TypeInt,
-										Optional:    true,
-										Default:     60,
-										Description: "SAP OAuth2 HTTP Client timeout.",
-									},
-								},
-							},
-						},
-					},
-				},
-			},
+			"endpoint_id":        endpointIdSchema("service_management"),
+			"service_management": endpointSchema("service_management"),
 
 			"name": {
 				Type:     schema.TypeString,
@@ -113,25 +41,23 @@ func resourceSapBtpSubAccountServiceManagementPlatforms() *schema.Resource {
 				Optional: true,
 			},
 
-			"labels": {
-				Type:     schema.TypeMap,
-				Optional: true,
-				Elem:     &schema.Schema{Type: schema.TypeList},
-			},
+			"labels": labelsSchema(),
 
 			"ready": {
 				Type:     schema.TypeBool,
 				Computed: true,
 			},
 			"credentials": {
-				Type:     schema.TypeList,
-				Computed: true,
+				Type:      schema.TypeList,
+				Computed:  true,
+				Sensitive: true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"basic": {
-							Type:     schema.TypeMap,
-							Computed: true,
-							Elem:     &schema.Schema{Type: schema.TypeString},
+							Type:      schema.TypeMap,
+							Computed:  true,
+							Sensitive: true,
+							Elem:      &schema.Schema{Type: schema.TypeString},
 						},
 					},
 				},
@@ -140,93 +66,76 @@ func resourceSapBtpSubAccountServiceManagementPlatforms() *schema.Resource {
 			"tags": tagsSchema(),
 		},
 	}
+	r.StateUpgraders = []schema.StateUpgrader{
+		labelsStateUpgraderV0(r),
+	}
+	return r
 }
 
 func resourceSapBtpSubAccountServiceManagementPlatformsCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	session := meta.(*SAPClient).session
-	serviceList := d.Get("service_management").([]interface{})
-	if len(serviceList) < 1 {
-		return diag.Errorf("Service management is required")
-	}
-
-	err := session.AddEndpointWithReplace(btpmanagment.EndpointsID, extractEndpointConfig(serviceList))
+	btpServiceManagementV1Client, err := meta.(*SAPClient).serviceManagementV1Client(d, "service_management")
 	if err != nil {
 		return diag.FromErr(errors.Errorf("BTP Service Management OAuth2;  %v", err))
 	}
 
-	btpServiceManagementV1Client := btpmanagment.New(session)
-
 	input := &btpmanagment.CreatePlatformInput{
 		Name:        d.Get("name").(string),
 		Type:        d.Get("type").(string),
 		Description: d.Get("description").(string),
-		Labels:      expandMapListString(d.Get("labels")),
+		Labels:      expandLabels(d.Get("labels")),
+	}
+	output, err := btpServiceManagementV1Client.CreatePlatform(ctx, input)
+	if err != nil {
+		return diag.Errorf("BTP Sub Account ServiceManagement Platforms can't be created; %v",
+			newServiceManagementError(err, output.Error, output.StatusAndBodyFromResponse))
 	}
-	if output, err := btpServiceManagementV1Client.CreatePlatform(ctx, input); err != nil {
-		if output != nil && output.ErrorMessage != "" {
-			return diag.FromErr(
-				errors.Errorf("BTP Sub Account ServiceManagement Platforms can't be created; %s", output.ErrorMessage))
-		}
-	} else {
-		d.SetId(output.Id)
-		d.Set("ready", output.Ready)
 
-		basic := map[string]string{
-			"username": output.Credentials.Basic.Username,
-			"password": output.Credentials.Basic.Password,
-		}
+	d.SetId(output.Id)
+	d.Set("ready", output.Ready)
 
-		credentials := make([]map[string]string, 1)
-		credentials[0] = basic
-		d.Set("credentials", credentials)
+	basic := map[string]string{
+		"username": output.Credentials.Basic.Username,
+		"password": output.Credentials.Basic.Password,
 	}
 
+	credentials := make([]map[string]string, 1)
+	credentials[0] = basic
+	d.Set("credentials", credentials)
+
 	return nil
 }
 
 func resourceSapBtpSubAccountServiceManagementPlatformsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	session := meta.(*SAPClient).session
-	serviceList := d.Get("service_management").([]interface{})
-	if len(serviceList) < 1 {
-		return diag.Errorf("Service management is required")
-	}
-
-	err := session.AddEndpointWithReplace(btpmanagment.EndpointsID, extractEndpointConfig(serviceList))
+	btpServiceManagementV1Client, err := meta.(*SAPClient).serviceManagementV1Client(d, "service_management")
 	if err != nil {
 		return diag.FromErr(errors.Errorf("BTP Service Management OAuth2;  %v", err))
 	}
 
-	btpServiceManagementV1Client := btpmanagment.New(session)
-
 	input := &btpmanagment.GetPlatformInput{
 		PlatformID: d.Id(),
 	}
-	if output, err := btpServiceManagementV1Client.GetPlatform(ctx, input); err != nil {
-		if output != nil && output.ErrorMessage != "" {
-			return diag.FromErr(
-				errors.Errorf("BTP Sub Account ServiceManagement Platform can't be retrived; %s", output.ErrorMessage))
+	output, err := btpServiceManagementV1Client.GetPlatform(ctx, input)
+	if err != nil {
+		smErr := newServiceManagementError(err, output.Error, output.StatusAndBodyFromResponse)
+		if smErr.IsNotFound() {
+			log.Printf("[WARN] BTP Sub Account ServiceManagement Platform %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
 		}
-	} else {
-		d.SetId(output.Id)
-		d.Set("ready", output.Ready)
+		return diag.Errorf("BTP Sub Account ServiceManagement Platform can't be retrived; %v", smErr)
 	}
+
+	d.SetId(output.Id)
+	d.Set("ready", output.Ready)
 	return nil
 }
 
 func resourceSapBtpSubAccountServiceManagementPlatformsUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	session := meta.(*SAPClient).session
-	serviceList := d.Get("service_management").([]interface{})
-	if len(serviceList) < 1 {
-		return diag.Errorf("Service management is required")
-	}
-
-	err := session.AddEndpointWithReplace(btpmanagment.EndpointsID, extractEndpointConfig(serviceList))
+	btpServiceManagementV1Client, err := meta.(*SAPClient).serviceManagementV1Client(d, "service_management")
 	if err != nil {
 		return diag.FromErr(errors.Errorf("BTP Service Management OAuth2;  %v", err))
 	}
 
-	btpServiceManagementV1Client := btpmanagment.New(session)
-
 	input := &btpmanagment.UpdatePlatformInput{
 		PlatformID:  d.Id(),
 		Id:          d.Id(),
@@ -234,37 +143,33 @@ func resourceSapBtpSubAccountServiceManagementPlatformsUpdate(ctx context.Contex
 		Type:        d.Get("type").(string),
 		Description: d.Get("description").(string),
 	}
+	if d.HasChange("labels") {
+		oldLabels, newLabels := d.GetChange("labels")
+		input.Labels = labelOperations(expandLabels(oldLabels), expandLabels(newLabels))
+	}
 	if output, err := btpServiceManagementV1Client.UpdatePlatform(ctx, input); err != nil {
-		if output != nil && output.ErrorMessage != "" {
-			return diag.FromErr(
-				errors.Errorf("BTP Sub Account ServiceManagement Platform can't be updated; %s", output.ErrorMessage))
-		}
+		return diag.Errorf("BTP Sub Account ServiceManagement Platform can't be updated; %v",
+			newServiceManagementError(err, output.Error, output.StatusAndBodyFromResponse))
 	}
 	return nil
 }
 
 func resourceSapBtpSubAccountServiceManagementPlatformsDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	session := meta.(*SAPClient).session
-	serviceList := d.Get("service_management").([]interface{})
-	if len(serviceList) < 1 {
-		return diag.Errorf("Service manager is required")
-	}
-	err := session.AddEndpointWithReplace(btpmanagment.EndpointsID, extractEndpointConfig(serviceList))
+	btpServiceManagementV1Client, err := meta.(*SAPClient).serviceManagementV1Client(d, "service_management")
 	if err != nil {
 		return diag.FromErr(errors.Errorf("BTP Service Management OAuth2;  %v", err))
 	}
 
-	btpServiceManagementV1Client := btpmanagment.New(session)
-
 	input := &btpmanagment.DeletePlatformInput{
 		PlatformID: d.Id(),
 		Cascade:    true,
 	}
 	if output, err := btpServiceManagementV1Client.DeletePlatform(ctx, input); err != nil {
-		if output != nil && output.ErrorMessage != "" {
-			return diag.FromErr(
-				errors.Errorf("BTP Sub Account ServiceManagement Platform can't be retrived; %s", output.ErrorMessage))
+		smErr := newServiceManagementError(err, output.Error, output.StatusAndBodyFromResponse)
+		if smErr.IsNotFound() {
+			return nil
 		}
+		return diag.Errorf("BTP Sub Account ServiceManagement Platform can't be deleted; %v", smErr)
 	}
 	return nil
 }
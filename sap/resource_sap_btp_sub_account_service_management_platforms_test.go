@@ -0,0 +1,49 @@
+package sap
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"testing"
+)
+
+func TestAccSapBtpSubAccountServiceManagementPlatforms_basic(t *testing.T) {
+	btp := newMockBtp(t)
+	resourceName := "sap_btp_sub_account_service_management_platforms.test"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		CheckDestroy: testAccCheckDestroyed(btp, "sap_btp_sub_account_service_management_platforms",
+			btp.platforms),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpSubAccountServiceManagementPlatformsConfig("Test platform"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "ready", "true"),
+					testAccCheckMockObject(btp, resourceName, btp.platforms, "description", "Test platform"),
+				),
+			},
+			{
+				Config: testAccSapBtpSubAccountServiceManagementPlatformsConfig("Renamed platform"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockObject(btp, resourceName, btp.platforms, "description", "Renamed platform"),
+				),
+			},
+		},
+	})
+}
+
+func testAccSapBtpSubAccountServiceManagementPlatformsConfig(description string) string {
+	return testAccProviderConfig + fmt.Sprintf(`
+resource "sap_btp_sub_account_service_management_platforms" "test" {
+  endpoint_id = "service-manager"
+  name        = "test-platform"
+  type        = "kubernetes"
+  description = %q
+
+  labels {
+    key    = "team"
+    values = ["platform"]
+  }
+}
+`, description)
+}
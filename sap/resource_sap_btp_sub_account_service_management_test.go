@@ -0,0 +1,166 @@
+package sap
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/nnicora/sap-sdk-go/service/btpaccounts"
+	"strings"
+	"testing"
+)
+
+func TestAccSapBtpSubAccountServiceManagement_basic(t *testing.T) {
+	btp := newMockBtp(t)
+	resourceName := "sap_btp_sub_account_service_management.test"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		CheckDestroy: resource.ComposeTestCheckFunc(
+			testAccCheckSubAccountServiceManagementDestroyed(btp),
+			testAccCheckDestroyed(btp, "sap_btp_sub_account", btp.subAccounts),
+		),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpSubAccountServiceManagementConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(resourceName, "sub_account_id", "sap_btp_sub_account.test", "id"),
+					resource.TestCheckResourceAttrSet(resourceName, "client_id"),
+					resource.TestCheckResourceAttrSet(resourceName, "client_secret"),
+					resource.TestCheckResourceAttr(resourceName, "service_management_url", mockBtpHost),
+				),
+			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccCheckSubAccountServiceManagementDestroyed(btp *mockBtp) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type != "sap_btp_sub_account_service_management" {
+				continue
+			}
+			if btp.exists(btp.smBindings, rs.Primary.Attributes["sub_account_id"]) {
+				return fmt.Errorf("service management binding of sub account %s still exists",
+					rs.Primary.Attributes["sub_account_id"])
+			}
+		}
+		return nil
+	}
+}
+
+var testAccSapBtpSubAccountServiceManagementConfig = testAccSapBtpSubAccountConfig("Test Sub Account") + `
+resource "sap_btp_sub_account_service_management" "test" {
+  sub_account_id = sap_btp_sub_account.test.id
+}
+`
+
+func TestSapBtpSubAccountServiceManagementCreate(t *testing.T) {
+	accounts := &fakeAccounts{smBindings: map[string]btpaccounts.ServiceManagementBinding{}}
+	meta := newFakeSAPClient(&fakeClientFactory{accountsClient: accounts})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccountServiceManagement().Schema, map[string]interface{}{
+		"sub_account_id": "sub-account-1",
+	})
+	if diags := resourceSapBtpSubAccountServiceManagementCreate(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "sub-account-1" {
+		t.Errorf("id is %q, expected the sub account %q", d.Id(), "sub-account-1")
+	}
+	if len(accounts.createdSmBindings) != 1 {
+		t.Errorf("%d bindings created, expected 1", len(accounts.createdSmBindings))
+	}
+	if got := d.Get("client_secret").(string); got != "secret-sub-account-1" {
+		t.Errorf("client_secret is %q, expected %q", got, "secret-sub-account-1")
+	}
+}
+
+func TestSapBtpSubAccountServiceManagementCreate_existing(t *testing.T) {
+	accounts := &fakeAccounts{
+		smBindings: map[string]btpaccounts.ServiceManagementBinding{
+			"sub-account-1": {ClientId: "existing-client", ClientSecret: "existing-secret"},
+		},
+	}
+	meta := newFakeSAPClient(&fakeClientFactory{accountsClient: accounts})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccountServiceManagement().Schema, map[string]interface{}{
+		"sub_account_id": "sub-account-1",
+	})
+	diags := resourceSapBtpSubAccountServiceManagementCreate(context.Background(), d, meta)
+	if !diags.HasError() {
+		t.Fatalf("expected an error, as the sub account has already a binding")
+	}
+	if !strings.Contains(diags[0].Summary, "terraform import") {
+		t.Errorf("unexpected error: %s", diags[0].Summary)
+	}
+	if len(accounts.createdSmBindings) != 0 {
+		t.Errorf("a binding was created, while the sub account has already one")
+	}
+	if d.Id() != "" {
+		t.Errorf("the existing binding was taken into state as %q", d.Id())
+	}
+}
+
+func TestSapBtpSubAccountServiceManagementCreate_error(t *testing.T) {
+	accounts := &fakeAccounts{failStatus: 403, failMessage: "Forbidden"}
+	meta := newFakeSAPClient(&fakeClientFactory{accountsClient: accounts})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccountServiceManagement().Schema, map[string]interface{}{
+		"sub_account_id": "sub-account-1",
+	})
+	if diags := resourceSapBtpSubAccountServiceManagementCreate(context.Background(), d, meta); !diags.HasError() {
+		t.Fatalf("expected an error")
+	}
+	if d.Id() != "" {
+		t.Errorf("id %q was set for a binding which wasn't created", d.Id())
+	}
+}
+
+func TestSapBtpSubAccountServiceManagementRead_import(t *testing.T) {
+	accounts := &fakeAccounts{
+		smBindings: map[string]btpaccounts.ServiceManagementBinding{
+			"sub-account-1": {ClientId: "existing-client", ClientSecret: "existing-secret"},
+		},
+	}
+	meta := newFakeSAPClient(&fakeClientFactory{accountsClient: accounts})
+
+	// Imported by the sub account, nothing but the id in state
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccountServiceManagement().Schema, map[string]interface{}{})
+	d.SetId("sub-account-1")
+	if diags := resourceSapBtpSubAccountServiceManagementRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if got := d.Get("sub_account_id").(string); got != "sub-account-1" {
+		t.Errorf("sub_account_id is %q, expected %q", got, "sub-account-1")
+	}
+	if got := d.Get("client_secret").(string); got != "existing-secret" {
+		t.Errorf("client_secret is %q, expected %q", got, "existing-secret")
+	}
+}
+
+func TestSapBtpSubAccountServiceManagementRead_legacyId(t *testing.T) {
+	accounts := &fakeAccounts{
+		smBindings: map[string]btpaccounts.ServiceManagementBinding{
+			"sub-account-1": {ClientId: "existing-client"},
+		},
+	}
+	meta := newFakeSAPClient(&fakeClientFactory{accountsClient: accounts})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccountServiceManagement().Schema, map[string]interface{}{
+		"sub_account_id": "sub-account-1",
+	})
+	d.SetId("4c1a4d6e-0d5f-4bb8-8d8e-0b7f0f2d7a61")
+	if diags := resourceSapBtpSubAccountServiceManagementRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "sub-account-1" {
+		t.Errorf("id is %q, expected it to move to the sub account %q", d.Id(), "sub-account-1")
+	}
+}
@@ -0,0 +1,350 @@
+package sap
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nnicora/sap-sdk-go/service/btpaccounts"
+	"strings"
+	"testing"
+)
+
+func TestAccSapBtpSubAccount_basic(t *testing.T) {
+	btp := newMockBtp(t)
+	resourceName := "sap_btp_sub_account.test"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		CheckDestroy:      testAccCheckDestroyed(btp, "sap_btp_sub_account", btp.subAccounts),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpSubAccountConfig("Test Sub Account"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "display_name", "Test Sub Account"),
+					resource.TestCheckResourceAttr(resourceName, "global_account_id", mockBtpGlobalAccountId),
+					resource.TestCheckResourceAttr(resourceName, "parent_id", mockBtpGlobalAccountId),
+					resource.TestCheckResourceAttr(resourceName, "state", "OK"),
+					testAccCheckMockObject(btp, resourceName, btp.subAccounts, "state", "OK"),
+				),
+			},
+			{
+				Config: testAccSapBtpSubAccountConfig("Renamed Sub Account"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "display_name", "Renamed Sub Account"),
+					resource.TestCheckResourceAttr(resourceName, "state", "OK"),
+					testAccCheckMockObject(btp, resourceName, btp.subAccounts, "displayName", "Renamed Sub Account"),
+				),
+			},
+		},
+	})
+}
+
+func testAccSapBtpSubAccountConfig(displayName string) string {
+	return testAccProviderConfig + fmt.Sprintf(`
+resource "sap_btp_sub_account" "test" {
+  global_account_id   = %[1]q
+  region              = "eu10"
+  display_name        = %[2]q
+  subdomain           = "test-sub-account"
+  used_for_production = "NOT_USED_FOR_PRODUCTION"
+  origin              = "test"
+}
+`, mockBtpGlobalAccountId, displayName)
+}
+
+func TestAccSapBtpSubAccount_customProperties(t *testing.T) {
+	btp := newMockBtp(t)
+	resourceName := "sap_btp_sub_account.test"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		CheckDestroy:      testAccCheckDestroyed(btp, "sap_btp_sub_account", btp.subAccounts),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpSubAccountCustomPropertiesConfig(`{
+    cost_center = "1000"
+    owner       = "team-a"
+  }`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "custom_properties.%", "2"),
+					resource.TestCheckResourceAttr(resourceName, "custom_properties.cost_center", "1000"),
+					resource.TestCheckResourceAttr(resourceName, "custom_properties.owner", "team-a"),
+					testAccCheckMockCustomProperties(btp, resourceName, btp.subAccounts, map[string]string{
+						"cost_center": "1000",
+						"owner":       "team-a",
+					}),
+				),
+			},
+			{
+				Config: testAccSapBtpSubAccountCustomPropertiesConfig(`{
+    owner = "team-b"
+    stage = "dev"
+  }`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "custom_properties.%", "2"),
+					resource.TestCheckResourceAttr(resourceName, "custom_properties.owner", "team-b"),
+					resource.TestCheckResourceAttr(resourceName, "custom_properties.stage", "dev"),
+					testAccCheckMockCustomProperties(btp, resourceName, btp.subAccounts, map[string]string{
+						"owner": "team-b",
+						"stage": "dev",
+					}),
+				),
+			},
+			{
+				Config: testAccSapBtpSubAccountCustomPropertiesConfig("{}"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "custom_properties.%", "0"),
+					testAccCheckMockCustomProperties(btp, resourceName, btp.subAccounts, map[string]string{}),
+				),
+			},
+		},
+	})
+}
+
+func testAccSapBtpSubAccountCustomPropertiesConfig(customProperties string) string {
+	return testAccProviderConfig + fmt.Sprintf(`
+resource "sap_btp_sub_account" "test" {
+  global_account_id   = %[1]q
+  region              = "eu10"
+  display_name        = "Test Sub Account"
+  subdomain           = "test-sub-account"
+  used_for_production = "NOT_USED_FOR_PRODUCTION"
+  origin              = "test"
+  custom_properties   = %[2]s
+}
+`, mockBtpGlobalAccountId, customProperties)
+}
+
+func TestAccSapBtpSubAccount_move(t *testing.T) {
+	btp := newMockBtp(t)
+	resourceName := "sap_btp_sub_account.test"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		CheckDestroy:      testAccCheckDestroyed(btp, "sap_btp_sub_account", btp.subAccounts),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpSubAccountMoveConfig("first"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(resourceName, "parent_id", "sap_btp_directory.first", "id"),
+					testAccCheckMockObjectId(btp, resourceName, btp.subAccounts, "parentGUID", "sap_btp_directory.first"),
+				),
+			},
+			{
+				Config: testAccSapBtpSubAccountMoveConfig("second"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(resourceName, "parent_id", "sap_btp_directory.second", "id"),
+					resource.TestCheckResourceAttr(resourceName, "state", "OK"),
+					testAccCheckMockObjectId(btp, resourceName, btp.subAccounts, "parentGUID", "sap_btp_directory.second"),
+				),
+			},
+			{
+				// Removing the parent moves the sub account back to the global account
+				Config: testAccSapBtpSubAccountMoveConfig(""),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "parent_id", ""),
+					testAccCheckMockObject(btp, resourceName, btp.subAccounts, "parentGUID", mockBtpGlobalAccountId),
+				),
+			},
+		},
+	})
+}
+
+// testAccSapBtpSubAccountMoveConfig places the sub account within the named directory, or the global account when
+// parent is empty.
+func testAccSapBtpSubAccountMoveConfig(parent string) string {
+	parentId := ""
+	if parent != "" {
+		parentId = "parent_id           = sap_btp_directory." + parent + ".id"
+	}
+	return testAccProviderConfig + fmt.Sprintf(`
+resource "sap_btp_directory" "first" {
+  display_name = "First Directory"
+}
+
+resource "sap_btp_directory" "second" {
+  display_name = "Second Directory"
+}
+
+resource "sap_btp_sub_account" "test" {
+  global_account_id   = %[1]q
+  %[2]s
+  region              = "eu10"
+  display_name        = "Moved Sub Account"
+  subdomain           = "moved-sub-account"
+  used_for_production = "NOT_USED_FOR_PRODUCTION"
+  origin              = "test"
+}
+`, mockBtpGlobalAccountId, parentId)
+}
+
+func TestSapBtpSubAccountRead(t *testing.T) {
+	accounts := &fakeAccounts{
+		subAccounts: map[string]btpaccounts.SubAccount{
+			"sub-account-1": {
+				Guid:              "sub-account-1",
+				DisplayName:       "Test Sub Account",
+				GlobalAccountGuid: mockBtpGlobalAccountId,
+				ParentGuid:        mockBtpGlobalAccountId,
+				State:             "OK",
+			},
+		},
+	}
+	meta := newFakeSAPClient(&fakeClientFactory{accountsClient: accounts})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccount().Schema, map[string]interface{}{})
+	d.SetId("sub-account-1")
+	if diags := resourceSapBtpSubAccountRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if got := d.Get("display_name").(string); got != "Test Sub Account" {
+		t.Errorf("display_name is %q, expected %q", got, "Test Sub Account")
+	}
+	if got := d.Get("state").(string); got != "OK" {
+		t.Errorf("state is %q, expected %q", got, "OK")
+	}
+}
+
+func TestSapBtpSubAccountRead_notFound(t *testing.T) {
+	accounts := &fakeAccounts{subAccounts: map[string]btpaccounts.SubAccount{}}
+	meta := newFakeSAPClient(&fakeClientFactory{accountsClient: accounts})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccount().Schema, map[string]interface{}{})
+	d.SetId("sub-account-1")
+	if diags := resourceSapBtpSubAccountRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "" {
+		t.Errorf("sub account %s is still in state", d.Id())
+	}
+}
+
+func TestSapBtpSubAccountRead_error(t *testing.T) {
+	accounts := &fakeAccounts{failStatus: 500, failMessage: "Internal error"}
+	meta := newFakeSAPClient(&fakeClientFactory{accountsClient: accounts})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccount().Schema, map[string]interface{}{})
+	d.SetId("sub-account-1")
+	diags := resourceSapBtpSubAccountRead(context.Background(), d, meta)
+	if !diags.HasError() {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(diags[0].Summary, "Internal error") {
+		t.Errorf("error %q doesn't hold the API message", diags[0].Summary)
+	}
+	if d.Id() != "sub-account-1" {
+		t.Errorf("sub account was removed from state")
+	}
+}
+
+func TestSapBtpSubAccountDelete(t *testing.T) {
+	accounts := &fakeAccounts{
+		subAccounts: map[string]btpaccounts.SubAccount{
+			"sub-account-1": {Guid: "sub-account-1", State: "OK"},
+		},
+	}
+	meta := newFakeSAPClient(&fakeClientFactory{accountsClient: accounts})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccount().Schema, map[string]interface{}{})
+	d.SetId("sub-account-1")
+	if diags := resourceSapBtpSubAccountDelete(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if _, ok := accounts.subAccounts["sub-account-1"]; ok {
+		t.Errorf("sub account wasn't deleted")
+	}
+}
+
+func TestSapBtpSubAccountDelete_error(t *testing.T) {
+	accounts := &fakeAccounts{failStatus: 409, failMessage: "Sub account has entitlements"}
+	meta := newFakeSAPClient(&fakeClientFactory{accountsClient: accounts})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccount().Schema, map[string]interface{}{})
+	d.SetId("sub-account-1")
+	diags := resourceSapBtpSubAccountDelete(context.Background(), d, meta)
+	if !diags.HasError() {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(diags[0].Summary, "Sub account has entitlements") {
+		t.Errorf("error %q doesn't hold the API message", diags[0].Summary)
+	}
+}
+
+func TestSapBtpSubAccountMove(t *testing.T) {
+	accounts := &fakeAccounts{
+		subAccounts: map[string]btpaccounts.SubAccount{
+			"sub-account-1": {Guid: "sub-account-1", ParentGuid: mockBtpGlobalAccountId, State: "OK"},
+		},
+	}
+	meta := newFakeSAPClient(&fakeClientFactory{accountsClient: accounts})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccount().Schema, map[string]interface{}{
+		"parent_id": "directory-1",
+	})
+	d.SetId("sub-account-1")
+	if diags := moveSubAccount(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if got := accounts.subAccounts["sub-account-1"].ParentGuid; got != "directory-1" {
+		t.Errorf("sub account is within %q, expected %q", got, "directory-1")
+	}
+}
+
+func TestSapBtpSubAccountMove_globalAccount(t *testing.T) {
+	accounts := &fakeAccounts{
+		subAccounts: map[string]btpaccounts.SubAccount{
+			"sub-account-1": {Guid: "sub-account-1", ParentGuid: "directory-1", State: "OK"},
+		},
+	}
+	meta := newFakeSAPClient(&fakeClientFactory{accountsClient: accounts})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccount().Schema, map[string]interface{}{
+		"global_account_id": mockBtpGlobalAccountId,
+	})
+	d.SetId("sub-account-1")
+	if diags := moveSubAccount(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if got := accounts.subAccounts["sub-account-1"].ParentGuid; got != mockBtpGlobalAccountId {
+		t.Errorf("sub account is within %q, expected the global account %q", got, mockBtpGlobalAccountId)
+	}
+}
+
+func TestFlattenSubAccountParentId(t *testing.T) {
+	cases := []struct {
+		configured string
+		parentId   string
+		expected   string
+	}{
+		{configured: "", parentId: mockBtpGlobalAccountId, expected: ""},
+		{configured: mockBtpGlobalAccountId, parentId: mockBtpGlobalAccountId, expected: mockBtpGlobalAccountId},
+		{configured: "", parentId: "directory-1", expected: "directory-1"},
+		{configured: "directory-1", parentId: "directory-2", expected: "directory-2"},
+	}
+	for _, c := range cases {
+		d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccount().Schema, map[string]interface{}{
+			"parent_id": c.configured,
+		})
+		if got := flattenSubAccountParentId(d, c.parentId, mockBtpGlobalAccountId); got != c.expected {
+			t.Errorf("parent %q configured as %q is kept as %q, expected %q", c.parentId, c.configured, got, c.expected)
+		}
+	}
+}
+
+func TestSapBtpSubAccountMove_error(t *testing.T) {
+	accounts := &fakeAccounts{failStatus: 400, failMessage: "Target account not found"}
+	meta := newFakeSAPClient(&fakeClientFactory{accountsClient: accounts})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpSubAccount().Schema, map[string]interface{}{
+		"parent_id": "directory-1",
+	})
+	d.SetId("sub-account-1")
+	diags := moveSubAccount(context.Background(), d, meta)
+	if !diags.HasError() {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(diags[0].Summary, "Target account not found") {
+		t.Errorf("error %q doesn't hold the API message", diags[0].Summary)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/nnicora/sap-sdk-go/service/btpsaasmanager"
 	"github.com/pkg/errors"
+	"log"
+	"net/http"
 	"time"
 )
 
@@ -26,82 +28,8 @@ func resourceSapBtpTenantApplicationSubscriptions() *schema.Resource {
 			Delete: schema.DefaultTimeout(3 * time.Minute),
 		},
 		Schema: map[string]*schema.Schema{
-			"saas_manager_service": {
-				Type:     schema.TypeList,
-				Required: true,
-				MaxItems: 1,
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-
-						"host": {
-							Type:     schema.TypeString,
-							Required: true,
-						},
-						"oauth2": {
-							Type:     schema.TypeList,
-							Required: true,
-							MaxItems: 1,
-							Elem: &schema.Resource{
-								Schema: map[string]*schema.Schema{
-									"grant_type": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "client_credentials",
-										Description: "SAP OAuth2 Grant Type.",
-									},
-									"client_id": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Client Id.",
-									},
-									"client_secret": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Client Secret.",
-									},
-									"token_url": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "SAP OAuth2 Token Url.",
-									},
-									"authorization_url": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Authorization Url.",
-									},
-									"redirect_url": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Redirect Url.",
-									},
-
-									"username": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Username. Used in case if 'grant_type=password'.",
-									},
-									"password": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Default:     "",
-										Description: "SAP OAuth2 Password. Used in case if 'grant_type=password'.",
-									},
-
-									"timeout_seconds": {
-										Type:        schema.TypeInt,
-										Optional:    true,
-										Default:     60,
-										Description: "SAP OAuth2 HTTP Client timeout.",
-									},
-								},
-							},
-						},
-					},
-				},
-			},
+			"endpoint_id":          endpointIdSchema("saas_manager_service"),
+			"saas_manager_service": endpointSchema("saas_manager_service"),
 
 			"tenant_id": {
 				Type:         schema.TypeString,
@@ -134,16 +62,10 @@ func resourceSapBtpTenantApplicationSubscriptions() *schema.Resource {
 
 func resourceSapBtpTenantApplicationSubscriptionsCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	//btpSaasManagerV1Client := meta.(*SAPClient).btpSaasManagerV1Client
-	session := meta.(*SAPClient).session
-	serviceList := d.Get("saas_manager_service").([]interface{})
-	if len(serviceList) < 1 {
-		return diag.Errorf("SaaS manager service is required")
-	}
-	err := session.AddEndpointWithReplace(btpsaasmanager.EndpointsID, extractEndpointConfig(serviceList))
+	btpSaasManagerV1Client, err := meta.(*SAPClient).saasManagerV1Client(d, "saas_manager_service")
 	if err != nil {
 		return diag.FromErr(errors.Errorf("BTP SaaS Management OAuth2;  %v", err))
 	}
-	btpSaasManagerV1Client := btpsaasmanager.New(session)
 
 	tenantId := d.Get("tenant_id")
 	input := &btpsaasmanager.SubscribeTenantToApplicationInput{
@@ -188,23 +110,51 @@ func resourceSapBtpTenantApplicationSubscriptionsCreate(ctx context.Context, d *
 }
 
 func resourceSapBtpTenantApplicationSubscriptionsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	//btpAccountsV1Client := meta.(*SAPClient).btpAccountsV1Client
+	btpSaasManagerV1Client, err := meta.(*SAPClient).saasManagerV1Client(d, "saas_manager_service")
+	if err != nil {
+		return diag.FromErr(errors.Errorf("BTP SaaS Management OAuth2;  %v", err))
+	}
 
+	tenantId := d.Id()
+	input := &btpsaasmanager.GetApplicationSubscriptionsInput{
+		TenantId: tenantId,
+	}
+	output, err := btpSaasManagerV1Client.GetApplicationSubscriptions(ctx, input)
+	if err != nil {
+		if output != nil && output.StatusCode == http.StatusNotFound {
+			log.Printf("[WARN] BTP SaaS Subscription of tenant %s not found, removing from state", tenantId)
+			d.SetId("")
+			return nil
+		} else if output != nil && output.Error != "" {
+			return diag.Errorf("BTP SaaS Subscription can't be read; Operation code %v; %s",
+				output.StatusCode, output.Error)
+		}
+		return diag.Errorf("BTP SaaS Subscription can't be read;  %v", err)
+	}
+
+	subscribed := false
+	for _, subscription := range output.Values {
+		if subscription.ConsumerTenantId == tenantId && subscription.State != "NOT_SUBSCRIBED" {
+			subscribed = true
+			break
+		}
+	}
+	if !subscribed {
+		log.Printf("[WARN] BTP SaaS Subscription of tenant %s not found, removing from state", tenantId)
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("tenant_id", tenantId)
 	return nil
 }
 
 func resourceSapBtpTenantApplicationSubscriptionsUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	//btpSaasManagerV1Client := meta.(*SAPClient).btpSaasManagerV1Client
-	session := meta.(*SAPClient).session
-	serviceList := d.Get("saas_manager_service").([]interface{})
-	if len(serviceList) < 1 {
-		return diag.Errorf("SaaS manager service is required")
-	}
-	err := session.AddEndpointWithReplace(btpsaasmanager.EndpointsID, extractEndpointConfig(serviceList))
+	btpSaasManagerV1Client, err := meta.(*SAPClient).saasManagerV1Client(d, "saas_manager_service")
 	if err != nil {
 		return diag.FromErr(errors.Errorf("BTP SaaS Management OAuth2;  %v", err))
 	}
-	btpSaasManagerV1Client := btpsaasmanager.New(session)
 
 	tenantId := d.Get("tenant_id")
 	input := &btpsaasmanager.UpdateSubscriptionDependenciesInput{
@@ -263,18 +213,11 @@ func resourceSapBtpTenantApplicationSubscriptionsUpdate(ctx context.Context, d *
 
 func resourceSapBtpTenantApplicationSubscriptionsDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	//btpSaasManagerV1Client := meta.(*SAPClient).btpSaasManagerV1Client
-	session := meta.(*SAPClient).session
-	serviceList := d.Get("saas_manager_service").([]interface{})
-	if len(serviceList) < 1 {
-		return diag.Errorf("SaaS manager service is required")
-	}
-	err := session.AddEndpointWithReplace(btpsaasmanager.EndpointsID, extractEndpointConfig(serviceList))
+	btpSaasManagerV1Client, err := meta.(*SAPClient).saasManagerV1Client(d, "saas_manager_service")
 	if err != nil {
 		return diag.FromErr(errors.Errorf("BTP SaaS Management OAuth2;  %v", err))
 	}
 
-	btpSaasManagerV1Client := btpsaasmanager.New(session)
-
 	tenantId := d.Get("tenant_id")
 	input := &btpsaasmanager.UnSubscribeTenantFromApplicationInput{
 		TenantId: tenantId.(string),
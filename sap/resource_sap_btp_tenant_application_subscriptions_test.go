@@ -0,0 +1,87 @@
+package sap
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nnicora/sap-sdk-go/service/btpsaasmanager"
+	"testing"
+)
+
+func TestAccSapBtpTenantApplicationSubscriptions_basic(t *testing.T) {
+	btp := newMockBtp(t)
+	resourceName := "sap_btp_tenant_application_subscriptions.test"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: btp.providerFactories(),
+		CheckDestroy: testAccCheckDestroyed(btp, "sap_btp_tenant_application_subscriptions",
+			btp.subscriptions),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSapBtpTenantApplicationSubscriptionsConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "id", "test-tenant"),
+					testAccCheckMockObject(btp, resourceName, btp.subscriptions, "tenantId", "test-tenant"),
+				),
+			},
+			{
+				// A subscription cancelled outside of Terraform is subscribed again
+				PreConfig: func() {
+					btp.unsubscribe("test-tenant")
+				},
+				Config: testAccSapBtpTenantApplicationSubscriptionsConfig,
+				Check:  testAccCheckMockObject(btp, resourceName, btp.subscriptions, "tenantId", "test-tenant"),
+			},
+		},
+	})
+}
+
+const testAccSapBtpTenantApplicationSubscriptionsConfig = testAccProviderConfig + `
+resource "sap_btp_tenant_application_subscriptions" "test" {
+  endpoint_id = "saas-manager"
+  tenant_id   = "test-tenant"
+}
+`
+
+func testSapBtpTenantApplicationSubscriptionsRead(t *testing.T,
+	subscriptions map[string]btpsaasmanager.ApplicationSubscription) *schema.ResourceData {
+
+	meta := newFakeSAPClient(&fakeClientFactory{
+		saasManagerClient: &fakeSaasManager{subscriptions: subscriptions},
+	})
+
+	d := schema.TestResourceDataRaw(t, resourceSapBtpTenantApplicationSubscriptions().Schema, map[string]interface{}{
+		"endpoint_id": fakeEndpointId,
+		"tenant_id":   "tenant-1",
+	})
+	d.SetId("tenant-1")
+	if diags := resourceSapBtpTenantApplicationSubscriptionsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	return d
+}
+
+func TestSapBtpTenantApplicationSubscriptionsRead(t *testing.T) {
+	d := testSapBtpTenantApplicationSubscriptionsRead(t, map[string]btpsaasmanager.ApplicationSubscription{
+		"tenant-1": {ConsumerTenantId: "tenant-1", State: "SUBSCRIBED"},
+	})
+	if d.Id() != "tenant-1" {
+		t.Errorf("subscription was removed from state, while the tenant is subscribed")
+	}
+}
+
+func TestSapBtpTenantApplicationSubscriptionsRead_unsubscribed(t *testing.T) {
+	d := testSapBtpTenantApplicationSubscriptionsRead(t, map[string]btpsaasmanager.ApplicationSubscription{
+		"tenant-1": {ConsumerTenantId: "tenant-1", State: "NOT_SUBSCRIBED"},
+	})
+	if d.Id() != "" {
+		t.Errorf("subscription wasn't removed from state, while the tenant isn't subscribed")
+	}
+}
+
+func TestSapBtpTenantApplicationSubscriptionsRead_notFound(t *testing.T) {
+	d := testSapBtpTenantApplicationSubscriptionsRead(t, map[string]btpsaasmanager.ApplicationSubscription{})
+	if d.Id() != "" {
+		t.Errorf("subscription wasn't removed from state, while the tenant is gone")
+	}
+}
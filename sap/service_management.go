@@ -0,0 +1,259 @@
+package sap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/nnicora/sap-sdk-go/sap/http/request"
+	"github.com/nnicora/sap-sdk-go/service/btpmanagment"
+	"github.com/nnicora/sap-sdk-go/service/types"
+	"github.com/pkg/errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// serviceManagementError classifies a failed Service Manager call by its HTTP status and by the error the
+// Service Manager, or the broker behind it, answered with.
+type serviceManagementError struct {
+	StatusCode  int32
+	Message     string
+	Description string
+	Cause       error
+}
+
+func newServiceManagementError(err error, apiError btpmanagment.Error,
+	response types.StatusAndBodyFromResponse) *serviceManagementError {
+
+	return &serviceManagementError{
+		StatusCode:  response.StatusCode,
+		Message:     apiError.ErrorMessage,
+		Description: apiError.ErrorDescription,
+		Cause:       err,
+	}
+}
+
+func (e *serviceManagementError) Error() string {
+	parts := make([]string, 0, 3)
+	if e.StatusCode > 0 {
+		parts = append(parts, fmt.Sprintf("Operation code %d", e.StatusCode))
+	}
+	if e.Message != "" {
+		parts = append(parts, e.Message)
+	}
+	if e.Description != "" {
+		parts = append(parts, e.Description)
+	}
+	if (e.Message == "" && e.Description == "") && e.Cause != nil {
+		parts = append(parts, e.Cause.Error())
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (e *serviceManagementError) Unwrap() error {
+	return e.Cause
+}
+
+func (e *serviceManagementError) IsNotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// isServiceManagementNotFound tells whether err is a Service Manager answer that the resource doesn't exist
+func isServiceManagementNotFound(err error) bool {
+	var smErr *serviceManagementError
+	return errors.As(err, &smErr) && smErr.IsNotFound()
+}
+
+// Service Manager answers asynchronous operations with '202 Accepted' and the location of the operation to follow,
+// a header which the sdk outputs don't expose; hence instances and bindings are changed through the requests of
+// serviceManagementV1.
+
+type createServiceInstanceOutput struct {
+	btpmanagment.CreateServiceInstanceOutput
+	Location string `src:"header" src-name:"Location" json:"-"`
+}
+
+type updateServiceInstanceOutput struct {
+	btpmanagment.UpdateServiceInstanceOutput
+	Location string `src:"header" src-name:"Location" json:"-"`
+}
+
+type deleteServiceInstanceOutput struct {
+	btpmanagment.DeleteServiceInstanceOutput
+	Location string `src:"header" src-name:"Location" json:"-"`
+}
+
+type createServiceBindingOutput struct {
+	btpmanagment.CreateServiceBindingOutput
+	Location string `src:"header" src-name:"Location" json:"-"`
+}
+
+type deleteServiceBindingOutput struct {
+	btpmanagment.DeleteServiceBindingOutput
+	Location string `src:"header" src-name:"Location" json:"-"`
+}
+
+// Service Manager changes the labels of an instance through add/remove operations, which the sdk update input
+// can't express
+type updateServiceInstanceInput struct {
+	ServiceInstanceID string `dest:"uri" dest-name:"serviceInstanceID" json:"-"`
+	Async             bool   `dest:"querystring" dest-name:"async" json:"-"`
+
+	Name          string               `json:"name,omitempty"`
+	ServicePlanId string               `json:"service_plan_id,omitempty"`
+	Parameters    map[string]string    `json:"parameters,omitempty"`
+	Labels        []btpmanagment.Label `json:"labels,omitempty"`
+}
+
+// The sdk plan items lack the JSON schemas of the parameters a plan takes
+type servicePlanItem struct {
+	btpmanagment.PlanItem
+	Schemas json.RawMessage `json:"schemas,omitempty"`
+}
+
+type getServicePlansOutput struct {
+	Token    string            `json:"token,omitempty"`
+	NumItems int64             `json:"num_items,omitempty"`
+	Items    []servicePlanItem `json:"items,omitempty"`
+
+	btpmanagment.Error
+	types.StatusAndBodyFromResponse
+}
+
+// serviceManagementV1 is the sdk client along with the requests the provider sends itself.
+type serviceManagementV1 struct {
+	*btpmanagment.ServiceManagementV1
+}
+
+func (c *serviceManagementV1) createServiceInstance(ctx context.Context,
+	input *btpmanagment.CreateServiceInstanceInput) (*createServiceInstanceOutput, error) {
+
+	output := &createServiceInstanceOutput{}
+	if err := sendServiceManagement(ctx, c.ServiceManagementV1, request.POST, "/service_instances", input, output); err != nil {
+		return output, newServiceManagementError(err, output.Error, output.StatusAndBodyFromResponse)
+	}
+	return output, nil
+}
+
+func (c *serviceManagementV1) updateServiceInstance(ctx context.Context,
+	input *updateServiceInstanceInput) (*updateServiceInstanceOutput, error) {
+
+	output := &updateServiceInstanceOutput{}
+	if err := sendServiceManagement(ctx, c.ServiceManagementV1, request.PATCH, "/service_instances/{serviceInstanceID}",
+		input, output); err != nil {
+		return output, newServiceManagementError(err, output.Error, output.StatusAndBodyFromResponse)
+	}
+	return output, nil
+}
+
+func (c *serviceManagementV1) deleteServiceInstance(ctx context.Context,
+	input *btpmanagment.DeleteServiceInstanceInput) (*deleteServiceInstanceOutput, error) {
+
+	output := &deleteServiceInstanceOutput{}
+	if err := sendServiceManagement(ctx, c.ServiceManagementV1, request.DELETE, "/service_instances/{serviceInstanceID}",
+		input, output); err != nil {
+		return output, newServiceManagementError(err, output.Error, output.StatusAndBodyFromResponse)
+	}
+	return output, nil
+}
+
+func (c *serviceManagementV1) createServiceBinding(ctx context.Context,
+	input *btpmanagment.CreateServiceBindingInput) (*createServiceBindingOutput, error) {
+
+	output := &createServiceBindingOutput{}
+	if err := sendServiceManagement(ctx, c.ServiceManagementV1, request.POST, "/service_bindings", input, output); err != nil {
+		return output, newServiceManagementError(err, output.Error, output.StatusAndBodyFromResponse)
+	}
+	return output, nil
+}
+
+func (c *serviceManagementV1) deleteServiceBinding(ctx context.Context,
+	input *btpmanagment.DeleteServiceBindingInput) (*deleteServiceBindingOutput, error) {
+
+	output := &deleteServiceBindingOutput{}
+	if err := sendServiceManagement(ctx, c.ServiceManagementV1, request.DELETE, "/service_bindings/{serviceBindingID}",
+		input, output); err != nil {
+		return output, newServiceManagementError(err, output.Error, output.StatusAndBodyFromResponse)
+	}
+	return output, nil
+}
+
+func (c *serviceManagementV1) getServicePlans(ctx context.Context,
+	input *btpmanagment.GetServicePlansInput) (*getServicePlansOutput, error) {
+
+	output := &getServicePlansOutput{}
+	if err := sendServiceManagement(ctx, c.ServiceManagementV1, request.GET, "/service_plans", input, output); err != nil {
+		return output, newServiceManagementError(err, output.Error, output.StatusAndBodyFromResponse)
+	}
+	return output, nil
+}
+
+func sendServiceManagement(ctx context.Context, client *btpmanagment.ServiceManagementV1, method request.HTTPMethod,
+	path string, input, output interface{}) error {
+
+	op := &request.Operation{
+		Name: "Service Management",
+		Http: request.HTTP{
+			Method: method,
+			Path:   path,
+		},
+	}
+	return client.NewRequest(ctx, op, input, output).Send()
+}
+
+// operationFromLocation splits an operation location, like
+// /v1/service_instances/{resourceID}/operations/{operationID}, into the input to get its status.
+func operationFromLocation(location string) (*btpmanagment.GetOperationStatusInput, error) {
+	parts := strings.Split(strings.Trim(location, "/"), "/")
+	for idx := range parts {
+		if parts[idx] == "operations" && idx >= 2 && idx+1 < len(parts) {
+			return &btpmanagment.GetOperationStatusInput{
+				ResourceType: parts[idx-2],
+				ResourceID:   parts[idx-1],
+				OperationID:  parts[idx+1],
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected operation location '%s'", location)
+}
+
+// waitForServiceManagementOperation polls the operation found at location until it succeeds or fails.
+func waitForServiceManagementOperation(ctx context.Context, client serviceManagementClient,
+	location string, timeout time.Duration) error {
+
+	input, err := operationFromLocation(location)
+	if err != nil {
+		return err
+	}
+
+	stateConf := &resource.StateChangeConf{
+		Pending: []string{"in progress", "pending"},
+		Target:  []string{"succeeded"},
+		Refresh: func() (interface{}, string, error) {
+			output, err := client.GetOperationStatus(ctx, input)
+			if err != nil {
+				return nil, "", fmt.Errorf("operation %s can't be read; %v", input.OperationID,
+					newServiceManagementError(err, output.Error, output.StatusAndBodyFromResponse))
+			}
+
+			if output.State == "failed" {
+				descriptions := make([]string, 0, len(output.Errors))
+				for _, e := range output.Errors {
+					descriptions = append(descriptions, strings.TrimSpace(e.ErrorMessage+" "+e.ErrorDescription))
+				}
+				if len(descriptions) == 0 {
+					descriptions = append(descriptions, output.Description)
+				}
+				return output, output.State, fmt.Errorf("operation %s failed; %s",
+					input.OperationID, strings.Join(descriptions, "; "))
+			}
+			return output, output.State, nil
+		},
+		Timeout:    timeout,
+		Delay:      2 * time.Second,
+		MinTimeout: 5 * time.Second,
+	}
+	_, err = stateConf.WaitForStateContext(ctx)
+	return err
+}
@@ -4,7 +4,6 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/nnicora/sap-sdk-go/sap"
 	"github.com/nnicora/sap-sdk-go/sap/oauth2"
-	"log"
 	"time"
 )
 
@@ -103,12 +102,15 @@ func extractEndpointConfig(services []interface{}) *sap.EndpointConfig {
 	service := services[0].(map[string]interface{})
 
 	oauth2Map := mapFrom(service["oauth2"])
-	log.Printf("[DEBUG] OAuth2 configuration: %v", oauth2Map)
 
-	return &sap.EndpointConfig{
-		Host:   service["host"].(string),
-		OAuth2: oauth2ConfigFrom(oauth2Map),
+	endpointConfig := &sap.EndpointConfig{
+		Host: service["host"].(string),
 	}
+	// Without an own oauth2 block, the provider level oauth2 configuration is used
+	if len(oauth2Map) != 0 {
+		endpointConfig.OAuth2 = oauth2ConfigFrom(oauth2Map)
+	}
+	return endpointConfig
 }
 
 func oauth2ConfigFrom(oauth2Map map[string]interface{}) *oauth2.Config {
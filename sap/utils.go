@@ -4,10 +4,13 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"log"
+	"net/http"
 	"reflect"
 	"regexp"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/nnicora/sap-sdk-go/sap"
+	"github.com/nnicora/sap-sdk-go/service/types"
 )
 
 // Base64Encode encodes data if the input isn't already encoded using base64.StdEncoding.EncodeToString.
@@ -49,6 +52,15 @@ func isResourceNotFoundError(err error) bool {
 	return ok
 }
 
+// isNotFound tells whether a failed BTP call answered that the requested resource doesn't exist, either through the
+// response status or through the code of the error in the response body.
+func isNotFound(response types.StatusAndBodyFromResponse, apiError *types.Error) bool {
+	if response.StatusCode == http.StatusNotFound {
+		return true
+	}
+	return apiError != nil && sap.Int32Value(apiError.Code) == http.StatusNotFound
+}
+
 func isResourceTimeoutError(err error) bool {
 	timeoutErr, ok := err.(*resource.TimeoutError)
 	return ok && timeoutErr.LastError == nil